@@ -1,54 +1,569 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"flag"
 	"fmt"
+	"net"
 	"net/http"
+	"net/mail"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+	_ "time/tzdata" // booking timezones must resolve on hosts without zoneinfo
 
 	"github.com/gookit/slog"
 
+	"portfolio-backend/internal/botdetect"
 	"portfolio-backend/internal/config"
+	"portfolio-backend/internal/envelope"
+	"portfolio-backend/internal/geo"
 	"portfolio-backend/internal/handler"
 	"portfolio-backend/internal/logger"
 	"portfolio-backend/internal/middleware"
+	"portfolio-backend/internal/schedule"
 	"portfolio-backend/internal/service"
+	"portfolio-backend/internal/validation"
 )
 
 func main() {
 	logger.Init()
 	defer slog.MustFlush()
 
+	cfg, opts, err := config.Load(os.Args[1:])
+	var invalid *config.ValidationError
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return
+	case err != nil && !errors.As(err, &invalid):
+		// The flag set has already printed the error and usage.
+		os.Exit(2)
+	}
+	if problems := checkSettings(cfg); len(problems) > 0 {
+		if invalid == nil {
+			invalid = &config.ValidationError{}
+		}
+		invalid.Problems = append(invalid.Problems, problems...)
+	}
+	if opts.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if invalid != nil {
+			fmt.Fprintln(os.Stderr, invalid)
+			os.Exit(1)
+		}
+		return
+	}
+
 	slog.Info("Starting Bhavy Yadav Portfolio Backend")
+	if invalid != nil {
+		for _, p := range invalid.Problems {
+			slog.Error("[config] " + p)
+		}
+		slog.Fatal("Invalid configuration", "problems", len(invalid.Problems))
+	}
+	slog.WithData(cfg.Fields()).Info("Config loaded", "file", opts.File)
 
-	cfg := config.LoadFromEnv()
+	// ctx is cancelled on SIGINT or SIGTERM, which stops the background
+	// loops and starts draining the server.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	tasks := service.NewTasks()
+
+	sealer, err := newRecordSealer(cfg)
+	if err != nil {
+		slog.Fatal("Invalid encryption configuration", "error", err)
+	}
 
 	// Services
-	emailSvc := service.NewResendEmailService(cfg.ResendAPIKey, cfg.ToEmail)
-	chatSvc := service.NewGroqChatService(cfg.GroqAPIKey)
-	contactLog := service.NewFileContactLogger("contacts.log")
+	events := service.NewEventBus(cfg.Server.AdminEventBuffer)
+	outbox, err := service.NewFileOutbox(filepath.Join(cfg.Storage.DataDir, "outbox.json"))
+	if err != nil {
+		slog.Fatal("Failed to load outbox", "error", err)
+	}
+	emailSvc := service.NewOutboxEmailService(
+		service.NewResendEmailService(cfg.Email.ResendAPIKey, cfg.Email.From, cfg.Email.To), outbox, cfg.Email.To, events)
+	chatSvc := service.NewGroqChatService(cfg.Chat.GroqAPIKey, events)
+	chatStore, err := service.NewFileChatStore(filepath.Join(cfg.Storage.DataDir, "chat.json"), sealer)
+	if err != nil {
+		slog.Fatal("Failed to load chat store", "error", err)
+	}
+	contactLog := service.NewFileContactLogger("contacts.log", sealer)
+	contactStore, err := service.NewFileContactStore(
+		filepath.Join(cfg.Storage.DataDir, "contacts.json"), service.MessageDomain(cfg.Email.From), sealer)
+	if err != nil {
+		slog.Fatal("Failed to load contact store", "error", err)
+	}
+	threads := service.NewContactThreads(contactStore, emailSvc, cfg.Email.From, sealer)
+
+	var hooks []service.WebhookTarget
+	for _, wh := range cfg.Notify.Webhooks {
+		hooks = append(hooks, service.WebhookTarget{Kind: wh.Kind, URL: wh.URL, Secret: wh.Secret, Enabled: !wh.Disabled})
+	}
+	notifier := service.NewWebhookNotifier(hooks, cfg.Notify.Timeout, cfg.Notify.Retries, events)
+
+	var leadLLM service.CompletionProvider
+	if cfg.Chat.LeadLLM {
+		leadLLM = chatSvc
+	}
+	leads := service.NewLeadRouter(service.NewLeadScorer(leadLLM, cfg.Chat.LeadHighScore), contactStore, notifier)
+
+	blobs := service.NewFileBlobStore(filepath.Join(cfg.Storage.DataDir, "blobs"), sealer)
+	attachPolicy := service.AttachmentPolicy{
+		MaxFiles:     cfg.Email.AttachmentMaxFiles,
+		MaxFileSize:  int64(cfg.Email.AttachmentMaxSizeMB) << 20,
+		MaxTotalSize: int64(cfg.Email.AttachmentMaxTotalMB) << 20,
+		AllowedTypes: cfg.Email.AttachmentTypes,
+	}
+
+	validatorOpts := validation.Options{DisposableFile: cfg.Email.DisposableDomainsFile}
+	if cfg.Email.ValidateMX {
+		validatorOpts.Resolver = net.DefaultResolver
+	}
+	contactValidator, err := validation.NewContactValidator(validatorOpts)
+	if err != nil {
+		slog.Fatal("Failed to load contact validator", "error", err)
+	}
+
+	bookingStore, err := service.NewFileBookingStore(filepath.Join(cfg.Storage.DataDir, "bookings.json"))
+	if err != nil {
+		slog.Fatal("Failed to load booking store", "error", err)
+	}
+	booking := service.NewBookingService(bookingStore, emailSvc, loadAvailability(cfg), service.MeetingInfo{
+		Title:     cfg.Booking.Title,
+		Location:  cfg.Booking.Location,
+		OwnerName: ownerName(cfg.Email.From),
+		PublicURL: cfg.Server.PublicURL,
+	}, service.BookingPolicy{
+		PerHour:    cfg.Booking.PerHour,
+		ConfirmTTL: cfg.Booking.ConfirmTTL,
+	}, cfg.Email.To, service.MessageDomain(cfg.Email.From))
+
+	subscribers, err := service.NewFileSubscriberStore(filepath.Join(cfg.Storage.DataDir, "subscribers.json"))
+	if err != nil {
+		slog.Fatal("Failed to load subscriber store", "error", err)
+	}
+
+	guestbookStore, err := service.NewFileGuestbookStore(filepath.Join(cfg.Storage.DataDir, "guestbook.json"))
+	if err != nil {
+		slog.Fatal("Failed to load guestbook", "error", err)
+	}
+
+	auditLog := service.NewFileAuditLog(filepath.Join(cfg.Storage.DataDir, "audit.log"))
+	privacy := service.NewPrivacyService(
+		service.PrivacyStores{
+			Contacts:    contactStore,
+			Chat:        chatStore,
+			Outbox:      outbox,
+			Blobs:       blobs,
+			Bookings:    bookingStore,
+			Subscribers: subscribers,
+			Guestbook:   guestbookStore,
+		},
+		auditLog, sealer,
+		service.RetentionPolicy{
+			MaxAge: time.Duration(cfg.Storage.RetentionDays) * 24 * time.Hour,
+			Mode:   cfg.Storage.RetentionMode,
+		},
+		[]service.LogFiles{
+			{Glob: "contacts.log", Lock: contactLog.Locker()},
+			{Glob: filepath.Join("logs", "app.log*"), Lock: logger.FileLock()},
+		},
+	)
+
+	newsletterKey := []byte(cfg.Newsletter.Secret)
+	if cfg.Newsletter.Secret == "" {
+		if newsletterKey, err = service.LoadOrCreateKey(filepath.Join(cfg.Storage.DataDir, "newsletter.key")); err != nil {
+			slog.Fatal("Failed to load newsletter key", "error", err)
+		}
+	}
+	issues, err := service.NewFileIssueStore(filepath.Join(cfg.Storage.DataDir, "newsletter_sends.json"))
+	if err != nil {
+		slog.Fatal("Failed to load newsletter sends", "error", err)
+	}
+	newsletter := service.NewNewsletterService(subscribers, issues, emailSvc, tasks, newsletterKey, cfg.Server.PublicURL,
+		cfg.Newsletter.ConfirmTTL, float64(cfg.Newsletter.PerMinute)/60)
+
+	geoDB, err := geo.Open(cfg.Analytics.GeoIPDB)
+	switch {
+	case errors.Is(err, geo.ErrNoDatabase):
+		slog.Info("[geo] No GeoIP database; visitor locations disabled", "path", cfg.Analytics.GeoIPDB)
+	case err != nil:
+		slog.Error("[geo] Failed to open GeoIP database; visitor locations disabled", "error", err)
+	default:
+		slog.Info("[geo] GeoIP database loaded", "path", cfg.Analytics.GeoIPDB, "database", geoDB.Describe())
+	}
+	locator := handler.NewClientLocator(geoDB, cfg.Server.TrustProxy)
+
+	visitorStats := service.NewVisitorStats(8 * 24 * time.Hour)
+	visitorHistory, err := service.NewVisitorHistory(filepath.Join(cfg.Storage.DataDir, "visitor_history.json"))
+	if err != nil {
+		slog.Fatal("Failed to load visitor history", "error", err)
+	}
+	analytics, err := service.NewAnalyticsService(filepath.Join(cfg.Storage.DataDir, "analytics.json"),
+		time.Duration(cfg.Analytics.HourlyDays)*24*time.Hour, time.Duration(cfg.Analytics.DailyDays)*24*time.Hour)
+	if err != nil {
+		slog.Fatal("Failed to load analytics", "error", err)
+	}
+	digest := service.NewDigestService(
+		contactStore, chatStore, outbox, visitorStats, emailSvc, sealer, cfg.Email.To, cfg.Email.DigestDryRun, os.Stdout,
+	)
 
 	// Handlers
-	contactH := handler.NewContactHandler(emailSvc, contactLog)
-	chatH := handler.NewChatHandler(chatSvc)
+	contactH := handler.NewContactHandler(emailSvc, contactLog, contactStore, leads, blobs, attachPolicy, contactValidator, locator, events, tasks)
+	adminContactH := handler.NewAdminContactHandler(contactStore, threads, blobs, sealer)
+	inboundH := handler.NewInboundEmailHandler(threads, cfg.Email.InboundSecret)
+	adminPrivacyH := handler.NewAdminPrivacyHandler(privacy)
+	adminEncryptionH := handler.NewAdminEncryptionHandler(sealer.Keys(), map[string]handler.Resealer{
+		"contacts":    contactStore,
+		"chat":        chatStore,
+		"attachments": blobs,
+		"contactLog":  contactLog,
+	}, auditLog)
+	chatH := handler.NewChatHandler(chatSvc, chatStore, notifier, cfg.Notify.ChatIntents, events, tasks)
 	healthH := handler.NewHealthHandler()
-	visitorH := handler.NewVisitorHandler()
+	reactions, err := service.NewFileReactionStore(filepath.Join(cfg.Storage.DataDir, "reactions.json"), cfg.Visitors.ReactionMaxItems)
+	if err != nil {
+		slog.Fatal("Failed to load reactions", "error", err)
+	}
+	reactionPolicy := service.ReactionPolicy{
+		Emojis:    cfg.Visitors.ReactionEmojis,
+		Items:     cfg.Visitors.ReactionItems,
+		PerSecond: float64(cfg.Visitors.ReactionRate),
+		Burst:     cfg.Visitors.ReactionBurst,
+	}
+	origins := middleware.NewOriginPolicy(cfg.Server.AllowedOrigins)
+	if err := origins.AllowURL(cfg.Server.PublicURL); err != nil {
+		slog.Fatal("Invalid public URL", "error", err)
+	}
+	if origins.AllowsAny() {
+		slog.Warn("[cors] No ALLOWED_ORIGINS set; any site may call the API and open WebSockets")
+	}
+	visitorLimiter := handler.NewConnectionLimiter(handler.ConnectionLimits{
+		Max:       cfg.Server.WSMaxConnections,
+		PerIP:     cfg.Server.WSMaxPerIP,
+		PerMinute: cfg.Server.WSUpgradesPerMinute,
+		Burst:     cfg.Server.WSUpgradeBurst,
+	})
+	if !cfg.Server.TrustProxy && (cfg.Server.WSMaxPerIP > 0 || cfg.Server.WSUpgradesPerMinute > 0) {
+		slog.Warn("[ws] Per-address connection limits are on without TRUST_PROXY; behind a reverse proxy every visitor shares its address",
+			"maxPerIP", cfg.Server.WSMaxPerIP, "upgradesPerMinute", cfg.Server.WSUpgradesPerMinute)
+	}
+	bots, err := botdetect.New(cfg.Security.BotAgentsFile)
+	if err != nil {
+		slog.Fatal("Failed to load bot agents", "error", err)
+	}
+	backplane := presenceBackplane(cfg)
+	visitorH := handler.NewVisitorHandler(handler.VisitorOptions{
+		Stats:          visitorStats,
+		History:        visitorHistory,
+		Analytics:      analytics,
+		Backplane:      backplane,
+		Heartbeat:      cfg.Presence.Heartbeat,
+		Locator:        locator,
+		Events:         events,
+		Reactions:      reactions,
+		ReactionPolicy: reactionPolicy,
+		Bots:           bots,
+		BotGrace:       cfg.Security.BotGrace,
+		Origins:        origins,
+		Limiter:        visitorLimiter,
+	})
+	reactionH := handler.NewReactionHandler(reactions, reactionPolicy)
+	guestbook := service.NewGuestbookService(guestbookStore, events, cfg.Visitors.GuestbookPerHour)
+	guestbookH := handler.NewGuestbookHandler(guestbook, contactValidator, locator)
+	adminGuestbookH := handler.NewAdminGuestbookHandler(guestbook, visitorH)
+	analyticsH := handler.NewAnalyticsHandler(analytics, locator, bots)
+	adminAnalyticsH := handler.NewAdminAnalyticsHandler(analytics)
+	adminLiveH := handler.NewAdminLiveHandler(events, origins)
+	adminMetricsH := handler.NewAdminMetricsHandler(map[string]*handler.ConnectionLimiter{"visitors": visitorLimiter})
+	adminDigestH := handler.NewAdminDigestHandler(digest)
+	bookingH := handler.NewBookingHandler(booking, contactValidator, locator, tasks)
+	subscribeH := handler.NewSubscribeHandler(newsletter, contactValidator)
+	adminNewsletterH := handler.NewAdminNewsletterHandler(newsletter)
 
-	go visitorH.RunHub()
+	// The hub runs apart from tasks: it must stop before the final flushes,
+	// which the other loops leave to shutdown.
+	hubDone := make(chan struct{})
+	go func() {
+		visitorH.RunHub(ctx)
+		close(hubDone)
+	}()
+	tasks.Go(func(context.Context) { analytics.Run(ctx, time.Minute) })
+	tasks.Go(func(context.Context) { reactions.Run(ctx, 10*time.Second) })
+	tasks.Go(func(context.Context) { visitorHistory.Run(ctx, 5*time.Minute) })
+	tasks.Go(func(context.Context) { bots.Run(ctx, time.Minute) })
+	tasks.Go(func(context.Context) { privacy.RunRetention(ctx, cfg.Storage.RetentionInterval) })
+	newsletter.Resume()
+	for _, d := range []struct {
+		period, expr string
+		window       time.Duration
+	}{
+		{service.DigestDaily, cfg.Email.DigestDaily, 24 * time.Hour},
+		{service.DigestWeekly, cfg.Email.DigestWeekly, 7 * 24 * time.Hour},
+	} {
+		if d.expr == "" {
+			continue
+		}
+		sched, err := schedule.Parse(d.expr)
+		if err != nil {
+			slog.Fatal("Invalid digest schedule", "period", d.period, "error", err)
+		}
+		period, window := d.period, d.window
+		tasks.Go(func(context.Context) { digest.Run(ctx, period, sched, window) })
+	}
 
 	// Routes
 	mux := http.NewServeMux()
-	mux.HandleFunc("/api/contact", middleware.CORS(contactH.Handle))
-	mux.HandleFunc("/api/chat", middleware.CORS(chatH.Handle))
-	mux.HandleFunc("/api/health", middleware.CORS(healthH.Handle))
-	mux.HandleFunc("/ws/visitors", visitorH.Handle)
-	mux.HandleFunc("/", middleware.CORS(healthH.Handle))
+	mux.HandleFunc("/api/contact", middleware.CORS(origins, contactH.Handle))
+	mux.HandleFunc("/api/chat", middleware.CORS(origins, chatH.Handle))
+	mux.HandleFunc("/api/health", middleware.CORS(origins, healthH.Handle))
+	mux.HandleFunc("/api/booking", middleware.CORS(origins, bookingH.Handle))
+	mux.HandleFunc("/api/booking/", middleware.CORS(origins, bookingH.Handle))
+	mux.HandleFunc("/api/subscribe", middleware.CORS(origins, subscribeH.Handle))
+	mux.HandleFunc("/api/subscribe/", middleware.CORS(origins, subscribeH.Handle))
+	mux.HandleFunc("/api/analytics/collect", middleware.CORS(origins, analyticsH.Handle))
+	mux.HandleFunc("/ws/visitors", visitorLimiter.CheckOrigin(origins, visitorH.Handle))
+	mux.HandleFunc("/api/visitors", middleware.CORS(origins, visitorH.Count))
+	mux.HandleFunc("/api/visitors/stream", visitorLimiter.CheckOrigin(origins, middleware.CORS(origins, visitorH.Stream)))
+	mux.HandleFunc("/api/visitors/history", middleware.CORS(origins, visitorH.History))
+	mux.HandleFunc("/api/ws/schema", middleware.CORS(origins, visitorH.Schema))
+	mux.HandleFunc("/api/reactions", middleware.CORS(origins, reactionH.Handle))
+	mux.HandleFunc("/api/guestbook", middleware.CORS(origins, guestbookH.Handle))
+	mux.HandleFunc("/ws/admin", middleware.AdminAuth(cfg.Security.AdminToken, adminLiveH.Handle))
+	mux.HandleFunc("/api/admin/contacts", middleware.CORS(origins, middleware.AdminAuth(cfg.Security.AdminToken, adminContactH.Handle)))
+	mux.HandleFunc("/api/admin/contacts/", middleware.CORS(origins, middleware.AdminAuth(cfg.Security.AdminToken, adminContactH.Handle)))
+	mux.HandleFunc("/api/admin/privacy/", middleware.CORS(origins, middleware.AdminAuth(cfg.Security.AdminToken, adminPrivacyH.Handle)))
+	mux.HandleFunc("/api/admin/encryption/", middleware.CORS(origins, middleware.AdminAuth(cfg.Security.AdminToken, adminEncryptionH.Handle)))
+	mux.HandleFunc("/api/admin/newsletter/", middleware.CORS(origins, middleware.AdminAuth(cfg.Security.AdminToken, adminNewsletterH.Handle)))
+	mux.HandleFunc("/api/admin/digest", middleware.CORS(origins, middleware.AdminAuth(cfg.Security.AdminToken, adminDigestH.Handle)))
+	mux.HandleFunc("/api/admin/analytics", middleware.CORS(origins, middleware.AdminAuth(cfg.Security.AdminToken, adminAnalyticsH.Handle)))
+	mux.HandleFunc("/api/admin/guestbook", middleware.CORS(origins, middleware.AdminAuth(cfg.Security.AdminToken, adminGuestbookH.Handle)))
+	mux.HandleFunc("/api/admin/guestbook/", middleware.CORS(origins, middleware.AdminAuth(cfg.Security.AdminToken, adminGuestbookH.Handle)))
+	mux.HandleFunc("/api/admin/metrics", middleware.CORS(origins, middleware.AdminAuth(cfg.Security.AdminToken, adminMetricsH.Handle)))
+	mux.HandleFunc("/api/inbound/email", inboundH.Handle)
+	mux.HandleFunc("/", middleware.CORS(origins, healthH.Handle))
 
-	addr := fmt.Sprintf(":%s", cfg.Port)
+	addr := fmt.Sprintf(":%s", cfg.Server.Port)
 	slog.WithData(slog.M{
-		"addr":      addr,
-		"endpoints": []string{"/api/contact", "/api/chat", "/api/health", "/ws/visitors"},
+		"addr": addr,
+		"endpoints": []string{
+			"/api/contact", "/api/chat", "/api/health", "/ws/visitors", "/ws/admin", "/api/ws/schema",
+			"/api/visitors", "/api/visitors/stream", "/api/visitors/history",
+			"/api/booking", "/api/booking/slots", "/api/subscribe", "/api/analytics/collect", "/api/reactions", "/api/guestbook",
+			"/api/admin/contacts", "/api/admin/privacy/", "/api/admin/encryption/",
+			"/api/admin/digest", "/api/admin/newsletter/", "/api/admin/analytics", "/api/admin/guestbook", "/api/admin/metrics",
+			"/api/inbound/email",
+		},
 	}).Info("Server listening")
 
-	if err := http.ListenAndServe(addr, mux); err != nil {
+	srv := &http.Server{Addr: addr, Handler: mux}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.ListenAndServe() }()
+	select {
+	case err := <-serveErr:
 		slog.Fatal("Server failed", "error", err)
+	case <-ctx.Done():
+	}
+	stop()
+	shutdown(cfg, srv, visitorH, hubDone, adminLiveH, tasks, analytics, reactions, visitorHistory, backplane)
+}
+
+// shutdown drains the server within cfg.Server.ShutdownTimeout: it stops accepting
+// connections, closes WebSockets with a going-away frame, waits for
+// in-flight requests and background tasks, then saves buffered data and
+// leaves the presence backplane.
+func shutdown(
+	cfg config.Config,
+	srv *http.Server,
+	visitorH *handler.VisitorHandler,
+	hubDone <-chan struct{},
+	adminLiveH *handler.AdminLiveHandler,
+	tasks *service.Tasks,
+	analytics *service.AnalyticsService,
+	reactions *service.FileReactionStore,
+	history *service.VisitorHistory,
+	backplane service.PresenceBackplane,
+) {
+	slog.Info("Shutting down", "timeout", cfg.Server.ShutdownTimeout.String())
+	drainCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	// Shutdown does not track WebSockets, and event streams only end once
+	// the hub has stopped, so the hub and admin feed close theirs meanwhile.
+	drained := make(chan error, 1)
+	go func() { drained <- srv.Shutdown(drainCtx) }()
+	adminLiveH.Shutdown()
+	select {
+	case <-hubDone:
+	case <-drainCtx.Done():
+		slog.Warn("[shutdown] Visitor hub did not stop in time")
+	}
+	if err := <-drained; err != nil {
+		slog.Warn("[shutdown] Requests still in flight", "error", err)
+	}
+	if err := tasks.Wait(drainCtx); err != nil {
+		slog.Warn("[shutdown] Background tasks still running", "error", err)
+	}
+
+	for name, flush := range map[string]func() error{
+		"analytics": analytics.Flush,
+		"reactions": reactions.Flush,
+		"history":   history.Flush,
+	} {
+		if err := flush(); err != nil {
+			slog.Error("[shutdown] Failed to save", "data", name, "error", err)
+		}
+	}
+	if err := backplane.Close(); err != nil {
+		slog.Error("[shutdown] Failed to leave presence backplane", "error", err)
+	}
+	slog.Info("Server stopped")
+}
+
+// checkSettings parses the settings that config leaves to the packages using
+// them, so every problem is reported at startup alongside config's own.
+func checkSettings(cfg config.Config) []string {
+	var problems []string
+	fail := func(path, format string, args ...any) {
+		problems = append(problems, config.Setting(path)+": "+fmt.Sprintf(format, args...))
+	}
+
+	for _, d := range []struct{ path, expr string }{
+		{"email.digest_daily", cfg.Email.DigestDaily},
+		{"email.digest_weekly", cfg.Email.DigestWeekly},
+	} {
+		if d.expr == "" {
+			continue
+		}
+		if _, err := schedule.Parse(d.expr); err != nil {
+			fail(d.path, "%v", err)
+		}
+	}
+
+	if mode := cfg.Storage.RetentionMode; mode != service.RetentionAnonymize && mode != service.RetentionPurge {
+		fail("storage.retention_mode", "%q is not %q or %q", mode, service.RetentionAnonymize, service.RetentionPurge)
+	}
+
+	if sec := cfg.Security; sec.EncryptionKeys != "" {
+		if keys, _, err := envelope.ParseKeys(sec.EncryptionKeys); err != nil {
+			// The error may quote the entry, so it is not repeated.
+			fail("security.encryption_keys", "want comma-separated id:base64 pairs")
+		} else if _, ok := keys[sec.EncryptionActiveKey]; sec.EncryptionActiveKey != "" && !ok {
+			fail("security.encryption_active_key", "%q is not one of security.encryption_keys", sec.EncryptionActiveKey)
+		}
+	}
+
+	if _, err := service.ParseWeeklyHours(cfg.Booking.Hours); err != nil {
+		fail("booking.hours", "%v", err)
+	}
+	if _, err := service.ParseBlackout(cfg.Booking.Blackout); err != nil {
+		fail("booking.blackout", "%v", err)
+	}
+	return problems
+}
+
+// newRecordSealer builds the encryption-at-rest sealer from config. With no
+// keys configured it returns a disabled sealer and data is stored in clear.
+func newRecordSealer(cfg config.Config) (*service.RecordSealer, error) {
+	if cfg.Security.EncryptionKeys == "" {
+		slog.Warn("[encryption] No ENCRYPTION_KEYS set; contacts and chat are stored unencrypted")
+		return service.NewRecordSealer(nil, nil), nil
+	}
+
+	keys, order, err := envelope.ParseKeys(cfg.Security.EncryptionKeys)
+	if err != nil {
+		return nil, err
+	}
+	active := cfg.Security.EncryptionActiveKey
+	if active == "" {
+		active = order[len(order)-1]
+	}
+	keyring, err := envelope.NewKeyring(keys, active)
+	if err != nil {
+		return nil, err
+	}
+
+	// The email index must stay stable across rotations, so it defaults to
+	// a key derived from the first (oldest) listed key rather than the active one.
+	var indexKey []byte
+	if cfg.Security.EncryptionIndexKey != "" {
+		if indexKey, err = base64.StdEncoding.DecodeString(cfg.Security.EncryptionIndexKey); err != nil {
+			return nil, fmt.Errorf("ENCRYPTION_INDEX_KEY: %w", err)
+		}
+	} else {
+		sum := sha256.Sum256(append([]byte("email-index:"), keys[order[0]]...))
+		indexKey = sum[:]
+	}
+
+	slog.Info("[encryption] Encryption at rest enabled", "activeKey", active, "keys", keyring.KeyIDs())
+	return service.NewRecordSealer(keyring, indexKey), nil
+}
+
+// loadAvailability builds booking availability from the configuration,
+// exiting on invalid rules.
+func loadAvailability(cfg config.Config) service.Availability {
+	loc, err := time.LoadLocation(cfg.Booking.Timezone)
+	if err != nil {
+		slog.Fatal("Invalid BOOKING_TIMEZONE", "error", err)
+	}
+	hours, err := service.ParseWeeklyHours(cfg.Booking.Hours)
+	if err != nil {
+		slog.Fatal("Invalid BOOKING_HOURS", "error", err)
+	}
+	blackout, err := service.ParseBlackout(cfg.Booking.Blackout)
+	if err != nil {
+		slog.Fatal("Invalid BOOKING_BLACKOUT", "error", err)
+	}
+	return service.Availability{
+		Location:  loc,
+		Hours:     hours,
+		Slot:      cfg.Booking.Slot,
+		Buffer:    cfg.Booking.Buffer,
+		MinNotice: cfg.Booking.MinNotice,
+		Horizon:   time.Duration(cfg.Booking.HorizonDays) * 24 * time.Hour,
+		Blackout:  blackout,
+	}
+}
+
+// ownerName returns the display name of an address such as
+// "Portfolio <hi@example.com>", falling back to the address itself.
+func ownerName(from string) string {
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return from
+	}
+	if addr.Name != "" {
+		return addr.Name
+	}
+	return addr.Address
+}
+
+// presenceBackplane connects to Redis when REDIS_URL is set, exiting if it
+// is unreachable, and otherwise keeps presence in-process.
+func presenceBackplane(cfg config.Config) service.PresenceBackplane {
+	instance := cfg.Presence.Instance
+	if instance == "" {
+		host, _ := os.Hostname()
+		instance = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	if cfg.Presence.RedisURL == "" {
+		return service.NewMemoryBus().Backplane(instance)
+	}
+	client, err := service.NewRedisClient(cfg.Presence.RedisURL)
+	if err != nil {
+		slog.Fatal("Failed to connect to Redis", "error", err)
+	}
+	backplane, err := service.NewRedisBackplane(client, instance, cfg.Presence.TTL)
+	if err != nil {
+		slog.Fatal("Failed to join presence backplane", "error", err)
 	}
+	return backplane
 }
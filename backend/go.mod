@@ -2,18 +2,26 @@ module portfolio-backend
 
 go 1.21
 
-require github.com/gorilla/websocket v1.5.1
+require (
+	github.com/BurntSushi/toml v1.4.0
+	github.com/gookit/slog v0.6.0
+	github.com/gorilla/websocket v1.5.1
+	github.com/oschwald/maxminddb-golang v1.13.1
+	github.com/redis/go-redis/v9 v9.7.3
+	golang.org/x/text v0.22.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
 	github.com/gookit/color v1.6.0 // indirect
 	github.com/gookit/goutil v0.7.1 // indirect
 	github.com/gookit/gsr v0.1.1 // indirect
-	github.com/gookit/slog v0.6.0 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	github.com/xo/terminfo v0.0.0-20220910002029-abceb7e1c41e // indirect
 	golang.org/x/net v0.17.0 // indirect
 	golang.org/x/sync v0.11.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
 	golang.org/x/term v0.29.0 // indirect
-	golang.org/x/text v0.22.0 // indirect
 )
@@ -0,0 +1,181 @@
+// Package botdetect tells crawlers, uptime monitors and scripts apart from
+// people, so they can be left out of visitor counts and analytics.
+//
+// Requests are checked against User-Agent patterns (an embedded list plus
+// an optional file that is re-read when it changes) and headers real
+// browsers always send. Signals that only show over time, such as how long
+// a connection lasts and whether it ever interacts, are applied by callers
+// using the Signal constants.
+package botdetect
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gookit/slog"
+)
+
+//go:embed agents.txt
+var defaultAgents []byte
+
+// Signals that mark a client as a bot.
+const (
+	// SignalUserAgent: the User-Agent matches a bot pattern or is empty.
+	SignalUserAgent = "user-agent"
+	// SignalHeadless: headless or automated browser hints.
+	SignalHeadless = "headless"
+	// SignalNoOrigin: a WebSocket handshake without the Origin header every
+	// browser sends.
+	SignalNoOrigin = "no-origin"
+	// SignalShortSession: the connection closed before interacting or
+	// lasting long enough to count.
+	SignalShortSession = "short-session"
+)
+
+// emptyAgent is the agent reported for requests without a User-Agent.
+const emptyAgent = "(empty)"
+
+// Classifier matches requests against the bot patterns.
+type Classifier struct {
+	extraFile string
+
+	mu       sync.RWMutex
+	patterns []string
+	modTime  time.Time
+}
+
+// New loads the embedded patterns plus those in extraFile, if set.
+func New(extraFile string) (*Classifier, error) {
+	c := &Classifier{extraFile: extraFile, patterns: parsePatterns(defaultAgents)}
+	if _, err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload re-reads the extra pattern file if it changed since it was last
+// read, and reports whether it did. A file that disappears leaves only the
+// embedded patterns.
+func (c *Classifier) Reload() (bool, error) {
+	if c.extraFile == "" {
+		return false, nil
+	}
+	info, err := os.Stat(c.extraFile)
+	if errors.Is(err, os.ErrNotExist) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.modTime.IsZero() {
+			return false, nil
+		}
+		c.patterns, c.modTime = parsePatterns(defaultAgents), time.Time{}
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("stat bot agents: %w", err)
+	}
+
+	c.mu.RLock()
+	unchanged := info.ModTime().Equal(c.modTime)
+	c.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+	data, err := os.ReadFile(c.extraFile)
+	if err != nil {
+		return false, fmt.Errorf("read bot agents: %w", err)
+	}
+	patterns := append(parsePatterns(defaultAgents), parsePatterns(data)...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.patterns, c.modTime = patterns, info.ModTime()
+	return true, nil
+}
+
+// Run reloads the extra pattern file every interval until ctx is done.
+func (c *Classifier) Run(ctx context.Context, interval time.Duration) {
+	if c.extraFile == "" {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		changed, err := c.Reload()
+		switch {
+		case err != nil:
+			slog.Error("[bots] Failed to reload bot agents", "error", err)
+		case changed:
+			slog.Info("[bots] Reloaded bot agents", "file", c.extraFile, "patterns", c.Patterns())
+		}
+	}
+}
+
+// Patterns returns the number of User-Agent patterns in use.
+func (c *Classifier) Patterns() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.patterns)
+}
+
+// Agent returns the bot pattern userAgent matches, "(empty)" when it is
+// blank, or "" when it looks like a browser.
+func (c *Classifier) Agent(userAgent string) string {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return emptyAgent
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, p := range c.patterns {
+		if strings.Contains(ua, p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// Request returns the bot signals r shows, or nil if it looks like a
+// browser. requireOrigin applies to WebSocket handshakes, where browsers
+// always send Origin.
+func (c *Classifier) Request(r *http.Request, requireOrigin bool) []string {
+	var signals []string
+	if c.Agent(r.UserAgent()) != "" {
+		signals = append(signals, SignalUserAgent)
+	}
+	// Browsers send Accept-Language on every request; headless Chrome
+	// announces itself in client hints even with a spoofed User-Agent.
+	if r.Header.Get("Accept-Language") == "" || strings.Contains(strings.ToLower(r.Header.Get("Sec-CH-UA")), "headless") {
+		signals = append(signals, SignalHeadless)
+	}
+	if requireOrigin && r.Header.Get("Origin") == "" {
+		signals = append(signals, SignalNoOrigin)
+	}
+	return signals
+}
+
+func parsePatterns(data []byte) []string {
+	var out []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.ToLower(strings.TrimSpace(sc.Text()))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		out = append(out, line)
+	}
+	return out
+}
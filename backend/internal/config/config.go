@@ -1,41 +1,273 @@
 package config
 
 import (
-	"os"
-
-	"github.com/gookit/slog"
+	"time"
 )
 
-// Config holds application configuration loaded from environment variables.
+// Config holds the application configuration. Each section can be set in a
+// YAML or TOML file under its yaml/toml key, overridden by the environment
+// variable in its env tag and then by a --section.field flag; see Load.
+// Fields tagged secret are redacted when the configuration is logged or
+// printed.
 type Config struct {
-	Port         string
-	ToEmail      string
-	GroqAPIKey   string
-	ResendAPIKey string
-}
-
-// LoadFromEnv reads configuration from environment variables with sensible defaults.
-func LoadFromEnv() Config {
-	cfg := Config{
-		Port:         getEnv("PORT", "8080"),
-		ToEmail:      getEnv("TO_EMAIL", "yadavbhavy25@gmail.com"),
-		GroqAPIKey:   getEnv("GROQ_API_KEY", ""),
-		ResendAPIKey: getEnv("RESEND_API_KEY", ""),
-	}
+	Server     ServerConfig     `yaml:"server" toml:"server"`
+	Chat       ChatConfig       `yaml:"chat" toml:"chat"`
+	Email      EmailConfig      `yaml:"email" toml:"email"`
+	Storage    StorageConfig    `yaml:"storage" toml:"storage"`
+	Security   SecurityConfig   `yaml:"security" toml:"security"`
+	Analytics  AnalyticsConfig  `yaml:"analytics" toml:"analytics"`
+	Notify     NotifyConfig     `yaml:"notify" toml:"notify"`
+	Booking    BookingConfig    `yaml:"booking" toml:"booking"`
+	Newsletter NewsletterConfig `yaml:"newsletter" toml:"newsletter"`
+	Presence   PresenceConfig   `yaml:"presence" toml:"presence"`
+	Visitors   VisitorsConfig   `yaml:"visitors" toml:"visitors"`
+}
+
+// ServerConfig covers the HTTP listener and connection limits.
+type ServerConfig struct {
+	Port string `yaml:"port" toml:"port" env:"PORT"`
+	// PublicURL is the externally reachable base URL of this server, used in
+	// links sent by email. It defaults to http://localhost:<port>.
+	PublicURL string `yaml:"public_url" toml:"public_url" env:"PUBLIC_URL"`
+
+	// ShutdownTimeout bounds how long a stopping server waits for requests
+	// and background emails to finish.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+
+	// TrustProxy takes client addresses from X-Forwarded-For, for
+	// deployments behind a single reverse proxy.
+	TrustProxy bool `yaml:"trust_proxy" toml:"trust_proxy" env:"TRUST_PROXY"`
+
+	// AllowedOrigins are the sites allowed to call the API and open
+	// WebSockets, e.g. https://example.com; empty allows any.
+	AllowedOrigins []string `yaml:"allowed_origins" toml:"allowed_origins" env:"ALLOWED_ORIGINS"`
+	// WSMaxConnections caps open visitor connections and WSMaxPerIP those
+	// of one client address; each address may open WSUpgradesPerMinute,
+	// WSUpgradeBurst at once. Zero disables a limit.
+	WSMaxConnections    int `yaml:"ws_max_connections" toml:"ws_max_connections" env:"WS_MAX_CONNECTIONS"`
+	WSMaxPerIP          int `yaml:"ws_max_per_ip" toml:"ws_max_per_ip" env:"WS_MAX_PER_IP"`
+	WSUpgradesPerMinute int `yaml:"ws_upgrades_per_minute" toml:"ws_upgrades_per_minute" env:"WS_UPGRADES_PER_MINUTE"`
+	WSUpgradeBurst      int `yaml:"ws_upgrade_burst" toml:"ws_upgrade_burst" env:"WS_UPGRADE_BURST"`
+
+	// AdminEventBuffer is how many recent events /ws/admin keeps for backfill.
+	AdminEventBuffer int `yaml:"admin_event_buffer" toml:"admin_event_buffer" env:"ADMIN_EVENT_BUFFER"`
+}
+
+// ChatConfig covers the chat assistant and lead scoring.
+type ChatConfig struct {
+	GroqAPIKey string `yaml:"groq_api_key" toml:"groq_api_key" env:"GROQ_API_KEY" secret:"true"`
+
+	// LeadLLM also asks the chat model to classify contacts; leads scoring
+	// at least LeadHighScore notify immediately.
+	LeadLLM       bool `yaml:"lead_llm_classify" toml:"lead_llm_classify" env:"LEAD_LLM_CLASSIFY"`
+	LeadHighScore int  `yaml:"lead_high_score" toml:"lead_high_score" env:"LEAD_HIGH_SCORE"`
+}
+
+// EmailConfig covers outgoing and inbound mail and the contact form.
+type EmailConfig struct {
+	// To receives contact messages, bookings and digests. It has no default.
+	To            string `yaml:"to" toml:"to" env:"TO_EMAIL"`
+	From          string `yaml:"from" toml:"from" env:"FROM_EMAIL"`
+	ResendAPIKey  string `yaml:"resend_api_key" toml:"resend_api_key" env:"RESEND_API_KEY" secret:"true"`
+	InboundSecret string `yaml:"inbound_secret" toml:"inbound_secret" env:"INBOUND_EMAIL_SECRET" secret:"true"`
+
+	DisposableDomainsFile string `yaml:"disposable_domains_file" toml:"disposable_domains_file" env:"DISPOSABLE_DOMAINS_FILE"`
+	ValidateMX            bool   `yaml:"validate_mx" toml:"validate_mx" env:"VALIDATE_MX"`
+
+	AttachmentMaxFiles   int      `yaml:"attachment_max_files" toml:"attachment_max_files" env:"ATTACHMENT_MAX_FILES"`
+	AttachmentMaxSizeMB  int      `yaml:"attachment_max_size_mb" toml:"attachment_max_size_mb" env:"ATTACHMENT_MAX_SIZE_MB"`
+	AttachmentMaxTotalMB int      `yaml:"attachment_max_total_mb" toml:"attachment_max_total_mb" env:"ATTACHMENT_MAX_TOTAL_MB"`
+	AttachmentTypes      []string `yaml:"attachment_types" toml:"attachment_types" env:"ATTACHMENT_TYPES"`
+
+	// DigestDaily and DigestWeekly are cron expressions; empty disables
+	// that digest.
+	DigestDaily  string `yaml:"digest_daily" toml:"digest_daily" env:"DIGEST_DAILY"`
+	DigestWeekly string `yaml:"digest_weekly" toml:"digest_weekly" env:"DIGEST_WEEKLY"`
+	DigestDryRun bool   `yaml:"digest_dry_run" toml:"digest_dry_run" env:"DIGEST_DRY_RUN"`
+}
+
+// StorageConfig covers persisted data and its retention.
+type StorageConfig struct {
+	DataDir string `yaml:"data_dir" toml:"data_dir" env:"DATA_DIR"`
+
+	RetentionDays     int           `yaml:"retention_days" toml:"retention_days" env:"RETENTION_DAYS"`
+	RetentionMode     string        `yaml:"retention_mode" toml:"retention_mode" env:"RETENTION_MODE"`
+	RetentionInterval time.Duration `yaml:"retention_interval" toml:"retention_interval" env:"RETENTION_INTERVAL"`
+}
+
+// SecurityConfig covers admin access, encryption at rest and bot detection.
+type SecurityConfig struct {
+	AdminToken string `yaml:"admin_token" toml:"admin_token" env:"ADMIN_TOKEN" secret:"true"`
+
+	EncryptionKeys      string `yaml:"encryption_keys" toml:"encryption_keys" env:"ENCRYPTION_KEYS" secret:"true"`
+	EncryptionActiveKey string `yaml:"encryption_active_key" toml:"encryption_active_key" env:"ENCRYPTION_ACTIVE_KEY"`
+	EncryptionIndexKey  string `yaml:"encryption_index_key" toml:"encryption_index_key" env:"ENCRYPTION_INDEX_KEY" secret:"true"`
+
+	// BotAgentsFile adds User-Agent patterns, one per line, to the built-in
+	// bot list; it is re-read when it changes. BotGrace is how long a
+	// visitor connection that never interacts must last to be counted.
+	BotAgentsFile string        `yaml:"bot_agents_file" toml:"bot_agents_file" env:"BOT_AGENTS_FILE"`
+	BotGrace      time.Duration `yaml:"bot_grace" toml:"bot_grace" env:"BOT_GRACE"`
+}
+
+// AnalyticsConfig covers rollup retention and GeoIP.
+type AnalyticsConfig struct {
+	HourlyDays int `yaml:"hourly_days" toml:"hourly_days" env:"ANALYTICS_HOURLY_DAYS"`
+	DailyDays  int `yaml:"daily_days" toml:"daily_days" env:"ANALYTICS_DAILY_DAYS"`
+
+	// GeoIPDB is the MaxMind-format database used to locate visitors; GeoIP
+	// is disabled when the file does not exist. It defaults to
+	// GeoLite2-City.mmdb in the data directory.
+	GeoIPDB string `yaml:"geoip_db" toml:"geoip_db" env:"GEOIP_DB"`
+}
+
+// NotifyConfig covers lead notifications.
+type NotifyConfig struct {
+	Timeout     time.Duration `yaml:"timeout" toml:"timeout" env:"NOTIFY_TIMEOUT"`
+	Retries     int           `yaml:"retries" toml:"retries" env:"NOTIFY_RETRIES"`
+	ChatIntents bool          `yaml:"chat_intents" toml:"chat_intents" env:"NOTIFY_CHAT_INTENTS"`
+
+	// Webhooks are set in the file as a list, or through
+	// NOTIFY_{SLACK,DISCORD,WEBHOOK}_{URL,SECRET,ENABLED}, which replace the
+	// file's target of the same kind.
+	Webhooks []WebhookConfig `yaml:"webhooks" toml:"webhooks"`
+}
+
+// WebhookConfig describes one outgoing notification target.
+type WebhookConfig struct {
+	Kind     string `yaml:"kind" toml:"kind"` // "slack", "discord" or "json"
+	URL      string `yaml:"url" toml:"url" secret:"true"`
+	Secret   string `yaml:"secret" toml:"secret" secret:"true"` // HMAC key, only used by "json" targets
+	Disabled bool   `yaml:"disabled" toml:"disabled"`
+}
+
+// BookingConfig covers meeting availability and invitations.
+type BookingConfig struct {
+	Hours       string        `yaml:"hours" toml:"hours" env:"BOOKING_HOURS"`
+	Timezone    string        `yaml:"timezone" toml:"timezone" env:"BOOKING_TIMEZONE"`
+	Slot        time.Duration `yaml:"slot" toml:"slot" env:"BOOKING_SLOT"`
+	Buffer      time.Duration `yaml:"buffer" toml:"buffer" env:"BOOKING_BUFFER"`
+	MinNotice   time.Duration `yaml:"min_notice" toml:"min_notice" env:"BOOKING_MIN_NOTICE"`
+	HorizonDays int           `yaml:"horizon_days" toml:"horizon_days" env:"BOOKING_HORIZON_DAYS"`
+	Blackout    []string      `yaml:"blackout" toml:"blackout" env:"BOOKING_BLACKOUT"`
+	Title       string        `yaml:"title" toml:"title" env:"BOOKING_TITLE"`
+	Location    string        `yaml:"location" toml:"location" env:"BOOKING_LOCATION"`
+	// PerHour is how many bookings one client address may request per hour;
+	// zero disables the limit.
+	PerHour int `yaml:"per_hour" toml:"per_hour" env:"BOOKING_PER_HOUR"`
+	// ConfirmTTL is how long a booking holds its slot while waiting for the
+	// visitor to confirm it from the emailed link.
+	ConfirmTTL time.Duration `yaml:"confirm_ttl" toml:"confirm_ttl" env:"BOOKING_CONFIRM_TTL"`
+}
+
+// NewsletterConfig covers subscriptions and issue delivery.
+type NewsletterConfig struct {
+	// Secret signs confirmation and unsubscribe links; when empty a key is
+	// generated in the data directory.
+	Secret     string        `yaml:"secret" toml:"secret" env:"NEWSLETTER_SECRET" secret:"true"`
+	ConfirmTTL time.Duration `yaml:"confirm_ttl" toml:"confirm_ttl" env:"NEWSLETTER_CONFIRM_TTL"`
+	PerMinute  int           `yaml:"rate_per_minute" toml:"rate_per_minute" env:"NEWSLETTER_RATE_PER_MINUTE"`
+}
+
+// PresenceConfig covers sharing visitor counts between instances.
+type PresenceConfig struct {
+	// RedisURL enables the Redis presence backplane so several instances
+	// report the same visitor totals; empty keeps presence in-process.
+	RedisURL  string        `yaml:"redis_url" toml:"redis_url" env:"REDIS_URL" secret:"url"`
+	Instance  string        `yaml:"instance" toml:"instance" env:"PRESENCE_INSTANCE"`
+	Heartbeat time.Duration `yaml:"heartbeat" toml:"heartbeat" env:"PRESENCE_HEARTBEAT"`
+	TTL       time.Duration `yaml:"ttl" toml:"ttl" env:"PRESENCE_TTL"`
+}
 
-	slog.WithData(slog.M{
-		"port":    cfg.Port,
-		"toEmail": cfg.ToEmail,
-		"groq":    cfg.GroqAPIKey != "",
-		"resend":  cfg.ResendAPIKey != "",
-	}).Info("Config loaded")
+// VisitorsConfig covers what visitors may send.
+type VisitorsConfig struct {
+	// ReactionEmojis are the reactions visitors may send; each connection
+	// may send ReactionBurst at once and ReactionRate per second after that.
+	ReactionEmojis []string `yaml:"reaction_emojis" toml:"reaction_emojis" env:"REACTION_EMOJIS"`
+	ReactionRate   int      `yaml:"reaction_rate" toml:"reaction_rate" env:"REACTION_RATE"`
+	ReactionBurst  int      `yaml:"reaction_burst" toml:"reaction_burst" env:"REACTION_BURST"`
+	// ReactionItems are the items visitors may react to, such as
+	// "project:graphql-parser"; when empty any item is accepted until
+	// ReactionMaxItems items have reactions (0 disables that cap).
+	ReactionItems    []string `yaml:"reaction_items" toml:"reaction_items" env:"REACTION_ITEMS"`
+	ReactionMaxItems int      `yaml:"reaction_max_items" toml:"reaction_max_items" env:"REACTION_MAX_ITEMS"`
 
-	return cfg
+	// GuestbookPerHour is how many guestbook entries one client address may
+	// submit an hour; 0 disables the limit.
+	GuestbookPerHour int `yaml:"guestbook_per_hour" toml:"guestbook_per_hour" env:"GUESTBOOK_PER_HOUR"`
 }
 
-func getEnv(key, fallback string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
+// Defaults returns the configuration used for everything not set
+// elsewhere.
+func Defaults() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:                "8080",
+			ShutdownTimeout:     30 * time.Second,
+			WSMaxConnections:    5000,
+			WSMaxPerIP:          20,
+			WSUpgradesPerMinute: 30,
+			WSUpgradeBurst:      10,
+			AdminEventBuffer:    500,
+		},
+		Chat: ChatConfig{
+			LeadHighScore: 50,
+		},
+		Email: EmailConfig{
+			From:                 "Portfolio <onboarding@resend.dev>",
+			AttachmentMaxFiles:   3,
+			AttachmentMaxSizeMB:  5,
+			AttachmentMaxTotalMB: 10,
+			AttachmentTypes: []string{
+				"application/pdf",
+				"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+				"application/vnd.oasis.opendocument.text",
+				"text/plain",
+				"image/png",
+				"image/jpeg",
+			},
+		},
+		Storage: StorageConfig{
+			DataDir:           "data",
+			RetentionMode:     "anonymize",
+			RetentionInterval: 24 * time.Hour,
+		},
+		Security: SecurityConfig{
+			BotGrace: 5 * time.Second,
+		},
+		Analytics: AnalyticsConfig{
+			HourlyDays: 14,
+			DailyDays:  730,
+		},
+		Notify: NotifyConfig{
+			Timeout: 5 * time.Second,
+			Retries: 3,
+		},
+		Booking: BookingConfig{
+			Hours:       "mon-fri 09:00-17:00",
+			Timezone:    "UTC",
+			Slot:        30 * time.Minute,
+			Buffer:      15 * time.Minute,
+			MinNotice:   12 * time.Hour,
+			PerHour:     3,
+			ConfirmTTL:  30 * time.Minute,
+			HorizonDays: 21,
+			Title:       "Intro call",
+		},
+		Newsletter: NewsletterConfig{
+			ConfirmTTL: 72 * time.Hour,
+			PerMinute:  60,
+		},
+		Presence: PresenceConfig{
+			Heartbeat: 10 * time.Second,
+			TTL:       30 * time.Second,
+		},
+		Visitors: VisitorsConfig{
+			ReactionEmojis:   []string{"👏", "❤️", "🔥", "🎉", "🤯"},
+			ReactionRate:     5,
+			ReactionBurst:    20,
+			ReactionMaxItems: 500,
+			GuestbookPerHour: 3,
+		},
 	}
-	return fallback
 }
@@ -0,0 +1,304 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Options are the command-line switches that are not configuration values.
+type Options struct {
+	// File is the YAML or TOML file given with --config or CONFIG_FILE.
+	File string
+	// PrintConfig asks for the effective configuration to be printed
+	// instead of starting the server.
+	PrintConfig bool
+}
+
+// ValidationError lists every problem found in the configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Load builds the configuration from, in increasing precedence, Defaults,
+// the config file, environment variables and the flags in args, then
+// validates it. Unset and empty variables leave the value alone.
+//
+// A *ValidationError lists all problems at once, including unparsable
+// values; the returned Config then holds whatever could be applied. Other
+// errors come from parsing args, and flag.ErrHelp is returned for -h.
+func Load(args []string) (Config, Options, error) {
+	cfg := Defaults()
+	var opts Options
+
+	fs := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	fs.StringVar(&opts.File, "config", "", "YAML or TOML config `file` (env CONFIG_FILE)")
+	fs.BoolVar(&opts.PrintConfig, "print-config", false, "print the effective configuration, secrets redacted, and exit")
+	var pending []*flagValue
+	visit(reflect.ValueOf(&cfg).Elem(), "", func(path string, f reflect.StructField, v reflect.Value) {
+		fv := &flagValue{v: v, isBool: v.Kind() == reflect.Bool, name: flagName(path), pending: &pending}
+		usage := "set " + path
+		if env := f.Tag.Get("env"); env != "" {
+			usage += " (env " + env + ")"
+		}
+		fs.Var(fv, fv.name, usage)
+	})
+	if err := fs.Parse(args); err != nil {
+		return cfg, opts, err
+	}
+	if fs.NArg() > 0 {
+		err := fmt.Errorf("unexpected argument %q", fs.Arg(0))
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		return cfg, opts, err
+	}
+
+	var problems []string
+	if opts.File == "" {
+		opts.File = os.Getenv("CONFIG_FILE")
+	}
+	if opts.File != "" {
+		problems = append(problems, loadFile(opts.File, &cfg)...)
+	}
+	problems = append(problems, loadEnv(&cfg)...)
+	// Flags were parsed before the file was read so --config could name it;
+	// their values are applied only now so they win over both.
+	for _, fv := range pending {
+		if err := setValue(fv.v, fv.raw); err != nil {
+			problems = append(problems, fmt.Sprintf("--%s: %v", fv.name, err))
+		}
+	}
+
+	if cfg.Server.PublicURL == "" {
+		cfg.Server.PublicURL = "http://localhost:" + cfg.Server.Port
+	}
+	if cfg.Analytics.GeoIPDB == "" {
+		cfg.Analytics.GeoIPDB = filepath.Join(cfg.Storage.DataDir, "GeoLite2-City.mmdb")
+	}
+
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return cfg, opts, &ValidationError{Problems: problems}
+	}
+	return cfg, opts, nil
+}
+
+// loadFile decodes a .yaml, .yml or .toml file over cfg. Keys that match no
+// setting are reported rather than ignored, so typos do not go unnoticed.
+func loadFile(path string, cfg *Config) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []string{fmt.Sprintf("config file: %v", err)}
+	}
+	var problems []string
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err := dec.Decode(cfg)
+		var typeErr *yaml.TypeError
+		switch {
+		case errors.As(err, &typeErr):
+			for _, e := range typeErr.Errors {
+				problems = append(problems, fmt.Sprintf("%s: %s", path, e))
+			}
+		case err != nil && !errors.Is(err, io.EOF):
+			problems = append(problems, fmt.Sprintf("%s: %v", path, err))
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return []string{fmt.Sprintf("%s: %v", path, err)}
+		}
+		for _, key := range md.Undecoded() {
+			problems = append(problems, fmt.Sprintf("%s: unknown setting %q", path, key.String()))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("%s: unsupported config file type %q (want .yaml, .yml or .toml)", path, ext))
+	}
+	return problems
+}
+
+// loadEnv applies the variables named in env tags, then the webhook
+// variables.
+func loadEnv(cfg *Config) []string {
+	var problems []string
+	visit(reflect.ValueOf(cfg).Elem(), "", func(path string, f reflect.StructField, v reflect.Value) {
+		env := f.Tag.Get("env")
+		raw := os.Getenv(env)
+		if env == "" || raw == "" {
+			return
+		}
+		if err := setValue(v, raw); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", env, err))
+		}
+	})
+	return append(problems, loadWebhooks(&cfg.Notify)...)
+}
+
+// loadWebhooks builds notification targets from NOTIFY_{SLACK,DISCORD,WEBHOOK}_URL,
+// replacing a target of the same kind from the file. A target is enabled
+// unless NOTIFY_<KIND>_ENABLED=false.
+func loadWebhooks(n *NotifyConfig) []string {
+	var problems []string
+	for _, t := range []struct{ kind, prefix string }{
+		{"slack", "NOTIFY_SLACK"},
+		{"discord", "NOTIFY_DISCORD"},
+		{"json", "NOTIFY_WEBHOOK"},
+	} {
+		url := os.Getenv(t.prefix + "_URL")
+		if url == "" {
+			continue
+		}
+		hook := WebhookConfig{Kind: t.kind, URL: url, Secret: os.Getenv(t.prefix + "_SECRET")}
+		if v := os.Getenv(t.prefix + "_ENABLED"); v != "" {
+			enabled, err := strconv.ParseBool(v)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s_ENABLED: invalid boolean %q", t.prefix, v))
+			}
+			hook.Disabled = !enabled
+		}
+		replaced := false
+		for i := range n.Webhooks {
+			if n.Webhooks[i].Kind == t.kind {
+				n.Webhooks[i], replaced = hook, true
+			}
+		}
+		if !replaced {
+			n.Webhooks = append(n.Webhooks, hook)
+		}
+	}
+	return problems
+}
+
+// visit calls fn for each setting in the struct v with its dotted yaml
+// path, e.g. "server.port". Lists of structs are walked element by element.
+func visit(v reflect.Value, prefix string, fn func(path string, f reflect.StructField, v reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		path := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		fv := v.Field(i)
+		switch {
+		case fv.Kind() == reflect.Struct:
+			visit(fv, path, fn)
+		case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Struct:
+			for j := 0; j < fv.Len(); j++ {
+				visit(fv.Index(j), fmt.Sprintf("%s[%d]", path, j), fn)
+			}
+		default:
+			fn(path, f, fv)
+		}
+	}
+}
+
+// flagName turns a setting path into its flag, e.g. "server.public_url"
+// into "server.public-url".
+func flagName(path string) string {
+	return strings.ReplaceAll(path, "_", "-")
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setValue parses raw into v. Lists are comma-separated.
+func setValue(v reflect.Value, raw string) error {
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		v.Set(reflect.ValueOf(splitList(raw)))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// formatValue is the inverse of setValue.
+func formatValue(v reflect.Value) string {
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Kind() == reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// splitList splits a comma-separated value, trimming blanks.
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// flagValue records a setting given on the command line so Load can apply
+// it after the file and environment.
+type flagValue struct {
+	v       reflect.Value
+	isBool  bool
+	name    string
+	raw     string
+	pending *[]*flagValue
+}
+
+func (f *flagValue) String() string {
+	if f == nil || !f.v.IsValid() {
+		return ""
+	}
+	if f.raw != "" {
+		return f.raw
+	}
+	return formatValue(f.v)
+}
+
+func (f *flagValue) Set(raw string) error {
+	f.raw = raw
+	*f.pending = append(*f.pending, f)
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool { return f.isBool }
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"io"
+	"net/url"
+	"reflect"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redactedValue replaces secrets that are set.
+const redactedValue = "[redacted]"
+
+// Redacted returns a copy of c with secrets replaced: fields tagged
+// secret:"true" entirely, those tagged secret:"url" only in their password.
+// Empty secrets stay empty so it still shows what is unset.
+func (c Config) Redacted() Config {
+	c.Notify.Webhooks = slices.Clone(c.Notify.Webhooks)
+	visit(reflect.ValueOf(&c).Elem(), "", func(_ string, f reflect.StructField, v reflect.Value) {
+		if v.Kind() != reflect.String || v.String() == "" {
+			return
+		}
+		switch f.Tag.Get("secret") {
+		case "true":
+			v.SetString(redactedValue)
+		case "url":
+			if u, err := url.Parse(v.String()); err == nil {
+				v.SetString(u.Redacted())
+			} else {
+				v.SetString(redactedValue)
+			}
+		}
+	})
+	return c
+}
+
+// Fields flattens the redacted configuration into dotted paths, for the
+// startup log.
+func (c Config) Fields() map[string]any {
+	c = c.Redacted()
+	fields := map[string]any{}
+	visit(reflect.ValueOf(&c).Elem(), "", func(path string, _ reflect.StructField, v reflect.Value) {
+		fields[path] = formatValue(v)
+	})
+	return fields
+}
+
+// Print writes the redacted configuration to w as YAML, in the layout a
+// config file uses.
+func (c Config) Print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return enc.Close()
+}
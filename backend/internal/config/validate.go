@@ -0,0 +1,169 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Setting names the setting at path as validation problems do, with its
+// environment variable, e.g. "booking.hours (BOOKING_HOURS)".
+func Setting(path string) string {
+	cfg := Defaults()
+	name := path
+	visit(reflect.ValueOf(&cfg).Elem(), "", func(p string, f reflect.StructField, _ reflect.Value) {
+		if env := f.Tag.Get("env"); p == path && env != "" {
+			name += " (" + env + ")"
+		}
+	})
+	return name
+}
+
+// validator collects problems, naming each setting with Setting.
+type validator struct {
+	problems []string
+}
+
+func (v *validator) fail(path, format string, args ...any) {
+	v.problems = append(v.problems, Setting(path)+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) check(ok bool, path, format string, args ...any) {
+	if !ok {
+		v.fail(path, format, args...)
+	}
+}
+
+func (v *validator) positive(path string, n int64) {
+	v.check(n > 0, path, "must be greater than zero")
+}
+
+func (v *validator) nonNegative(path string, n int64) {
+	v.check(n >= 0, path, "must not be negative")
+}
+
+func (v *validator) httpURL(path, raw string) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.fail(path, "%q is not an http(s) URL", raw)
+	}
+}
+
+func (v *validator) address(path, raw string) {
+	if _, err := mail.ParseAddress(raw); err != nil {
+		v.fail(path, "%q is not an email address", raw)
+	}
+}
+
+// validate returns every problem in cfg. Values with a syntax of their own,
+// such as digest schedules, booking hours and encryption keys, are parsed by
+// the packages that use them and checked by the caller.
+func (c Config) validate() []string {
+	v := &validator{}
+
+	s := c.Server
+	if port, err := strconv.Atoi(s.Port); err != nil || port < 1 || port > 65535 {
+		v.fail("server.port", "%q is not a TCP port", s.Port)
+	}
+	v.httpURL("server.public_url", s.PublicURL)
+	v.positive("server.shutdown_timeout", int64(s.ShutdownTimeout))
+	for _, o := range s.AllowedOrigins {
+		if o != "*" {
+			u, err := url.Parse(o)
+			v.check(err == nil && u.Scheme != "" && u.Host != "" && (u.Path == "" || u.Path == "/"),
+				"server.allowed_origins", "%q is not an origin such as https://example.com", o)
+		}
+	}
+	v.nonNegative("server.ws_max_connections", int64(s.WSMaxConnections))
+	v.nonNegative("server.ws_max_per_ip", int64(s.WSMaxPerIP))
+	v.nonNegative("server.ws_upgrades_per_minute", int64(s.WSUpgradesPerMinute))
+	v.nonNegative("server.ws_upgrade_burst", int64(s.WSUpgradeBurst))
+	v.positive("server.admin_event_buffer", int64(s.AdminEventBuffer))
+
+	v.check(c.Chat.LeadHighScore >= 0 && c.Chat.LeadHighScore <= 100, "chat.lead_high_score", "must be between 0 and 100")
+
+	e := c.Email
+	if e.To == "" {
+		v.fail("email.to", "required: the address that receives contact messages, bookings and digests")
+	} else {
+		v.address("email.to", e.To)
+	}
+	v.address("email.from", e.From)
+	v.nonNegative("email.attachment_max_files", int64(e.AttachmentMaxFiles))
+	v.positive("email.attachment_max_size_mb", int64(e.AttachmentMaxSizeMB))
+	v.check(e.AttachmentMaxTotalMB >= e.AttachmentMaxSizeMB, "email.attachment_max_total_mb",
+		"must be at least email.attachment_max_size_mb (%d)", e.AttachmentMaxSizeMB)
+	v.check(e.AttachmentMaxFiles == 0 || len(e.AttachmentTypes) > 0, "email.attachment_types",
+		"required while attachments are allowed")
+
+	st := c.Storage
+	v.check(st.DataDir != "", "storage.data_dir", "required")
+	v.nonNegative("storage.retention_days", int64(st.RetentionDays))
+	v.positive("storage.retention_interval", int64(st.RetentionInterval))
+
+	sec := c.Security
+	if sec.EncryptionKeys == "" {
+		v.check(sec.EncryptionActiveKey == "", "security.encryption_active_key", "set without security.encryption_keys")
+		v.check(sec.EncryptionIndexKey == "", "security.encryption_index_key", "set without security.encryption_keys")
+	}
+	if sec.EncryptionIndexKey != "" {
+		_, err := base64.StdEncoding.DecodeString(sec.EncryptionIndexKey)
+		v.check(err == nil, "security.encryption_index_key", "not valid base64")
+	}
+	v.nonNegative("security.bot_grace", int64(sec.BotGrace))
+
+	v.positive("analytics.hourly_days", int64(c.Analytics.HourlyDays))
+	v.positive("analytics.daily_days", int64(c.Analytics.DailyDays))
+
+	n := c.Notify
+	v.positive("notify.timeout", int64(n.Timeout))
+	v.nonNegative("notify.retries", int64(n.Retries))
+	for i, wh := range n.Webhooks {
+		path := fmt.Sprintf("notify.webhooks[%d]", i)
+		switch wh.Kind {
+		case "slack", "discord", "json":
+		default:
+			v.fail(path+".kind", "%q is not slack, discord or json", wh.Kind)
+		}
+		// The URL is a credential for Slack and Discord, so it is not quoted.
+		if u, err := url.Parse(wh.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.fail(path+".url", "not an http(s) URL")
+		}
+	}
+
+	b := c.Booking
+	if _, err := time.LoadLocation(b.Timezone); err != nil {
+		v.fail("booking.timezone", "%v", err)
+	}
+	v.positive("booking.slot", int64(b.Slot))
+	v.nonNegative("booking.buffer", int64(b.Buffer))
+	v.nonNegative("booking.min_notice", int64(b.MinNotice))
+	v.positive("booking.horizon_days", int64(b.HorizonDays))
+	v.nonNegative("booking.per_hour", int64(b.PerHour))
+	v.positive("booking.confirm_ttl", int64(b.ConfirmTTL))
+
+	v.positive("newsletter.confirm_ttl", int64(c.Newsletter.ConfirmTTL))
+	v.positive("newsletter.rate_per_minute", int64(c.Newsletter.PerMinute))
+
+	p := c.Presence
+	if p.RedisURL != "" {
+		u, err := url.Parse(p.RedisURL)
+		v.check(err == nil && (u.Scheme == "redis" || u.Scheme == "rediss" || u.Scheme == "unix"),
+			"presence.redis_url", "want redis://[user:password@]host:port/db")
+	}
+	v.positive("presence.heartbeat", int64(p.Heartbeat))
+	v.check(p.TTL > p.Heartbeat, "presence.ttl", "must be longer than presence.heartbeat (%s)", p.Heartbeat)
+
+	vis := c.Visitors
+	v.check(len(vis.ReactionEmojis) > 0, "visitors.reaction_emojis", "required")
+	v.positive("visitors.reaction_rate", int64(vis.ReactionRate))
+	v.positive("visitors.reaction_burst", int64(vis.ReactionBurst))
+	v.nonNegative("visitors.reaction_max_items", int64(vis.ReactionMaxItems))
+	v.nonNegative("visitors.guestbook_per_hour", int64(vis.GuestbookPerHour))
+
+	return v.problems
+}
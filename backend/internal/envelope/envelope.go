@@ -0,0 +1,181 @@
+// Package envelope implements AES-256-GCM envelope encryption with named,
+// rotatable key-encryption keys.
+//
+// Every sealed value gets a fresh random data key. The data key encrypts the
+// payload and is itself encrypted ("wrapped") with the active key-encryption
+// key, whose ID is stored alongside so older keys can still open it after a
+// rotation.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// KeySize is the required length of key-encryption keys (AES-256).
+const KeySize = 32
+
+// ErrUnknownKey is returned when an envelope names a key not in the keyring.
+var ErrUnknownKey = errors.New("envelope: unknown key id")
+
+// Envelope is a sealed payload. Byte fields are base64 in JSON.
+type Envelope struct {
+	KeyID      string `json:"kid"`
+	WrappedKey []byte `json:"wk"`
+	Nonce      []byte `json:"n"`
+	Ciphertext []byte `json:"ct"`
+}
+
+// Keyring holds key-encryption keys by ID; new envelopes use the active key.
+type Keyring struct {
+	keys   map[string][]byte
+	active string
+}
+
+// NewKeyring creates a keyring. active must name one of keys.
+func NewKeyring(keys map[string][]byte, active string) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("envelope: no keys")
+	}
+	for id, k := range keys {
+		if len(k) != KeySize {
+			return nil, fmt.Errorf("envelope: key %q must be %d bytes, got %d", id, KeySize, len(k))
+		}
+	}
+	if _, ok := keys[active]; !ok {
+		return nil, fmt.Errorf("envelope: active key %q not in keyring", active)
+	}
+	return &Keyring{keys: keys, active: active}, nil
+}
+
+// ParseKeys parses "id1:base64key,id2:base64key" into a key map. The IDs are
+// also returned in the order given.
+func ParseKeys(spec string) (map[string][]byte, []string, error) {
+	keys := make(map[string][]byte)
+	var order []string
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, enc, ok := strings.Cut(item, ":")
+		if !ok || id == "" {
+			return nil, nil, fmt.Errorf("envelope: key entry %q must be id:base64", item)
+		}
+		k, err := base64.StdEncoding.DecodeString(enc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("envelope: key %q: %w", id, err)
+		}
+		if _, dup := keys[id]; dup {
+			return nil, nil, fmt.Errorf("envelope: duplicate key id %q", id)
+		}
+		keys[id] = k
+		order = append(order, id)
+	}
+	return keys, order, nil
+}
+
+// ActiveKeyID returns the ID used for new envelopes.
+func (k *Keyring) ActiveKeyID() string { return k.active }
+
+// KeyIDs returns all key IDs, sorted.
+func (k *Keyring) KeyIDs() []string {
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Key returns the raw key with the given ID.
+func (k *Keyring) Key(id string) ([]byte, bool) {
+	key, ok := k.keys[id]
+	return key, ok
+}
+
+// Seal encrypts plaintext under a fresh data key wrapped with the active key.
+// aad is authenticated but not encrypted; the same aad must be passed to Open.
+func (k *Keyring) Seal(plaintext, aad []byte) (*Envelope, error) {
+	dek := make([]byte, KeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, fmt.Errorf("envelope: generate data key: %w", err)
+	}
+
+	nonce, ct, err := gcmSeal(dek, plaintext, aad)
+	if err != nil {
+		return nil, err
+	}
+	wrapNonce, wrapped, err := gcmSeal(k.keys[k.active], dek, []byte(k.active))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		KeyID:      k.active,
+		WrappedKey: append(wrapNonce, wrapped...),
+		Nonce:      nonce,
+		Ciphertext: ct,
+	}, nil
+}
+
+// Open decrypts an envelope sealed by any key in the keyring.
+func (k *Keyring) Open(env *Envelope, aad []byte) ([]byte, error) {
+	kek, ok := k.keys[env.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, env.KeyID)
+	}
+
+	gcm, err := newGCM(kek)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.WrappedKey) < gcm.NonceSize() {
+		return nil, errors.New("envelope: wrapped key too short")
+	}
+	dek, err := gcm.Open(nil, env.WrappedKey[:gcm.NonceSize()], env.WrappedKey[gcm.NonceSize():], []byte(env.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("envelope: unwrap data key: %w", err)
+	}
+
+	gcm, err = newGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+	pt, err := gcm.Open(nil, env.Nonce, env.Ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: decrypt: %w", err)
+	}
+	return pt, nil
+}
+
+// NeedsRotation reports whether env was sealed with a key other than the active one.
+func (k *Keyring) NeedsRotation(env *Envelope) bool {
+	return env.KeyID != k.active
+}
+
+func gcmSeal(key, plaintext, aad []byte) (nonce, ct []byte, err error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, fmt.Errorf("envelope: generate nonce: %w", err)
+	}
+	return nonce, gcm.Seal(nil, nonce, plaintext, aad), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
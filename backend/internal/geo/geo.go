@@ -0,0 +1,91 @@
+// Package geo resolves IP addresses to countries and cities using a local
+// MaxMind-format database (GeoLite2-City or GeoLite2-Country), so no lookup
+// ever leaves the server.
+//
+// A nil *DB is valid and resolves nothing, which is how the server runs when
+// no database file is installed.
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"portfolio-backend/internal/model"
+)
+
+// ErrNoDatabase is returned by Open when the database file does not exist.
+var ErrNoDatabase = errors.New("geoip database not found")
+
+// DB is an open GeoIP database.
+type DB struct {
+	reader *maxminddb.Reader
+}
+
+// record holds the fields read from City and Country databases.
+type record struct {
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// Open memory-maps the database at path.
+func Open(path string) (*DB, error) {
+	if path == "" {
+		return nil, ErrNoDatabase
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNoDatabase, path)
+	}
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open geoip database %s: %w", path, err)
+	}
+	return &DB{reader: reader}, nil
+}
+
+// Lookup returns the location of ip, or a zero GeoLocation when the address
+// is invalid, private or unknown, or when d is nil.
+func (d *DB) Lookup(ip string) model.GeoLocation {
+	if d == nil {
+		return model.GeoLocation{}
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil || addr.IsLoopback() || addr.IsPrivate() {
+		return model.GeoLocation{}
+	}
+	var rec record
+	if err := d.reader.Lookup(addr, &rec); err != nil {
+		return model.GeoLocation{}
+	}
+	return model.GeoLocation{
+		Country:     rec.Country.ISOCode,
+		CountryName: rec.Country.Names["en"],
+		City:        rec.City.Names["en"],
+	}
+}
+
+// Describe reports the database type and build date, for logging.
+func (d *DB) Describe() string {
+	if d == nil {
+		return "none"
+	}
+	m := d.reader.Metadata
+	return fmt.Sprintf("%s (built %s)", m.DatabaseType, time.Unix(int64(m.BuildEpoch), 0).UTC().Format(time.DateOnly))
+}
+
+// Close releases the database. It is safe to call on a nil DB.
+func (d *DB) Close() error {
+	if d == nil {
+		return nil
+	}
+	return d.reader.Close()
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"portfolio-backend/internal/httputil"
+	"portfolio-backend/internal/model"
+	"portfolio-backend/internal/service"
+)
+
+// AdminAnalyticsHandler serves analytics reports.
+//
+//	GET /api/admin/analytics?granularity=hour|day&from=…&to=…
+//
+// from and to are RFC 3339 times or YYYY-MM-DD dates (UTC). They default to
+// the last 24 hours for hourly and the last 30 days for daily reports; to
+// is exclusive.
+type AdminAnalyticsHandler struct {
+	analytics *service.AnalyticsService
+}
+
+func NewAdminAnalyticsHandler(analytics *service.AnalyticsService) *AdminAnalyticsHandler {
+	return &AdminAnalyticsHandler{analytics: analytics}
+}
+
+func (h *AdminAnalyticsHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendJSON(w, http.StatusMethodNotAllowed, model.APIResponse{
+			Success: false, Message: "Method not allowed",
+		})
+		return
+	}
+
+	q := r.URL.Query()
+	granularity := q.Get("granularity")
+	now := time.Now().UTC()
+	var from, to time.Time
+	switch granularity {
+	case service.AnalyticsHourly:
+		from, to = now.Truncate(time.Hour).Add(-23*time.Hour), now.Truncate(time.Hour).Add(time.Hour)
+	case "", service.AnalyticsDaily:
+		granularity = service.AnalyticsDaily
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		from, to = today.AddDate(0, 0, -29), today.AddDate(0, 0, 1)
+	default:
+		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+			Success: false, Message: "Granularity must be hour or day",
+		})
+		return
+	}
+
+	var ok bool
+	if v := q.Get("from"); v != "" {
+		if from, ok = parseReportTime(v); !ok {
+			httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+				Success: false, Message: "Invalid from; use RFC 3339 or YYYY-MM-DD",
+			})
+			return
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if to, ok = parseReportTime(v); !ok {
+			httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+				Success: false, Message: "Invalid to; use RFC 3339 or YYYY-MM-DD",
+			})
+			return
+		}
+	}
+	if !to.After(from) {
+		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+			Success: false, Message: "to must be after from",
+		})
+		return
+	}
+
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+		Success: true, Message: "Analytics report", Data: h.analytics.Report(granularity, from, to),
+	})
+}
+
+func parseReportTime(v string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/httputil"
+	"portfolio-backend/internal/model"
+	"portfolio-backend/internal/service"
+)
+
+const adminContactsPath = "/api/admin/contacts"
+
+// AdminContactHandler exposes stored contacts and their threads to the site owner.
+//
+//	GET  /api/admin/contacts            list contacts, newest first
+//	GET  /api/admin/contacts/{id}       a single contact with its thread
+//	POST /api/admin/contacts/{id}/reply email the contact and record the reply
+//	GET  /api/admin/contacts/{id}/attachments/{sha256} download an attachment
+//
+// This is the only place contact records are decrypted for display.
+type AdminContactHandler struct {
+	store   service.ContactStore
+	threads *service.ContactThreads
+	blobs   service.BlobStore
+	sealer  *service.RecordSealer
+}
+
+func NewAdminContactHandler(
+	store service.ContactStore,
+	threads *service.ContactThreads,
+	blobs service.BlobStore,
+	sealer *service.RecordSealer,
+) *AdminContactHandler {
+	return &AdminContactHandler{store: store, threads: threads, blobs: blobs, sealer: sealer}
+}
+
+func (h *AdminContactHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, adminContactsPath), "/")
+	parts := strings.Split(rest, "/")
+
+	switch {
+	case rest == "" && r.Method == http.MethodGet:
+		h.list(w)
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		h.get(w, parts[0])
+	case len(parts) == 2 && parts[1] == "reply" && r.Method == http.MethodPost:
+		h.reply(w, r, parts[0])
+	case len(parts) == 3 && parts[1] == "attachments" && r.Method == http.MethodGet:
+		h.attachment(w, parts[0], parts[2])
+	case len(parts) <= 3:
+		httputil.SendJSON(w, http.StatusMethodNotAllowed, model.APIResponse{
+			Success: false, Message: "Method not allowed",
+		})
+	default:
+		httputil.SendJSON(w, http.StatusNotFound, model.APIResponse{
+			Success: false, Message: "Not found",
+		})
+	}
+}
+
+func (h *AdminContactHandler) list(w http.ResponseWriter) {
+	records := h.store.List()
+	for i := range records {
+		if err := h.sealer.OpenContact(&records[i]); err != nil {
+			slog.Error("[admin] Failed to decrypt contact", "error", err, "contact", records[i].ID)
+		}
+	}
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+		Success: true,
+		Message: "Contacts retrieved",
+		Data:    map[string]any{"contacts": records, "total": len(records)},
+	})
+}
+
+func (h *AdminContactHandler) get(w http.ResponseWriter, id string) {
+	rec, ok := h.open(w, id)
+	if !ok {
+		return
+	}
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+		Success: true, Message: "Contact retrieved", Data: rec,
+	})
+}
+
+func (h *AdminContactHandler) reply(w http.ResponseWriter, r *http.Request, id string) {
+	var req model.ReplyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+			Success: false, Message: "Invalid request body",
+		})
+		return
+	}
+
+	if strings.TrimSpace(req.Message) == "" {
+		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+			Success: false, Message: "Message is required",
+		})
+		return
+	}
+
+	msg, err := h.threads.Reply(r.Context(), id, req)
+	if errors.Is(err, service.ErrContactNotFound) {
+		httputil.SendJSON(w, http.StatusNotFound, model.APIResponse{
+			Success: false, Message: "Contact not found",
+		})
+		return
+	}
+	if errors.Is(err, service.ErrReplyNotStored) {
+		slog.Error("[admin] Reply sent but not stored", "error", err, "contact", id)
+		httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+			Success: true, Message: "Reply sent, but it could not be saved to the thread", Data: msg,
+		})
+		return
+	}
+	if err != nil {
+		slog.Error("[admin] Reply failed", "error", err, "contact", id)
+		httputil.SendJSON(w, http.StatusBadGateway, model.APIResponse{
+			Success: false, Message: "Failed to send reply",
+		})
+		return
+	}
+
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+		Success: true, Message: "Reply sent", Data: msg,
+	})
+}
+
+func (h *AdminContactHandler) attachment(w http.ResponseWriter, id, sum string) {
+	rec, ok := h.open(w, id)
+	if !ok {
+		return
+	}
+
+	for _, a := range rec.Contact.Attachments {
+		if a.SHA256 != sum {
+			continue
+		}
+		data, err := h.blobs.Get(sum)
+		if err != nil {
+			slog.Error("[admin] Attachment blob missing", "error", err, "contact", id, "sha256", sum)
+			httputil.SendJSON(w, http.StatusNotFound, model.APIResponse{
+				Success: false, Message: "Attachment not found",
+			})
+			return
+		}
+		w.Header().Set("Content-Type", a.ContentType)
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename}))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Write(data)
+		return
+	}
+
+	httputil.SendJSON(w, http.StatusNotFound, model.APIResponse{
+		Success: false, Message: "Attachment not found",
+	})
+}
+
+// open loads and decrypts a record, writing an error response on failure.
+func (h *AdminContactHandler) open(w http.ResponseWriter, id string) (model.ContactRecord, bool) {
+	rec, ok := h.store.Get(id)
+	if !ok {
+		httputil.SendJSON(w, http.StatusNotFound, model.APIResponse{
+			Success: false, Message: "Contact not found",
+		})
+		return rec, false
+	}
+	if err := h.sealer.OpenContact(&rec); err != nil {
+		slog.Error("[admin] Failed to decrypt contact", "error", err, "contact", id)
+		httputil.SendJSON(w, http.StatusInternalServerError, model.APIResponse{
+			Success: false, Message: "Failed to decrypt contact",
+		})
+		return rec, false
+	}
+	return rec, true
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/httputil"
+	"portfolio-backend/internal/model"
+	"portfolio-backend/internal/service"
+)
+
+// AdminDigestHandler previews or sends an activity digest on demand.
+//
+//	GET  /api/admin/digest?period=daily|weekly  digest contents as JSON
+//	POST /api/admin/digest?period=daily|weekly  send (or dry-run print) it now
+type AdminDigestHandler struct {
+	digest *service.DigestService
+}
+
+func NewAdminDigestHandler(digest *service.DigestService) *AdminDigestHandler {
+	return &AdminDigestHandler{digest: digest}
+}
+
+func (h *AdminDigestHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		httputil.SendJSON(w, http.StatusMethodNotAllowed, model.APIResponse{
+			Success: false, Message: "Method not allowed",
+		})
+		return
+	}
+
+	period := r.URL.Query().Get("period")
+	window := 24 * time.Hour
+	switch period {
+	case "", service.DigestDaily:
+		period = service.DigestDaily
+	case service.DigestWeekly:
+		window = 7 * 24 * time.Hour
+	default:
+		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+			Success: false, Message: "Period must be daily or weekly",
+		})
+		return
+	}
+
+	now := time.Now()
+	dg := h.digest.Build(period, now.Add(-window), now)
+	if r.Method == http.MethodGet {
+		httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+			Success: true, Message: "Digest generated", Data: dg,
+		})
+		return
+	}
+
+	if err := h.digest.Send(r.Context(), dg); err != nil {
+		slog.Error("[digest] Manual send failed", "error", err)
+		httputil.SendJSON(w, http.StatusBadGateway, model.APIResponse{
+			Success: false, Message: "Failed to send digest",
+		})
+		return
+	}
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+		Success: true, Message: "Digest sent", Data: dg,
+	})
+}
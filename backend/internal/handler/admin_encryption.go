@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/envelope"
+	"portfolio-backend/internal/httputil"
+	"portfolio-backend/internal/model"
+	"portfolio-backend/internal/service"
+)
+
+const adminEncryptionPath = "/api/admin/encryption/"
+
+// Resealer re-encrypts a store's data under the active key.
+type Resealer interface {
+	Reseal() (int, error)
+}
+
+// AdminEncryptionHandler reports key status and re-encrypts stored data
+// after a key rotation.
+//
+//	GET  /api/admin/encryption/status     active key and known key IDs
+//	POST /api/admin/encryption/reencrypt  re-seal every store under the active key
+type AdminEncryptionHandler struct {
+	keys   *envelope.Keyring
+	stores map[string]Resealer
+	audit  service.AuditLog
+}
+
+// NewAdminEncryptionHandler creates the handler. keys is nil when encryption is disabled.
+func NewAdminEncryptionHandler(keys *envelope.Keyring, stores map[string]Resealer, audit service.AuditLog) *AdminEncryptionHandler {
+	return &AdminEncryptionHandler{keys: keys, stores: stores, audit: audit}
+}
+
+func (h *AdminEncryptionHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch action := strings.TrimPrefix(r.URL.Path, adminEncryptionPath); {
+	case action == "status" && r.Method == http.MethodGet:
+		h.status(w)
+	case action == "reencrypt" && r.Method == http.MethodPost:
+		h.reencrypt(w, r)
+	case action == "status" || action == "reencrypt":
+		httputil.SendJSON(w, http.StatusMethodNotAllowed, model.APIResponse{
+			Success: false, Message: "Method not allowed",
+		})
+	default:
+		httputil.SendJSON(w, http.StatusNotFound, model.APIResponse{
+			Success: false, Message: "Not found",
+		})
+	}
+}
+
+func (h *AdminEncryptionHandler) status(w http.ResponseWriter) {
+	data := map[string]any{"enabled": h.keys != nil}
+	if h.keys != nil {
+		data["activeKey"] = h.keys.ActiveKeyID()
+		data["keys"] = h.keys.KeyIDs()
+	}
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+		Success: true, Message: "Encryption status", Data: data,
+	})
+}
+
+func (h *AdminEncryptionHandler) reencrypt(w http.ResponseWriter, r *http.Request) {
+	if h.keys == nil {
+		httputil.SendJSON(w, http.StatusConflict, model.APIResponse{
+			Success: false, Message: "Encryption is not configured",
+		})
+		return
+	}
+
+	counts := map[string]int{}
+	failed := false
+	for name, store := range h.stores {
+		n, err := store.Reseal()
+		counts[name] = n
+		if err != nil {
+			failed = true
+			slog.Error("[encryption] Re-encrypt failed", "store", name, "error", err)
+		}
+	}
+
+	if err := h.audit.Record(model.AuditEntry{
+		Time:    time.Now(),
+		Action:  "reencrypt",
+		Actor:   r.RemoteAddr,
+		Details: counts,
+	}); err != nil {
+		slog.Error("[encryption] Failed to write audit entry", "error", err)
+	}
+
+	if failed {
+		httputil.SendJSON(w, http.StatusInternalServerError, model.APIResponse{
+			Success: false, Message: "Re-encryption incomplete", Data: counts,
+		})
+		return
+	}
+
+	slog.Info("[encryption] Re-encrypted stored data", "activeKey", h.keys.ActiveKeyID(), "counts", counts)
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+		Success: true, Message: "Data re-encrypted", Data: counts,
+	})
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/httputil"
+	"portfolio-backend/internal/model"
+	"portfolio-backend/internal/service"
+	"portfolio-backend/internal/wsproto"
+)
+
+const adminGuestbookPath = "/api/admin/guestbook"
+
+// Announcer pushes a message to every live visitor whose client supports
+// capability. *VisitorHandler implements it.
+type Announcer interface {
+	Announce(capability string, msg []byte)
+}
+
+// AdminGuestbookHandler moderates the guestbook.
+//
+//	GET  /api/admin/guestbook?status=pending  entries by status (pending, approved, rejected, spam or all)
+//	POST /api/admin/guestbook/{id}/approve    publish an entry and push it to visitors online
+//	POST /api/admin/guestbook/{id}/reject     keep an entry private
+type AdminGuestbookHandler struct {
+	guestbook *service.GuestbookService
+	announcer Announcer
+}
+
+func NewAdminGuestbookHandler(guestbook *service.GuestbookService, announcer Announcer) *AdminGuestbookHandler {
+	return &AdminGuestbookHandler{guestbook: guestbook, announcer: announcer}
+}
+
+func (h *AdminGuestbookHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, adminGuestbookPath), "/")
+	parts := strings.Split(rest, "/")
+
+	switch {
+	case rest == "" && r.Method == http.MethodGet:
+		h.list(w, r)
+	case len(parts) == 2 && parts[1] == "approve" && r.Method == http.MethodPost:
+		h.moderate(w, parts[0], model.GuestbookApproved)
+	case len(parts) == 2 && parts[1] == "reject" && r.Method == http.MethodPost:
+		h.moderate(w, parts[0], model.GuestbookRejected)
+	case rest == "" || len(parts) == 2 && (parts[1] == "approve" || parts[1] == "reject"):
+		httputil.SendJSON(w, http.StatusMethodNotAllowed, model.APIResponse{
+			Success: false, Message: "Method not allowed",
+		})
+	default:
+		httputil.SendJSON(w, http.StatusNotFound, model.APIResponse{
+			Success: false, Message: "Not found",
+		})
+	}
+}
+
+func (h *AdminGuestbookHandler) list(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = model.GuestbookPending
+	case "all":
+		status = ""
+	case model.GuestbookPending, model.GuestbookApproved, model.GuestbookRejected, model.GuestbookSpam:
+	default:
+		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+			Success: false, Message: "Status must be pending, approved, rejected, spam or all",
+		})
+		return
+	}
+	entries := h.guestbook.List(status)
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+		Success: true,
+		Message: "Guestbook entries retrieved",
+		Data:    map[string]any{"entries": entries, "total": len(entries)},
+	})
+}
+
+func (h *AdminGuestbookHandler) moderate(w http.ResponseWriter, id, status string) {
+	e, err := h.guestbook.Moderate(id, status)
+	if errors.Is(err, service.ErrGuestbookNotFound) {
+		httputil.SendJSON(w, http.StatusNotFound, model.APIResponse{
+			Success: false, Message: "Entry not found",
+		})
+		return
+	}
+	if err != nil {
+		slog.Error("[admin] Failed to moderate guestbook entry", "error", err, "entry", id)
+		httputil.SendJSON(w, http.StatusInternalServerError, model.APIResponse{
+			Success: false, Message: "Failed to update entry",
+		})
+		return
+	}
+
+	if status == model.GuestbookApproved {
+		h.announcer.Announce(wsproto.CapGuestbook, wsproto.Encode(wsproto.TypeGuestbook, "", wsproto.GuestbookEntry{
+			ID:        e.ID,
+			CreatedAt: e.CreatedAt,
+			Name:      e.Name,
+			Message:   e.Message,
+			Website:   e.Website,
+			Country:   e.Country,
+		}))
+	}
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+		Success: true, Message: "Entry " + status, Data: e,
+	})
+}
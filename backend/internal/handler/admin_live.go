@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gookit/slog"
+	"github.com/gorilla/websocket"
+
+	"portfolio-backend/internal/httputil"
+	"portfolio-backend/internal/middleware"
+	"portfolio-backend/internal/model"
+	"portfolio-backend/internal/service"
+	"portfolio-backend/internal/wsproto"
+)
+
+// Admin feed message types, in wsproto envelopes.
+const (
+	// adminTypeEvent carries one model.AdminEvent from the server.
+	adminTypeEvent = "event"
+	// adminTypeFilter replaces the connection's filter (client to server)
+	// and echoes the filter in force (server to client).
+	adminTypeFilter = "filter"
+)
+
+// defaultBackfill is how many past events a new connection gets unless it
+// asks for another number.
+const defaultBackfill = 50
+
+// AdminLiveHandler streams the admin event feed over WebSocket.
+//
+//	GET /ws/admin?types=visitor,contact&countries=IN,US&backfill=50&since=<seq>
+//
+// types and countries set the initial filter (see model.AdminEventFilter).
+// A new connection first receives up to backfill buffered events, or with
+// since every buffered event after that sequence number, so a client can
+// reconnect without gaps. Connections that fall behind are closed, as are
+// all connections on Shutdown.
+type AdminLiveHandler struct {
+	events   *service.EventBus
+	upgrader websocket.Upgrader
+
+	mu      sync.Mutex
+	stopped bool
+	quit    chan struct{}
+	conns   sync.WaitGroup
+}
+
+func NewAdminLiveHandler(events *service.EventBus, origins *middleware.OriginPolicy) *AdminLiveHandler {
+	return &AdminLiveHandler{
+		events: events,
+		quit:   make(chan struct{}),
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 4096,
+			CheckOrigin:     origins.CheckOrigin,
+		},
+	}
+}
+
+func (h *AdminLiveHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := model.AdminEventFilter{Types: splitList(q.Get("types")), Countries: splitList(q.Get("countries"))}
+	backfill := defaultBackfill
+	if n, err := strconv.Atoi(q.Get("backfill")); err == nil && n >= 0 {
+		backfill = n
+	}
+	since, _ := strconv.ParseUint(q.Get("since"), 10, 64)
+
+	h.mu.Lock()
+	if h.stopped {
+		h.mu.Unlock()
+		httputil.SendJSON(w, http.StatusServiceUnavailable, model.APIResponse{
+			Success: false, Message: "Server is shutting down",
+		})
+		return
+	}
+	h.conns.Add(1)
+	h.mu.Unlock()
+	defer h.conns.Done()
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.Error("[admin] Live feed upgrade failed", "error", err, "remoteAddr", r.RemoteAddr)
+		return
+	}
+	slog.Info("[admin] Live feed connected", "remoteAddr", r.RemoteAddr, "types", filter.Types, "countries", filter.Countries)
+
+	sub, past := h.events.Subscribe(filter, backfill, since)
+	replies := make(chan []byte, 4)
+	done := make(chan struct{})
+	go adminLiveRead(conn, sub, replies, done)
+	adminLiveWrite(conn, sub, past, replies, done, h.quit)
+}
+
+// Shutdown sends every feed connection a going-away close frame and waits
+// until they are written. Later connections are refused.
+func (h *AdminLiveHandler) Shutdown() {
+	h.mu.Lock()
+	if !h.stopped {
+		h.stopped = true
+		close(h.quit)
+	}
+	h.mu.Unlock()
+	h.conns.Wait()
+}
+
+// adminLiveRead applies filter changes from the client until the connection
+// closes, then ends the subscription.
+func adminLiveRead(conn *websocket.Conn, sub *service.EventSubscription, replies chan<- []byte, done chan<- struct{}) {
+	defer func() {
+		sub.Close()
+		close(done)
+	}()
+	conn.SetReadLimit(4096)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error { return conn.SetReadDeadline(time.Now().Add(pongWait)) })
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var reply []byte
+		var env wsproto.Envelope
+		var filter model.AdminEventFilter
+		switch {
+		case json.Unmarshal(data, &env) != nil:
+			reply = wsproto.Encode(wsproto.TypeError, "", wsproto.Error{Code: wsproto.ErrBadJSON, Message: "message is not a JSON envelope"})
+		case env.Type != adminTypeFilter:
+			reply = wsproto.Encode(wsproto.TypeError, "", wsproto.Error{
+				Code: wsproto.ErrUnknownType, Ref: env.ID, Message: "unknown message type " + strconv.Quote(env.Type),
+			})
+		case len(env.Payload) > 0 && json.Unmarshal(env.Payload, &filter) != nil:
+			reply = wsproto.Encode(wsproto.TypeError, "", wsproto.Error{Code: wsproto.ErrBadPayload, Ref: env.ID, Message: "invalid filter payload"})
+		default:
+			sub.SetFilter(filter)
+			reply = wsproto.Encode(adminTypeFilter, env.ID, filter)
+		}
+		select {
+		case replies <- reply:
+		default:
+		}
+	}
+}
+
+// adminLiveWrite sends the backfill, then events, replies and pings until
+// the reader finishes, the subscription is dropped, quit is closed or a
+// write fails.
+func adminLiveWrite(conn *websocket.Conn, sub *service.EventSubscription, past []model.AdminEvent, replies <-chan []byte, done, quit <-chan struct{}) {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+
+	write := func(msg []byte) bool {
+		conn.SetWriteDeadline(time.Now().Add(writeWait))
+		return conn.WriteMessage(websocket.TextMessage, msg) == nil
+	}
+	for _, ev := range past {
+		if !write(wsproto.Encode(adminTypeEvent, "", ev)) {
+			return
+		}
+	}
+
+	for {
+		select {
+		case ev, ok := <-sub.Events():
+			if !ok {
+				conn.SetWriteDeadline(time.Now().Add(writeWait))
+				conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow; reconnect with since"))
+				return
+			}
+			if !write(wsproto.Encode(adminTypeEvent, "", ev)) {
+				return
+			}
+		case msg := <-replies:
+			if !write(msg) {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-quit:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			conn.WriteMessage(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, shutdownReason))
+			return
+		case <-done:
+			return
+		}
+	}
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
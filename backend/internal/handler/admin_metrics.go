@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+
+	"portfolio-backend/internal/httputil"
+	"portfolio-backend/internal/model"
+)
+
+// AdminMetricsHandler serves operational counters.
+//
+//	GET /api/admin/metrics
+//
+// The response maps each connection endpoint to its open connections and
+// the upgrades accepted and refused since startup.
+type AdminMetricsHandler struct {
+	limiters map[string]*ConnectionLimiter
+}
+
+func NewAdminMetricsHandler(limiters map[string]*ConnectionLimiter) *AdminMetricsHandler {
+	return &AdminMetricsHandler{limiters: limiters}
+}
+
+func (h *AdminMetricsHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendJSON(w, http.StatusMethodNotAllowed, model.APIResponse{
+			Success: false, Message: "Method not allowed",
+		})
+		return
+	}
+
+	conns := make(map[string]model.ConnectionMetrics, len(h.limiters))
+	for name, l := range h.limiters {
+		conns[name] = l.Metrics()
+	}
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+		Success: true, Data: map[string]any{"connections": conns},
+	})
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/httputil"
+	"portfolio-backend/internal/model"
+	"portfolio-backend/internal/service"
+)
+
+const adminNewsletterPath = "/api/admin/newsletter/"
+
+// AdminNewsletterHandler lists subscribers and sends issues.
+//
+//	GET  /api/admin/newsletter/subscribers  all subscriptions with status counts
+//	POST /api/admin/newsletter/send         {"subject","html","text"} mail active subscribers
+type AdminNewsletterHandler struct {
+	newsletter *service.NewsletterService
+}
+
+func NewAdminNewsletterHandler(newsletter *service.NewsletterService) *AdminNewsletterHandler {
+	return &AdminNewsletterHandler{newsletter: newsletter}
+}
+
+func (h *AdminNewsletterHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch action := strings.TrimPrefix(r.URL.Path, adminNewsletterPath); {
+	case action == "subscribers" && r.Method == http.MethodGet:
+		h.subscribers(w)
+	case action == "send" && r.Method == http.MethodPost:
+		h.send(w, r)
+	case action == "subscribers" || action == "send":
+		httputil.SendJSON(w, http.StatusMethodNotAllowed, model.APIResponse{
+			Success: false, Message: "Method not allowed",
+		})
+	default:
+		httputil.SendJSON(w, http.StatusNotFound, model.APIResponse{
+			Success: false, Message: "Not found",
+		})
+	}
+}
+
+func (h *AdminNewsletterHandler) subscribers(w http.ResponseWriter) {
+	subs := h.newsletter.Subscribers()
+	counts := map[string]int{}
+	for _, s := range subs {
+		counts[s.Status]++
+	}
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+		Success: true,
+		Message: "Subscribers retrieved",
+		Data:    map[string]any{"counts": counts, "subscribers": subs},
+	})
+}
+
+func (h *AdminNewsletterHandler) send(w http.ResponseWriter, r *http.Request) {
+	var issue model.NewsletterIssue
+	if err := json.NewDecoder(r.Body).Decode(&issue); err != nil ||
+		strings.TrimSpace(issue.Subject) == "" || strings.TrimSpace(issue.HTML) == "" {
+		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+			Success: false, Message: "Subject and html are required",
+		})
+		return
+	}
+
+	n, err := h.newsletter.SendIssue(issue)
+	switch {
+	case errors.Is(err, service.ErrSendInProgress):
+		httputil.SendJSON(w, http.StatusConflict, model.APIResponse{
+			Success: false, Message: "An issue is already being sent",
+		})
+		return
+	case errors.Is(err, service.ErrInvalidIssue):
+		slog.Warn("[newsletter] Invalid issue", "error", err)
+		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+			Success: false, Message: err.Error(),
+		})
+		return
+	case err != nil:
+		slog.Error("[newsletter] Failed to start sending", "error", err)
+		httputil.SendJSON(w, http.StatusInternalServerError, model.APIResponse{
+			Success: false, Message: "Failed to send issue",
+		})
+		return
+	}
+
+	slog.Info("[admin] Newsletter issue queued", "subject", issue.Subject, "recipients", n)
+	httputil.SendJSON(w, http.StatusAccepted, model.APIResponse{
+		Success: true, Message: "Issue is being sent",
+		Data: map[string]int{"recipients": n},
+	})
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/httputil"
+	"portfolio-backend/internal/model"
+	"portfolio-backend/internal/service"
+)
+
+const adminPrivacyPath = "/api/admin/privacy/"
+
+// AdminPrivacyHandler exposes data-subject and retention commands.
+//
+//	GET  /api/admin/privacy/export?email=…  everything held for an address
+//	POST /api/admin/privacy/erase           {"email": …} delete it everywhere
+//	POST /api/admin/privacy/retention       apply the retention policy now
+type AdminPrivacyHandler struct {
+	privacy *service.PrivacyService
+}
+
+func NewAdminPrivacyHandler(privacy *service.PrivacyService) *AdminPrivacyHandler {
+	return &AdminPrivacyHandler{privacy: privacy}
+}
+
+func (h *AdminPrivacyHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch action := strings.TrimPrefix(r.URL.Path, adminPrivacyPath); {
+	case action == "export" && r.Method == http.MethodGet:
+		h.export(w, r)
+	case action == "erase" && r.Method == http.MethodPost:
+		h.erase(w, r)
+	case action == "retention" && r.Method == http.MethodPost:
+		h.retention(w)
+	case action == "export" || action == "erase" || action == "retention":
+		httputil.SendJSON(w, http.StatusMethodNotAllowed, model.APIResponse{
+			Success: false, Message: "Method not allowed",
+		})
+	default:
+		httputil.SendJSON(w, http.StatusNotFound, model.APIResponse{
+			Success: false, Message: "Not found",
+		})
+	}
+}
+
+func (h *AdminPrivacyHandler) export(w http.ResponseWriter, r *http.Request) {
+	email := r.URL.Query().Get("email")
+	if strings.TrimSpace(email) == "" {
+		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+			Success: false, Message: "Email is required",
+		})
+		return
+	}
+
+	export, err := h.privacy.Export(email, r.RemoteAddr)
+	if err != nil {
+		slog.Error("[privacy] Export failed", "error", err)
+		httputil.SendJSON(w, http.StatusInternalServerError, model.APIResponse{
+			Success: false, Message: "Export failed",
+		})
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="data-export.json"`)
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+		Success: true, Message: "Export generated", Data: export,
+	})
+}
+
+func (h *AdminPrivacyHandler) erase(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Email) == "" {
+		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+			Success: false, Message: "Email is required",
+		})
+		return
+	}
+
+	counts, err := h.privacy.Erase(req.Email, r.RemoteAddr)
+	if err != nil {
+		slog.Error("[privacy] Erasure incomplete", "error", err, "counts", counts)
+		httputil.SendJSON(w, http.StatusInternalServerError, model.APIResponse{
+			Success: false, Message: "Erasure incomplete", Data: counts,
+		})
+		return
+	}
+
+	slog.Info("[privacy] Erasure complete", "counts", counts)
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+		Success: true, Message: "Data erased", Data: counts,
+	})
+}
+
+func (h *AdminPrivacyHandler) retention(w http.ResponseWriter) {
+	counts, err := h.privacy.ApplyRetention(time.Now())
+	if err != nil {
+		slog.Error("[privacy] Retention run failed", "error", err)
+		httputil.SendJSON(w, http.StatusInternalServerError, model.APIResponse{
+			Success: false, Message: "Retention run failed", Data: counts,
+		})
+		return
+	}
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+		Success: true, Message: "Retention applied", Data: counts,
+	})
+}
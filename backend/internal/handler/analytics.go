@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/botdetect"
+	"portfolio-backend/internal/httputil"
+	"portfolio-backend/internal/model"
+	"portfolio-backend/internal/service"
+)
+
+// maxBeaconSize caps collect request bodies.
+const maxBeaconSize = 4 << 10
+
+// AnalyticsHandler receives analytics beacons.
+//
+//	POST /api/analytics/collect  {"type":"pageview|download","url","referrer","target"}
+//
+// The body is read as JSON whatever its Content-Type, since
+// navigator.sendBeacon sends text/plain to avoid a CORS preflight. Beacons
+// from bots are accepted but only counted as bot traffic.
+type AnalyticsHandler struct {
+	analytics *service.AnalyticsService
+	locator   *ClientLocator
+	bots      *botdetect.Classifier
+}
+
+func NewAnalyticsHandler(analytics *service.AnalyticsService, locator *ClientLocator, bots *botdetect.Classifier) *AnalyticsHandler {
+	return &AnalyticsHandler{analytics: analytics, locator: locator, bots: bots}
+}
+
+func (h *AnalyticsHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httputil.SendJSON(w, http.StatusMethodNotAllowed, model.APIResponse{
+			Success: false, Message: "Method not allowed",
+		})
+		return
+	}
+
+	var ev model.AnalyticsEvent
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBeaconSize)).Decode(&ev); err != nil {
+		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+			Success: false, Message: "Invalid request body",
+		})
+		return
+	}
+
+	if signals := h.bots.Request(r, false); len(signals) > 0 {
+		h.analytics.RecordBot(h.bots.Agent(r.UserAgent()), signals)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	ip, loc := h.locator.Locate(r)
+	err := h.analytics.Record(ev, ip, r.UserAgent(), loc)
+	if errors.Is(err, service.ErrUnknownEvent) {
+		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+			Success: false, Message: "Unknown event type",
+		})
+		return
+	}
+	if err != nil {
+		slog.Error("[analytics] Failed to record event", "error", err)
+		httputil.SendJSON(w, http.StatusInternalServerError, model.APIResponse{
+			Success: false, Message: "Failed to record event",
+		})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
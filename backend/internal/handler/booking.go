@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/httputil"
+	"portfolio-backend/internal/model"
+	"portfolio-backend/internal/service"
+	"portfolio-backend/internal/validation"
+)
+
+// BookingHandler serves meeting booking.
+//
+//	GET  /api/booking/slots?from=YYYY-MM-DD&days=N  free slots
+//	POST /api/booking                              {"start","name","email","note"}; holds the slot
+//	GET  /api/booking/confirm?id=…&token=…         confirmation page (from email links)
+//	POST /api/booking/confirm                      id and token as form or query values
+//	GET  /api/booking/cancel?id=…&token=…          confirmation page (from email links)
+//	POST /api/booking/cancel                       id and token as form or query values
+type BookingHandler struct {
+	booking   *service.BookingService
+	validator *validation.ContactValidator
+	locator   *ClientLocator
+	tasks     *service.Tasks
+}
+
+func NewBookingHandler(booking *service.BookingService, validator *validation.ContactValidator, locator *ClientLocator, tasks *service.Tasks) *BookingHandler {
+	return &BookingHandler{booking: booking, validator: validator, locator: locator, tasks: tasks}
+}
+
+func (h *BookingHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch action := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/api/booking"), "/"); {
+	case action == "" && r.Method == http.MethodPost:
+		h.book(w, r)
+	case action == "slots" && r.Method == http.MethodGet:
+		h.slots(w, r)
+	case action == "confirm" && r.Method == http.MethodGet:
+		h.confirmPage(w, r)
+	case action == "confirm" && r.Method == http.MethodPost:
+		h.confirm(w, r)
+	case action == "cancel" && r.Method == http.MethodGet:
+		h.cancelPage(w, r)
+	case action == "cancel" && r.Method == http.MethodPost:
+		h.cancel(w, r)
+	case action == "" || action == "slots" || action == "confirm" || action == "cancel":
+		httputil.SendJSON(w, http.StatusMethodNotAllowed, model.APIResponse{
+			Success: false, Message: "Method not allowed",
+		})
+	default:
+		httputil.SendJSON(w, http.StatusNotFound, model.APIResponse{
+			Success: false, Message: "Not found",
+		})
+	}
+}
+
+func (h *BookingHandler) slots(w http.ResponseWriter, r *http.Request) {
+	loc := h.booking.Location()
+	now := time.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if v := r.URL.Query().Get("from"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, loc)
+		if err != nil {
+			httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+				Success: false, Message: "from must be YYYY-MM-DD",
+			})
+			return
+		}
+		from = t
+	}
+	days := 14
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 60 {
+			httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+				Success: false, Message: "days must be between 1 and 60",
+			})
+			return
+		}
+		days = n
+	}
+
+	slots := h.booking.Slots(from, from.AddDate(0, 0, days))
+	if slots == nil {
+		slots = []model.Slot{}
+	}
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+		Success: true,
+		Message: "Slots retrieved",
+		Data: map[string]any{
+			"timezone":        loc.String(),
+			"durationMinutes": int(h.booking.SlotLength() / time.Minute),
+			"slots":           slots,
+		},
+	})
+}
+
+func (h *BookingHandler) book(w http.ResponseWriter, r *http.Request) {
+	var req model.BookingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+			Success: false, Message: "Invalid request body",
+		})
+		return
+	}
+	if errs := h.validator.ValidateBooking(r.Context(), &req); errs != nil {
+		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+			Success: false,
+			Message: "Please correct the highlighted fields",
+			Data:    map[string]any{"errors": errs},
+		})
+		return
+	}
+
+	ip, _ := h.locator.Locate(r)
+	b, token, err := h.booking.Book(req, ip)
+	switch {
+	case errors.Is(err, service.ErrBookingRateLimited):
+		httputil.SendJSON(w, http.StatusTooManyRequests, model.APIResponse{
+			Success: false, Message: "You have requested a few meetings already; please try again later",
+		})
+		return
+	case errors.Is(err, service.ErrSlotUnavailable):
+		httputil.SendJSON(w, http.StatusUnprocessableEntity, model.APIResponse{
+			Success: false, Message: "That time is not available",
+		})
+		return
+	case errors.Is(err, service.ErrSlotTaken):
+		httputil.SendJSON(w, http.StatusConflict, model.APIResponse{
+			Success: false, Message: "That slot was just booked, please pick another",
+		})
+		return
+	case err != nil:
+		slog.Error("[booking] Failed to book", "error", err)
+		httputil.SendJSON(w, http.StatusInternalServerError, model.APIResponse{
+			Success: false, Message: "Failed to book meeting",
+		})
+		return
+	}
+
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+		Success: true,
+		Message: "Almost done! Check your inbox to confirm the meeting.",
+		Data: map[string]any{
+			"id":        b.ID,
+			"start":     b.Start,
+			"end":       b.End,
+			"status":    b.Status,
+			"holdUntil": b.HoldUntil,
+		},
+	})
+
+	h.tasks.Go(func(ctx context.Context) { h.booking.SendConfirmation(ctx, b, token) })
+}
+
+// confirmPage asks for a click so that link scanners following the email
+// link do not confirm the meeting.
+func (h *BookingHandler) confirmPage(w http.ResponseWriter, r *http.Request) {
+	id, token := r.URL.Query().Get("id"), r.URL.Query().Get("token")
+	httputil.SendPage(w, http.StatusOK, "Confirm meeting?", fmt.Sprintf(
+		`<form method="post" action="/api/booking/confirm">`+
+			`<input type="hidden" name="id" value="%s"><input type="hidden" name="token" value="%s">`+
+			`<button type="submit">Yes, confirm it</button></form>`,
+		html.EscapeString(id), html.EscapeString(token)))
+}
+
+func (h *BookingHandler) confirm(w http.ResponseWriter, r *http.Request) {
+	id, token := r.FormValue("id"), r.FormValue("token")
+	b, changed, err := h.booking.Confirm(id, token)
+	switch {
+	case errors.Is(err, service.ErrBookingNotFound), errors.Is(err, service.ErrInvalidToken):
+		httputil.SendJSON(w, http.StatusNotFound, model.APIResponse{
+			Success: false, Message: "Booking not found",
+		})
+		return
+	case errors.Is(err, service.ErrBookingExpired):
+		httputil.SendJSON(w, http.StatusGone, model.APIResponse{
+			Success: false, Message: "This booking was not confirmed in time; please book again",
+		})
+		return
+	case err != nil:
+		slog.Error("[booking] Failed to confirm", "error", err, "id", id)
+		httputil.SendJSON(w, http.StatusInternalServerError, model.APIResponse{
+			Success: false, Message: "Failed to confirm booking",
+		})
+		return
+	}
+
+	if changed {
+		h.tasks.Go(func(ctx context.Context) { h.booking.SendInvites(ctx, b, token) })
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "text/html") {
+		httputil.SendPage(w, http.StatusOK, "Meeting confirmed", "<p>A calendar invite is on its way.</p>")
+		return
+	}
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+		Success: true, Message: "Booking confirmed",
+		Data: map[string]any{"id": b.ID, "status": b.Status},
+	})
+}
+
+// cancelPage asks for confirmation so that link scanners following the
+// email link do not cancel the meeting.
+func (h *BookingHandler) cancelPage(w http.ResponseWriter, r *http.Request) {
+	id, token := r.URL.Query().Get("id"), r.URL.Query().Get("token")
+	httputil.SendPage(w, http.StatusOK, "Cancel meeting?", fmt.Sprintf(
+		`<form method="post" action="/api/booking/cancel">`+
+			`<input type="hidden" name="id" value="%s"><input type="hidden" name="token" value="%s">`+
+			`<button type="submit">Yes, cancel it</button></form>`,
+		html.EscapeString(id), html.EscapeString(token)))
+}
+
+func (h *BookingHandler) cancel(w http.ResponseWriter, r *http.Request) {
+	id, token := r.FormValue("id"), r.FormValue("token")
+	b, changed, err := h.booking.Cancel(id, token)
+	switch {
+	case errors.Is(err, service.ErrBookingNotFound), errors.Is(err, service.ErrInvalidToken):
+		httputil.SendJSON(w, http.StatusNotFound, model.APIResponse{
+			Success: false, Message: "Booking not found",
+		})
+		return
+	case err != nil:
+		slog.Error("[booking] Failed to cancel", "error", err, "id", id)
+		httputil.SendJSON(w, http.StatusInternalServerError, model.APIResponse{
+			Success: false, Message: "Failed to cancel booking",
+		})
+		return
+	}
+
+	// Invitations only went out once the booking was confirmed.
+	note := "<p>The time is free again.</p>"
+	if changed {
+		h.tasks.Go(func(ctx context.Context) { h.booking.SendInvites(ctx, b, "") })
+		note = "<p>Both calendars will receive a cancellation.</p>"
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "text/html") {
+		httputil.SendPage(w, http.StatusOK, "Meeting cancelled", note)
+		return
+	}
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+		Success: true, Message: "Booking cancelled",
+		Data: map[string]any{"id": b.ID, "status": b.Status},
+	})
+}
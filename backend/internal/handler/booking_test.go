@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"context"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"portfolio-backend/internal/middleware"
+	"portfolio-backend/internal/model"
+	"portfolio-backend/internal/service"
+	"portfolio-backend/internal/validation"
+)
+
+const (
+	testPublicURL = "https://api.example.com"
+	testSiteURL   = "https://example.com"
+	testPerHour   = 2
+)
+
+// recordedEmails is an EmailService that keeps what it is given.
+type recordedEmails struct {
+	mu   sync.Mutex
+	sent []model.Email
+}
+
+func (r *recordedEmails) Send(ctx context.Context, req model.ContactRequest) error { return nil }
+
+func (r *recordedEmails) Deliver(ctx context.Context, msg model.Email) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, msg)
+	return nil
+}
+
+func (r *recordedEmails) emails() []model.Email {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]model.Email(nil), r.sent...)
+}
+
+// testOrigins allows the site and, like main, the server's public URL.
+func testOrigins(t *testing.T) *middleware.OriginPolicy {
+	t.Helper()
+	origins := middleware.NewOriginPolicy([]string{testSiteURL})
+	if err := origins.AllowURL(testPublicURL); err != nil {
+		t.Fatal(err)
+	}
+	return origins
+}
+
+func testTasks(t *testing.T) *service.Tasks {
+	tasks := service.NewTasks()
+	t.Cleanup(func() { tasks.Wait(context.Background()) })
+	return tasks
+}
+
+var (
+	formAction = regexp.MustCompile(`<form method="post" action="([^"]*)">`)
+	formInput  = regexp.MustCompile(`<input type="hidden" name="([^"]*)" value="([^"]*)">`)
+)
+
+// submitForm loads the page at target through h and posts its form back
+// the way a browser would, with the page's origin in the Origin header.
+func submitForm(t *testing.T, h http.HandlerFunc, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	page := httptest.NewRecorder()
+	h(page, httptest.NewRequest(http.MethodGet, testPublicURL+target, nil))
+	if page.Code != http.StatusOK {
+		t.Fatalf("GET %s: status %d: %s", target, page.Code, page.Body)
+	}
+	m := formAction.FindStringSubmatch(page.Body.String())
+	if m == nil {
+		t.Fatalf("GET %s: no form in %s", target, page.Body)
+	}
+	values := url.Values{}
+	for _, in := range formInput.FindAllStringSubmatch(page.Body.String(), -1) {
+		values.Set(html.UnescapeString(in[1]), html.UnescapeString(in[2]))
+	}
+
+	req := httptest.NewRequest(http.MethodPost, testPublicURL+html.UnescapeString(m[1]), strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("Origin", testPublicURL)
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	return rec
+}
+
+func newTestBookingService(t *testing.T, email service.EmailService) *service.BookingService {
+	t.Helper()
+	store, err := service.NewFileBookingStore(filepath.Join(t.TempDir(), "bookings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hours, err := service.ParseWeeklyHours("sun-sat 00:00-24:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service.NewBookingService(store, email, service.Availability{
+		Location: time.UTC,
+		Hours:    hours,
+		Slot:     30 * time.Minute,
+		Horizon:  7 * 24 * time.Hour,
+	}, service.MeetingInfo{Title: "Intro call", OwnerName: "Owner", PublicURL: testPublicURL},
+		service.BookingPolicy{PerHour: testPerHour, ConfirmTTL: time.Hour}, "owner@example.com", "example.com")
+}
+
+func newTestBookingHandler(t *testing.T, booking *service.BookingService, tasks *service.Tasks) http.HandlerFunc {
+	t.Helper()
+	validator, err := validation.NewContactValidator(validation.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return middleware.CORS(testOrigins(t), NewBookingHandler(booking, validator, NewClientLocator(nil, false), tasks).Handle)
+}
+
+// firstSlot returns the earliest free slot.
+func firstSlot(t *testing.T, booking *service.BookingService) model.Slot {
+	t.Helper()
+	slots := booking.Slots(time.Now(), time.Now().Add(24*time.Hour))
+	if len(slots) == 0 {
+		t.Fatal("no free slots")
+	}
+	return slots[0]
+}
+
+// postBooking requests slot through h as a client at 192.0.2.1.
+func postBooking(h http.HandlerFunc, slot model.Slot) *httptest.ResponseRecorder {
+	body := `{"start":"` + slot.Start.Format(time.RFC3339) + `","name":"Visitor","email":"visitor@example.com"}`
+	req := httptest.NewRequest(http.MethodPost, testPublicURL+"/api/booking", strings.NewReader(body))
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", testSiteURL)
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	return rec
+}
+
+func TestBookingHeldUntilConfirmed(t *testing.T) {
+	email := &recordedEmails{}
+	booking := newTestBookingService(t, email)
+	tasks := testTasks(t)
+	h := newTestBookingHandler(t, booking, tasks)
+
+	slot := firstSlot(t, booking)
+	b, token, err := booking.Book(model.BookingRequest{Start: slot.Start, Name: "Visitor", Email: "visitor@example.com"}, "192.0.2.1")
+	if err != nil {
+		t.Fatalf("Book: %v", err)
+	}
+	if b.Status != model.BookingPending {
+		t.Fatalf("new booking is %q, want %q", b.Status, model.BookingPending)
+	}
+	// The pending booking already holds the slot.
+	if got := booking.Slots(slot.Start, slot.End); len(got) != 0 {
+		t.Fatal("slot offered while a booking holds it")
+	}
+	booking.SendConfirmation(context.Background(), b, token)
+	sent := email.emails()
+	if len(sent) != 1 || len(sent[0].To) != 1 || sent[0].To[0] != "visitor@example.com" {
+		t.Fatalf("sent %+v before confirming, want one request to the visitor", sent)
+	}
+	if !strings.Contains(sent[0].Text, booking.ConfirmURL(b.ID, token)) {
+		t.Errorf("confirmation request lacks the confirm link:\n%s", sent[0].Text)
+	}
+
+	confirmURL, err := url.Parse(booking.ConfirmURL(b.ID, token))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := submitForm(t, h, confirmURL.RequestURI())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("confirm form: status %d: %s", rec.Code, rec.Body)
+	}
+	tasks.Wait(context.Background())
+
+	var to []string
+	for _, m := range email.emails()[1:] {
+		to = append(to, m.To...)
+	}
+	if len(to) != 2 {
+		t.Errorf("invitations went to %v after confirming, want the visitor and the owner", to)
+	}
+	// Confirming again sends nothing more.
+	if _, changed, err := booking.Confirm(b.ID, token); err != nil || changed {
+		t.Errorf("second Confirm = %v, %v; want no change", changed, err)
+	}
+}
+
+func TestBookingRateLimited(t *testing.T) {
+	booking := newTestBookingService(t, &recordedEmails{})
+	h := newTestBookingHandler(t, booking, testTasks(t))
+
+	slot := firstSlot(t, booking)
+	for i := 0; i < testPerHour; i++ {
+		// Taken slots still count, so repeated attempts cannot probe for free ones.
+		if rec := postBooking(h, slot); rec.Code == http.StatusTooManyRequests {
+			t.Fatalf("booking %d refused: %s", i+1, rec.Body)
+		}
+	}
+	if rec := postBooking(h, slot); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("booking over the limit: status %d, want %d: %s", rec.Code, http.StatusTooManyRequests, rec.Body)
+	}
+}
+
+func TestBookingCancelFormFromPublicURL(t *testing.T) {
+	booking := newTestBookingService(t, &recordedEmails{})
+	h := newTestBookingHandler(t, booking, testTasks(t))
+
+	slot := firstSlot(t, booking)
+	b, token, err := booking.Book(model.BookingRequest{Start: slot.Start, Name: "Visitor", Email: "visitor@example.com"}, "192.0.2.1")
+	if err != nil {
+		t.Fatalf("Book: %v", err)
+	}
+
+	cancelURL, err := url.Parse(booking.CancelURL(b.ID, token))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := submitForm(t, h, cancelURL.RequestURI())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("cancel form: status %d: %s", rec.Code, rec.Body)
+	}
+	if got := booking.Slots(slot.Start, slot.End); len(got) != 1 {
+		t.Errorf("slot still taken after cancelling")
+	}
+}
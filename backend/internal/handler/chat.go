@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"time"
+
+	"github.com/gookit/slog"
 
 	"portfolio-backend/internal/httputil"
 	"portfolio-backend/internal/model"
@@ -11,11 +15,19 @@ import (
 
 // ChatHandler serves AI-powered chat responses.
 type ChatHandler struct {
-	chat service.ChatService
+	chat       service.ChatService
+	store      service.ChatStore
+	notify     service.Notifier
+	notifyHire bool
+	events     service.EventPublisher
+	tasks      *service.Tasks
 }
 
-func NewChatHandler(chat service.ChatService) *ChatHandler {
-	return &ChatHandler{chat: chat}
+// NewChatHandler creates a chat handler that records transcripts in store
+// and announces them to events. When notifyHire is set, messages that look
+// like hiring enquiries are forwarded to notify.
+func NewChatHandler(chat service.ChatService, store service.ChatStore, notify service.Notifier, notifyHire bool, events service.EventPublisher, tasks *service.Tasks) *ChatHandler {
+	return &ChatHandler{chat: chat, store: store, notify: notify, notifyHire: notifyHire, events: events, tasks: tasks}
 }
 
 func (h *ChatHandler) Handle(w http.ResponseWriter, r *http.Request) {
@@ -41,7 +53,26 @@ func (h *ChatHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, _ := h.chat.GetResponse(req.Message, req.History)
+	if h.notifyHire && service.IsHireIntent(req.Message) {
+		ev := model.LeadEvent{
+			Type:      model.LeadChatHire,
+			Message:   req.Message,
+			CreatedAt: time.Now(),
+		}
+		h.tasks.Go(func(ctx context.Context) { h.notify.Notify(ctx, ev) })
+	}
+
+	response, _ := h.chat.GetResponse(r.Context(), req.Message, req.History)
+
+	exchange := model.ChatExchange{
+		CreatedAt: time.Now(),
+		Message:   req.Message,
+		Response:  response,
+	}
+	if err := h.store.Append(exchange); err != nil {
+		slog.Error("[chat] Failed to store transcript", "error", err)
+	}
+	h.events.Publish(service.EventChatMessage, exchange)
 
 	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
 		Success: true,
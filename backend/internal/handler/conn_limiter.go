@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/httputil"
+	"portfolio-backend/internal/middleware"
+	"portfolio-backend/internal/model"
+)
+
+// Reasons a connection is refused, as counted in model.ConnectionMetrics.
+const (
+	RejectOrigin   = "origin"
+	RejectCapacity = "capacity"
+	RejectPerIP    = "per-ip"
+	RejectRate     = "rate"
+)
+
+// ConnectionLimits caps long-lived connections. Zero disables a limit.
+type ConnectionLimits struct {
+	// Max is the number of open connections the server accepts.
+	Max int
+	// PerIP is the number of open connections one client address may hold.
+	PerIP int
+	// PerMinute is how many connections one client address may open a
+	// minute on average; Burst is how many it may open at once.
+	PerMinute int
+	Burst     int
+}
+
+// ConnectionLimiter admits long-lived connections within ConnectionLimits
+// and counts what it admits and refuses.
+type ConnectionLimiter struct {
+	limits ConnectionLimits
+
+	mu       sync.Mutex
+	open     int
+	clients  map[string]*connClient
+	swept    time.Time
+	accepted int64
+	rejected map[string]int64
+}
+
+// connClient is the state kept for one client address.
+type connClient struct {
+	open    int
+	upgrade tokenBucket
+}
+
+func NewConnectionLimiter(limits ConnectionLimits) *ConnectionLimiter {
+	limits.Burst = max(limits.Burst, 1)
+	return &ConnectionLimiter{limits: limits, clients: map[string]*connClient{}, rejected: map[string]int64{}}
+}
+
+// Acquire admits a connection from ip, returning a function to call once it
+// closes, or the reason to pass to Refuse.
+func (l *ConnectionLimiter) Acquire(ip string) (release func(), reason string) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweepLocked(now)
+
+	if l.limits.Max > 0 && l.open >= l.limits.Max {
+		return nil, RejectCapacity
+	}
+	c := l.clients[ip]
+	if c == nil {
+		c = &connClient{}
+		l.clients[ip] = c
+	}
+	if l.limits.PerIP > 0 && c.open >= l.limits.PerIP {
+		return nil, RejectPerIP
+	}
+	if l.limits.PerMinute > 0 && c.upgrade.take(1, l.rate(), l.limits.Burst, now) == 0 {
+		return nil, RejectRate
+	}
+
+	l.open++
+	c.open++
+	l.accepted++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.open--
+			c.open--
+		})
+	}, ""
+}
+
+// Metrics returns the open connections and the counts so far.
+func (l *ConnectionLimiter) Metrics() model.ConnectionMetrics {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	m := model.ConnectionMetrics{
+		Open:     l.open,
+		Max:      l.limits.Max,
+		PerIP:    l.limits.PerIP,
+		Accepted: l.accepted,
+		Rejected: make(map[string]int64, len(l.rejected)),
+	}
+	for reason, n := range l.rejected {
+		m.Rejected[reason] = n
+	}
+	return m
+}
+
+// Refuse counts and answers a refused connection with the matching status:
+// 403 for a disallowed origin, 503 when the server is full and 429 when the
+// client holds or opens too many.
+func (l *ConnectionLimiter) Refuse(w http.ResponseWriter, r *http.Request, reason string) {
+	l.mu.Lock()
+	l.rejected[reason]++
+	l.mu.Unlock()
+
+	status, msg := http.StatusTooManyRequests, "Too many connections"
+	switch reason {
+	case RejectOrigin:
+		status, msg = http.StatusForbidden, "Origin not allowed"
+	case RejectCapacity:
+		status, msg = http.StatusServiceUnavailable, "Server is at capacity"
+		w.Header().Set("Retry-After", "30")
+	case RejectRate:
+		msg = "Too many connection attempts"
+		w.Header().Set("Retry-After", strconv.Itoa(60/l.limits.PerMinute+1))
+	}
+	slog.Debug("[connections] Refused", "reason", reason, "path", r.URL.Path, "origin", r.Header.Get("Origin"), "remoteAddr", r.RemoteAddr)
+	httputil.SendJSON(w, status, model.APIResponse{Success: false, Message: msg})
+}
+
+// CheckOrigin wraps the handler of a long-lived connection endpoint so
+// requests from origins the policy does not allow are refused and counted
+// here, whichever transport the endpoint serves. It must be the outermost
+// wrapper, ahead of CORS, or refusals go uncounted.
+func (l *ConnectionLimiter) CheckOrigin(origins *middleware.OriginPolicy, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !origins.CheckOrigin(r) {
+			l.Refuse(w, r, RejectOrigin)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (l *ConnectionLimiter) rate() float64 { return float64(l.limits.PerMinute) / 60 }
+
+// sweepLocked forgets, at most once a minute, addresses with no open
+// connections whose upgrade allowance has refilled.
+func (l *ConnectionLimiter) sweepLocked(now time.Time) {
+	if now.Sub(l.swept) < time.Minute {
+		return
+	}
+	l.swept = now
+	for ip, c := range l.clients {
+		if c.open > 0 {
+			continue
+		}
+		if l.limits.PerMinute <= 0 || now.Sub(c.upgrade.at).Seconds()*l.rate() >= float64(l.limits.Burst) {
+			delete(l.clients, ip)
+		}
+	}
+}
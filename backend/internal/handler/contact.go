@@ -1,25 +1,59 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"mime"
 	"net/http"
-	"strings"
 
 	"github.com/gookit/slog"
 
 	"portfolio-backend/internal/httputil"
 	"portfolio-backend/internal/model"
 	"portfolio-backend/internal/service"
+	"portfolio-backend/internal/validation"
 )
 
 // ContactHandler processes contact form submissions.
 type ContactHandler struct {
 	email  service.EmailService
 	logger service.ContactLogger
+	store  service.ContactStore
+	leads  *service.LeadRouter
+	blobs  service.BlobStore
+	policy service.AttachmentPolicy
+
+	validator *validation.ContactValidator
+	locator   *ClientLocator
+	events    service.EventPublisher
+	tasks     *service.Tasks
 }
 
-func NewContactHandler(email service.EmailService, logger service.ContactLogger) *ContactHandler {
-	return &ContactHandler{email: email, logger: logger}
+func NewContactHandler(
+	email service.EmailService,
+	logger service.ContactLogger,
+	store service.ContactStore,
+	leads *service.LeadRouter,
+	blobs service.BlobStore,
+	policy service.AttachmentPolicy,
+	validator *validation.ContactValidator,
+	locator *ClientLocator,
+	events service.EventPublisher,
+	tasks *service.Tasks,
+) *ContactHandler {
+	return &ContactHandler{
+		email:     email,
+		logger:    logger,
+		store:     store,
+		leads:     leads,
+		blobs:     blobs,
+		policy:    policy,
+		validator: validator,
+		locator:   locator,
+		events:    events,
+		tasks:     tasks,
+	}
 }
 
 func (h *ContactHandler) Handle(w http.ResponseWriter, r *http.Request) {
@@ -30,8 +64,16 @@ func (h *ContactHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req model.ContactRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	req, err := h.decode(w, r)
+	var attErr *service.AttachmentError
+	if errors.As(err, &attErr) {
+		slog.Warn("[contact] Attachment rejected", "error", err)
+		httputil.SendJSON(w, attErr.Status, model.APIResponse{
+			Success: false, Message: attErr.Message,
+		})
+		return
+	}
+	if err != nil {
 		slog.Warn("[contact] Invalid request body", "error", err)
 		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
 			Success: false, Message: "Invalid request body",
@@ -39,42 +81,100 @@ func (h *ContactHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Name == "" || req.Email == "" || req.Message == "" {
-		slog.Warn("[contact] Missing required fields", "name", req.Name, "email", req.Email)
+	if errs := h.validator.Validate(r.Context(), &req); errs != nil {
+		slog.Warn("[contact] Validation failed", "errors", errs.Error())
 		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
-			Success: false, Message: "Name, email, and message are required",
+			Success: false,
+			Message: "Please correct the highlighted fields",
+			Data:    map[string]any{"errors": errs},
 		})
 		return
 	}
 
-	if !strings.Contains(req.Email, "@") {
-		slog.Warn("[contact] Invalid email address", "email", req.Email)
-		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
-			Success: false, Message: "Invalid email address",
+	if err := service.StoreAttachments(h.blobs, req.Attachments); err != nil {
+		slog.Error("[contact] Failed to store attachments", "error", err)
+		httputil.SendJSON(w, http.StatusInternalServerError, model.APIResponse{
+			Success: false, Message: "Failed to store attachments",
 		})
 		return
 	}
 
-	slog.WithData(slog.M{
-		"name":    req.Name,
-		"email":   req.Email,
-		"subject": req.Subject,
-	}).Info("[contact] New submission")
-
 	if err := h.logger.Log(req); err != nil {
 		slog.Error("[contact] Failed to log contact", "error", err)
 	}
 
+	var geo *model.GeoLocation
+	if _, loc := h.locator.Locate(r); loc.Country != "" {
+		geo = &loc
+	}
+
+	var contactID string
+	if rec, err := h.store.Create(req, geo); err != nil {
+		slog.Error("[contact] Failed to store contact", "error", err)
+	} else {
+		contactID = rec.ID
+	}
+	// Only the ID: names and addresses must not reach the application log.
+	slog.Info("[contact] New submission", "id", contactID, "attachments", len(req.Attachments))
+	h.events.Publish(service.EventContactNew, model.ContactEvent{
+		ID:          contactID,
+		Name:        req.Name,
+		Email:       req.Email,
+		Subject:     req.Subject,
+		Attachments: len(req.Attachments),
+		Geo:         geo,
+	})
+
 	// Respond immediately; send email in background.
 	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
 		Success: true, Message: "Message received! I'll get back to you soon.",
 	})
 
-	go func() {
-		if err := h.email.Send(req); err != nil {
-			slog.Error("[contact] Failed to send email", "error", err, "email", req.Email)
+	h.tasks.Go(func(ctx context.Context) {
+		if err := h.email.Send(ctx, req); err != nil {
+			slog.Error("[contact] Failed to send email", "error", err, "id", contactID)
 		} else {
-			slog.Info("[contact] Email sent successfully", "email", req.Email)
+			slog.Info("[contact] Email sent successfully", "id", contactID)
 		}
-	}()
+	})
+
+	h.tasks.Go(func(ctx context.Context) { h.leads.Route(ctx, contactID, req) })
+}
+
+// decode reads a submission from either a JSON body or a multipart form.
+// Attachments are only accepted through multipart uploads.
+func (h *ContactHandler) decode(w http.ResponseWriter, r *http.Request) (model.ContactRequest, error) {
+	var req model.ContactRequest
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		err := json.NewDecoder(r.Body).Decode(&req)
+		req.Attachments = nil
+		return req, err
+	}
+
+	// Allow some headroom over the attachment limit for the text fields and multipart framing.
+	r.Body = http.MaxBytesReader(w, r.Body, h.policy.MaxTotalSize+1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			return req, &service.AttachmentError{
+				Status: http.StatusRequestEntityTooLarge, Message: "Request too large",
+			}
+		}
+		return req, err
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	req.Name = r.FormValue("name")
+	req.Email = r.FormValue("email")
+	req.Subject = r.FormValue("subject")
+	req.Message = r.FormValue("message")
+
+	atts, err := h.policy.ReadAttachments(r.MultipartForm.File["attachments"])
+	if err != nil {
+		return req, err
+	}
+	req.Attachments = atts
+	return req, nil
 }
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/httputil"
+	"portfolio-backend/internal/model"
+	"portfolio-backend/internal/service"
+	"portfolio-backend/internal/validation"
+)
+
+// Guestbook page sizes.
+const (
+	guestbookPerPage    = 20
+	guestbookMaxPerPage = 50
+)
+
+// GuestbookHandler serves the public guestbook.
+//
+//	POST /api/guestbook                      {"name","message","website"} submit for moderation
+//	GET  /api/guestbook?page=1&perPage=20    approved entries, newest first
+type GuestbookHandler struct {
+	guestbook *service.GuestbookService
+	validator *validation.ContactValidator
+	locator   *ClientLocator
+}
+
+func NewGuestbookHandler(guestbook *service.GuestbookService, validator *validation.ContactValidator, locator *ClientLocator) *GuestbookHandler {
+	return &GuestbookHandler{guestbook: guestbook, validator: validator, locator: locator}
+}
+
+func (h *GuestbookHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.list(w, r)
+	case http.MethodPost:
+		h.submit(w, r)
+	default:
+		httputil.SendJSON(w, http.StatusMethodNotAllowed, model.APIResponse{
+			Success: false, Message: "Method not allowed",
+		})
+	}
+}
+
+func (h *GuestbookHandler) list(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, perPage := 1, guestbookPerPage
+	if n, err := strconv.Atoi(q.Get("page")); err == nil && n > 0 {
+		page = n
+	}
+	if n, err := strconv.Atoi(q.Get("perPage")); err == nil && n > 0 {
+		perPage = min(n, guestbookMaxPerPage)
+	}
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+		Success: true, Data: h.guestbook.Page(page, perPage),
+	})
+}
+
+func (h *GuestbookHandler) submit(w http.ResponseWriter, r *http.Request) {
+	var req model.GuestbookRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 16<<10)).Decode(&req); err != nil {
+		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+			Success: false, Message: "Invalid request body",
+		})
+		return
+	}
+	if errs := h.validator.ValidateGuestbook(&req); errs != nil {
+		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+			Success: false,
+			Message: "Please correct the highlighted fields",
+			Data:    map[string]any{"errors": errs},
+		})
+		return
+	}
+
+	ip, loc := h.locator.Locate(r)
+	_, err := h.guestbook.Submit(req, ip, loc)
+	switch {
+	case errors.Is(err, service.ErrGuestbookRateLimited):
+		httputil.SendJSON(w, http.StatusTooManyRequests, model.APIResponse{
+			Success: false, Message: "You have signed the guestbook a few times already; please try again later",
+		})
+	case errors.Is(err, service.ErrGuestbookDuplicate):
+		httputil.SendJSON(w, http.StatusConflict, model.APIResponse{
+			Success: false, Message: "This message is already in the guestbook",
+		})
+	case err != nil:
+		slog.Error("[guestbook] Failed to store entry", "error", err)
+		httputil.SendJSON(w, http.StatusInternalServerError, model.APIResponse{
+			Success: false, Message: "Failed to sign the guestbook",
+		})
+	default:
+		httputil.SendJSON(w, http.StatusAccepted, model.APIResponse{
+			Success: true, Message: "Thanks for signing! Your entry will appear once it's approved.",
+		})
+	}
+}
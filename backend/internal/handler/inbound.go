@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/httputil"
+	"portfolio-backend/internal/model"
+	"portfolio-backend/internal/service"
+)
+
+// InboundEmailHandler receives forwarded emails from the mail provider's
+// inbound webhook and attaches them to the matching contact thread.
+type InboundEmailHandler struct {
+	threads *service.ContactThreads
+	secret  string
+}
+
+func NewInboundEmailHandler(threads *service.ContactThreads, secret string) *InboundEmailHandler {
+	return &InboundEmailHandler{threads: threads, secret: secret}
+}
+
+func (h *InboundEmailHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httputil.SendJSON(w, http.StatusMethodNotAllowed, model.APIResponse{
+			Success: false, Message: "Method not allowed",
+		})
+		return
+	}
+
+	if !h.authorized(r) {
+		slog.Warn("[inbound] Rejected webhook with bad secret", "remoteAddr", r.RemoteAddr)
+		httputil.SendJSON(w, http.StatusUnauthorized, model.APIResponse{
+			Success: false, Message: "Unauthorized",
+		})
+		return
+	}
+
+	in, err := service.ParseInboundEmail(r)
+	if err != nil {
+		slog.Warn("[inbound] Invalid payload", "error", err)
+		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+			Success: false, Message: "Invalid email payload",
+		})
+		return
+	}
+
+	rec, err := h.threads.AttachInbound(in)
+	if errors.Is(err, service.ErrContactNotFound) {
+		// Acknowledge so the provider does not retry mail we cannot place.
+		slog.Notice("[inbound] No matching thread", "from", in.From, "inReplyTo", in.InReplyTo)
+		httputil.SendJSON(w, http.StatusAccepted, model.APIResponse{
+			Success: true, Message: "No matching conversation",
+		})
+		return
+	}
+	if err != nil {
+		slog.Error("[inbound] Failed to attach reply", "error", err)
+		httputil.SendJSON(w, http.StatusInternalServerError, model.APIResponse{
+			Success: false, Message: "Failed to store email",
+		})
+		return
+	}
+
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+		Success: true,
+		Message: "Reply attached",
+		Data:    map[string]string{"contactId": rec.ID},
+	})
+}
+
+// authorized checks the shared secret from the X-Webhook-Secret header. It
+// is not accepted in the query string, which ends up in access logs. An
+// unset secret disables the endpoint.
+func (h *InboundEmailHandler) authorized(r *http.Request) bool {
+	if h.secret == "" {
+		return false
+	}
+	got := r.Header.Get("X-Webhook-Secret")
+	return subtle.ConstantTimeCompare([]byte(got), []byte(h.secret)) == 1
+}
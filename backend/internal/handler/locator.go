@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"net/http"
+
+	"portfolio-backend/internal/geo"
+	"portfolio-backend/internal/httputil"
+	"portfolio-backend/internal/model"
+)
+
+// ClientLocator works out which address a request came from and where that
+// address is.
+type ClientLocator struct {
+	geo        *geo.DB
+	trustProxy bool
+}
+
+// NewClientLocator creates a locator. db may be nil when GeoIP is disabled.
+func NewClientLocator(db *geo.DB, trustProxy bool) *ClientLocator {
+	return &ClientLocator{geo: db, trustProxy: trustProxy}
+}
+
+// Locate returns the client IP of r and its location, which is zero when
+// unknown.
+func (l *ClientLocator) Locate(r *http.Request) (string, model.GeoLocation) {
+	ip := httputil.ClientIP(r, l.trustProxy)
+	return ip, l.geo.Lookup(ip)
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"portfolio-backend/internal/httputil"
+	"portfolio-backend/internal/model"
+	"portfolio-backend/internal/service"
+)
+
+// Reaction totals page sizes.
+const (
+	reactionsPerPage    = 50
+	reactionsMaxPerPage = 100
+)
+
+// ReactionHandler serves all-time reaction totals.
+//
+//	GET /api/reactions?page=1&perPage=50   items ordered by name
+//	GET /api/reactions?item=<id>           one item
+//
+// Reactions themselves are sent over /ws/visitors.
+type ReactionHandler struct {
+	store  service.ReactionStore
+	policy service.ReactionPolicy
+}
+
+func NewReactionHandler(store service.ReactionStore, policy service.ReactionPolicy) *ReactionHandler {
+	return &ReactionHandler{store: store, policy: policy}
+}
+
+func (h *ReactionHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendJSON(w, http.StatusMethodNotAllowed, model.APIResponse{
+			Success: false, Message: "Method not allowed",
+		})
+		return
+	}
+
+	q := r.URL.Query()
+	resp := model.ReactionsResponse{Emojis: h.policy.Emojis, Items: []model.ReactionTotals{}, Page: 1, PerPage: reactionsPerPage}
+	if raw := q.Get("item"); raw != "" {
+		item := normalizeItem(raw)
+		if item == "" {
+			httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+				Success: false, Message: "Invalid item",
+			})
+			return
+		}
+		resp.Items = append(resp.Items, model.ReactionTotals{Item: item, Totals: h.store.Totals(item)})
+		resp.Total = 1
+		httputil.SendJSON(w, http.StatusOK, model.APIResponse{Success: true, Data: resp})
+		return
+	}
+
+	if n, err := strconv.Atoi(q.Get("page")); err == nil && n > 0 {
+		resp.Page = n
+	}
+	if n, err := strconv.Atoi(q.Get("perPage")); err == nil && n > 0 {
+		resp.PerPage = min(n, reactionsMaxPerPage)
+	}
+	all := h.store.All()
+	items := make([]string, 0, len(all))
+	for item := range all {
+		items = append(items, item)
+	}
+	sort.Strings(items)
+	resp.Total = len(items)
+	if start := (resp.Page - 1) * resp.PerPage; start >= 0 && start < len(items) {
+		for _, item := range items[start:min(start+resp.PerPage, len(items))] {
+			resp.Items = append(resp.Items, model.ReactionTotals{Item: item, Totals: all[item]})
+		}
+	}
+
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{Success: true, Data: resp})
+}
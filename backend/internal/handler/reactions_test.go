@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"portfolio-backend/internal/model"
+	"portfolio-backend/internal/service"
+)
+
+func TestReactionHandlerPaginates(t *testing.T) {
+	store, err := service.NewFileReactionStore(filepath.Join(t.TempDir(), "reactions.json"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range []string{"project:c", "project:a", "project:b"} {
+		if _, err := store.Add(item, map[string]int{"👏": 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := NewReactionHandler(store, service.ReactionPolicy{Emojis: []string{"👏"}})
+
+	for _, tc := range []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"project:a", "project:b", "project:c"}},
+		{"?perPage=2", []string{"project:a", "project:b"}},
+		{"?perPage=2&page=2", []string{"project:c"}},
+		{"?perPage=2&page=3", nil},
+		{"?page=9223372036854775807", nil},
+	} {
+		rec := httptest.NewRecorder()
+		h.Handle(rec, httptest.NewRequest(http.MethodGet, "/api/reactions"+tc.query, nil))
+		var body struct {
+			Data model.ReactionsResponse `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: %v: %s", tc.query, err, rec.Body)
+		}
+		var got []string
+		for _, it := range body.Data.Items {
+			got = append(got, it.Item)
+		}
+		if len(got) != len(tc.want) || body.Data.Total != 3 {
+			t.Errorf("%s: items %v of %d, want %v of 3", tc.query, got, body.Data.Total, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%s: items %v, want %v", tc.query, got, tc.want)
+				break
+			}
+		}
+	}
+}
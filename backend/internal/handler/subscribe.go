@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/httputil"
+	"portfolio-backend/internal/model"
+	"portfolio-backend/internal/service"
+	"portfolio-backend/internal/validation"
+)
+
+// SubscribeHandler serves newsletter sign-up.
+//
+//	POST /api/subscribe                      {"email"} start double opt-in
+//	GET  /api/subscribe/confirm?token=…      activate (link in confirmation email)
+//	GET  /api/subscribe/unsubscribe?token=…  confirmation page
+//	POST /api/subscribe/unsubscribe?token=…  unsubscribe (RFC 8058 one-click)
+type SubscribeHandler struct {
+	newsletter *service.NewsletterService
+	validator  *validation.ContactValidator
+}
+
+func NewSubscribeHandler(newsletter *service.NewsletterService, validator *validation.ContactValidator) *SubscribeHandler {
+	return &SubscribeHandler{newsletter: newsletter, validator: validator}
+}
+
+func (h *SubscribeHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch action := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/api/subscribe"), "/"); {
+	case action == "" && r.Method == http.MethodPost:
+		h.subscribe(w, r)
+	case action == "confirm" && r.Method == http.MethodGet:
+		h.confirm(w, r)
+	case action == "unsubscribe" && r.Method == http.MethodGet:
+		h.unsubscribePage(w, r)
+	case action == "unsubscribe" && r.Method == http.MethodPost:
+		h.unsubscribe(w, r)
+	case action == "" || action == "confirm" || action == "unsubscribe":
+		httputil.SendJSON(w, http.StatusMethodNotAllowed, model.APIResponse{
+			Success: false, Message: "Method not allowed",
+		})
+	default:
+		httputil.SendJSON(w, http.StatusNotFound, model.APIResponse{
+			Success: false, Message: "Not found",
+		})
+	}
+}
+
+func (h *SubscribeHandler) subscribe(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+			Success: false, Message: "Invalid request body",
+		})
+		return
+	}
+	email, errs := h.validator.ValidateEmail(r.Context(), req.Email)
+	if errs != nil {
+		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+			Success: false,
+			Message: "Please correct the highlighted fields",
+			Data:    map[string]any{"errors": errs},
+		})
+		return
+	}
+
+	if err := h.newsletter.Subscribe(r.Context(), email); err != nil {
+		slog.Error("[newsletter] Subscribe failed", "error", err)
+		httputil.SendJSON(w, http.StatusInternalServerError, model.APIResponse{
+			Success: false, Message: "Failed to subscribe",
+		})
+		return
+	}
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+		Success: true, Message: "Check your inbox to confirm your subscription.",
+	})
+}
+
+func (h *SubscribeHandler) confirm(w http.ResponseWriter, r *http.Request) {
+	_, err := h.newsletter.Confirm(r.URL.Query().Get("token"))
+	if err != nil {
+		h.tokenError(w, err)
+		return
+	}
+	httputil.SendPage(w, http.StatusOK, "Subscription confirmed", "<p>Thanks! You will get an email when something new is published.</p>")
+}
+
+func (h *SubscribeHandler) unsubscribePage(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	httputil.SendPage(w, http.StatusOK, "Unsubscribe?", fmt.Sprintf(
+		`<form method="post" action="/api/subscribe/unsubscribe?token=%s">`+
+			`<button type="submit">Unsubscribe</button></form>`,
+		html.EscapeString(token)))
+}
+
+// unsubscribe handles both the confirmation form and RFC 8058 one-click
+// POSTs from mail clients, which send "List-Unsubscribe=One-Click".
+func (h *SubscribeHandler) unsubscribe(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.newsletter.Unsubscribe(r.URL.Query().Get("token")); err != nil {
+		h.tokenError(w, err)
+		return
+	}
+	httputil.SendPage(w, http.StatusOK, "Unsubscribed", "<p>You will not receive further emails.</p>")
+}
+
+func (h *SubscribeHandler) tokenError(w http.ResponseWriter, err error) {
+	if errors.Is(err, service.ErrInvalidSignature) || errors.Is(err, service.ErrSubscriberNotFound) {
+		httputil.SendPage(w, http.StatusBadRequest, "Link not valid", "<p>This link is invalid or has expired.</p>")
+		return
+	}
+	slog.Error("[newsletter] Token action failed", "error", err)
+	httputil.SendPage(w, http.StatusInternalServerError, "Something went wrong", "<p>Please try again later.</p>")
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"portfolio-backend/internal/middleware"
+	"portfolio-backend/internal/model"
+	"portfolio-backend/internal/service"
+)
+
+func TestUnsubscribeFormFromPublicURL(t *testing.T) {
+	store, err := service.NewFileSubscriberStore(filepath.Join(t.TempDir(), "subscribers.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := store.Create(model.Subscriber{Email: "reader@example.com", Status: model.SubscriberActive, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := service.NewFileIssueStore(filepath.Join(t.TempDir(), "sends.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newsletter := service.NewNewsletterService(store, issues, &recordedEmails{}, testTasks(t), []byte("0123456789abcdef"), testPublicURL, time.Hour, 1)
+	h := middleware.CORS(testOrigins(t), NewSubscribeHandler(newsletter, nil).Handle)
+
+	unsubURL, err := url.Parse(newsletter.UnsubscribeURL(sub.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := submitForm(t, h, unsubURL.RequestURI())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unsubscribe form: status %d: %s", rec.Code, rec.Body)
+	}
+	if got, _ := store.Get(sub.ID); got.Status != model.SubscriberUnsubscribed {
+		t.Errorf("status = %s, want %s", got.Status, model.SubscriberUnsubscribed)
+	}
+}
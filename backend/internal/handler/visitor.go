@@ -1,125 +1,934 @@
 package handler
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"math"
 	"net/http"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/gookit/slog"
 	"github.com/gorilla/websocket"
+
+	"portfolio-backend/internal/botdetect"
+	"portfolio-backend/internal/httputil"
+	"portfolio-backend/internal/middleware"
+	"portfolio-backend/internal/model"
+	"portfolio-backend/internal/service"
+	"portfolio-backend/internal/wsproto"
+)
+
+const (
+	// writeWait bounds a single write to a client.
+	writeWait = 10 * time.Second
+	// pongWait is how long a client may stay silent before it is considered dead.
+	pongWait = 60 * time.Second
+	// pingPeriod must be shorter than pongWait so pongs arrive in time.
+	pingPeriod = pongWait * 9 / 10
+	// maxMessageSize caps inbound frames; visitors only send small control messages.
+	maxMessageSize = 512
+	// sendBuffer is how many outbound messages may queue before a client is
+	// treated as a slow consumer and evicted.
+	sendBuffer = 16
+	// broadcastInterval coalesces count changes so connection bursts cost
+	// one broadcast instead of one per connect.
+	broadcastInterval = 250 * time.Millisecond
+	// historyInterval is how often the total is sampled into the history.
+	historyInterval = time.Minute
+	// shutdownReason is sent with the going-away close frame on shutdown.
+	shutdownReason = "server restarting, reconnect"
 )
 
 // VisitorHandler manages real-time visitor tracking over WebSocket.
 type VisitorHandler struct {
 	hub      *visitorHub
+	stats    *service.VisitorStats
+	history  *service.VisitorHistory
 	upgrader websocket.Upgrader
+	locator  *ClientLocator
+	bots     *botdetect.Classifier
+	limiter  *ConnectionLimiter
 }
 
-func NewVisitorHandler() *VisitorHandler {
+// VisitorOptions configures a VisitorHandler.
+type VisitorOptions struct {
+	Stats     *service.VisitorStats
+	History   *service.VisitorHistory
+	Analytics *service.AnalyticsService
+	// Backplane shares local counts with other instances, at least every
+	// Heartbeat.
+	Backplane service.PresenceBackplane
+	Heartbeat time.Duration
+	Locator   *ClientLocator
+	Events    service.EventPublisher
+	// Reactions stores reactions allowed by ReactionPolicy.
+	Reactions      service.ReactionStore
+	ReactionPolicy service.ReactionPolicy
+	Bots           *botdetect.Classifier
+	BotGrace       time.Duration
+	Origins        *middleware.OriginPolicy
+	Limiter        *ConnectionLimiter
+}
+
+// NewVisitorHandler creates the handler. Local counts are published to the
+// backplane at least every heartbeat, and counts sent to visitors include
+// those of the other instances on it. Finished sessions go to analytics,
+// and connects, page views and disconnects to events. Reactions allowed by
+// the policy are stored and announced to the item's viewers on this
+// instance.
+//
+// Connections that the bot classifier flags are never counted. Others are
+// counted once they send a message or stay connected for BotGrace, so
+// pingers that connect and leave do not show up as visitors.
+//
+// Upgrades are refused beyond the limiter's connection limits, and from
+// origins the policy does not allow; wrap Handle in the limiter's
+// CheckOrigin so those refusals are counted. The total is sampled into history every
+// minute.
+func NewVisitorHandler(opts VisitorOptions) *VisitorHandler {
 	return &VisitorHandler{
-		hub: newVisitorHub(),
+		stats:   opts.Stats,
+		history: opts.History,
+		locator: opts.Locator,
+		bots:    opts.Bots,
+		limiter: opts.Limiter,
+		hub:     newVisitorHub(opts),
 		upgrader: websocket.Upgrader{
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
-			CheckOrigin:     func(r *http.Request) bool { return true },
+			CheckOrigin:     opts.Origins.CheckOrigin,
 		},
 	}
 }
 
-// RunHub starts the hub event loop. Call this in a goroutine before accepting connections.
-func (h *VisitorHandler) RunHub() { h.hub.run() }
+// Schema serves the JSON Schema of the WebSocket protocol.
+func (h *VisitorHandler) Schema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendJSON(w, http.StatusMethodNotAllowed, model.APIResponse{
+			Success: false, Message: "Method not allowed",
+		})
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Write(wsproto.Schema)
+}
+
+// RunHub starts the hub event loop. Call this in a goroutine before
+// accepting connections. When ctx is done, every visitor is sent a
+// going-away close frame and RunHub returns once those are written; later
+// connections are turned away.
+func (h *VisitorHandler) RunHub(ctx context.Context) { h.hub.run(ctx) }
 
 // Handle upgrades an HTTP request to a WebSocket connection and tracks the visitor.
 func (h *VisitorHandler) Handle(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+	ip, loc := h.locator.Locate(r)
+	release, reason := h.limiter.Acquire(ip)
+	if release == nil {
+		h.limiter.Refuse(w, r, reason)
+		return
+	}
 
 	conn, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
+		release()
 		slog.Error("[visitor] WebSocket upgrade failed", "error", err, "remoteAddr", r.RemoteAddr)
 		return
 	}
 
 	slog.Debug("[visitor] New connection", "remoteAddr", r.RemoteAddr)
 
-	h.hub.register <- conn
+	c := &visitorClient{
+		id:          newSessionID(),
+		hub:         h.hub,
+		conn:        conn,
+		send:        make(chan []byte, sendBuffer),
+		ip:          ip,
+		userAgent:   r.UserAgent(),
+		agent:       h.bots.Agent(r.UserAgent()),
+		loc:         loc,
+		connectedAt: time.Now(),
+		release:     release,
+		class:       classUnverified,
+		botSignals:  h.bots.Request(r, true),
+		rooms:       map[string]string{},
+	}
+	if len(c.botSignals) > 0 {
+		c.class = classBot
+	}
+	if !h.hub.add(c) {
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, shutdownReason), time.Now().Add(writeWait))
+		conn.Close()
+		release()
+		return
+	}
+
+	go c.writePump()
+	go c.readPump()
+}
 
-	// Send current count immediately.
-	count := h.hub.getCount()
-	msg, _ := json.Marshal(map[string]int{"count": count})
-	conn.WriteMessage(websocket.TextMessage, msg)
+// ---------- client ----------
 
-	// Read loop detects disconnection.
-	go func() {
-		defer func() { h.hub.unregister <- conn }()
-		for {
-			if _, _, err := conn.ReadMessage(); err != nil {
-				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-					slog.Warn("[visitor] Unexpected close", "error", err)
-				}
-				return
+// visitorClient is one connection. The hub only ever queues messages on send;
+// writePump is the connection's sole writer and readPump its sole reader.
+type visitorClient struct {
+	hub  *visitorHub
+	conn *websocket.Conn
+	send chan []byte
+
+	id          string
+	ip          string
+	userAgent   string
+	agent       string // matched bot pattern, if any
+	loc         model.GeoLocation
+	connectedAt time.Time
+	release     func() // frees the connection's limiter slot
+
+	// The fields below are owned by the hub goroutine.
+
+	// closeMsg is the close frame writePump sends once send is closed.
+	closeMsg []byte
+
+	// class says whether the client is counted; botSignals explain why a
+	// bot is not.
+	class      string
+	botSignals []string
+
+	// session is set to id once the client has said hello; until then it
+	// is a legacy client that only understands {"count": N}.
+	session string
+	caps    map[string]bool
+	// rooms maps a room kind to the room the client is in. Only counted
+	// clients are members of the hub's rooms.
+	rooms map[string]string
+	// allowance limits how fast the client may react.
+	allowance tokenBucket
+}
+
+// Room kinds. Clients choose their page, section and item rooms; the
+// country room is joined on connect from GeoIP.
+const (
+	roomPage    = "page"
+	roomSection = "section"
+	roomItem    = "item"
+	roomCountry = "country"
+)
+
+// Client classes. New connections are unverified until they send a message
+// or stay long enough; only humans are counted.
+const (
+	classUnverified = "unverified"
+	classHuman      = "human"
+	classBot        = "bot"
+)
+
+// clientMessage is a decoded client message (or decode error) queued from a
+// reader to the hub.
+type clientMessage struct {
+	client  *visitorClient
+	env     wsproto.Envelope
+	payload any
+	err     *wsproto.Error
+}
+
+// readPump forwards client messages to the hub, detects disconnection and
+// keeps the read deadline moving on pongs.
+func (c *visitorClient) readPump() {
+	defer func() {
+		c.hub.drop(c)
+		c.conn.Close()
+		c.release()
+	}()
+
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				slog.Warn("[visitor] Unexpected close", "error", err)
 			}
+			return
+		}
+
+		env, payload, perr := wsproto.Decode(data)
+		select {
+		case c.hub.inbound <- clientMessage{client: c, env: env, payload: payload, err: perr}:
+		case <-c.hub.done:
+			return
+		}
+	}
+}
+
+// normalizePage keeps the path of a page URL, dropping query and fragment.
+func normalizePage(p string) string {
+	if i := strings.IndexAny(p, "?#"); i >= 0 {
+		p = p[:i]
+	}
+	if p == "" || p[0] != '/' || len(p) > 200 {
+		return ""
+	}
+	return p
+}
+
+// normalizeSection accepts short identifiers such as "projects".
+func normalizeSection(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) > 40 {
+		return ""
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return ""
+		}
+	}
+	return s
+}
+
+// normalizeItem accepts identifiers such as "project:graphql-parser" or
+// "post:2024/go-generics".
+func normalizeItem(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) > 80 {
+		return ""
+	}
+	for i, r := range s {
+		alnum := r >= 'a' && r <= 'z' || r >= '0' && r <= '9'
+		if !alnum && (i == 0 || !strings.ContainsRune(":/._-", r)) {
+			return ""
 		}
+	}
+	return s
+}
+
+// tokenBucket holds up to burst tokens, refilled at rate per second. It
+// starts full.
+type tokenBucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// take withdraws up to n tokens and returns how many were allowed.
+func (a *tokenBucket) take(n int, rate float64, burst int, now time.Time) int {
+	if a.at.IsZero() {
+		a.tokens = float64(burst)
+	} else {
+		a.tokens = math.Min(float64(burst), a.tokens+now.Sub(a.at).Seconds()*rate)
+	}
+	a.at = now
+	if avail := int(a.tokens); n > avail {
+		n = avail
+	}
+	a.tokens -= float64(n)
+	return n
+}
+
+// writePump drains send and pings the client. It exits, closing the
+// connection, when the hub closes send or a write fails.
+func (c *visitorClient) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+		c.hub.writers.Done()
 	}()
+
+	for {
+		select {
+		case msg, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, c.closeMsg)
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				slog.Debug("[visitor] Write failed", "error", err)
+				return
+			}
+
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
 }
 
 // ---------- hub ----------
 
+// visitorHub owns the client set and room membership. Only the run
+// goroutine touches them, so they need no lock.
 type visitorHub struct {
-	clients    map[*websocket.Conn]bool
-	register   chan *websocket.Conn
-	unregister chan *websocket.Conn
-	mu         sync.RWMutex
+	clients    map[*visitorClient]bool
+	rooms      map[string]map[*visitorClient]bool // "kind:name" -> members
+	register   chan *visitorClient
+	unregister chan *visitorClient
+	inbound    chan clientMessage
+	announce   chan announcement
+	stats      *service.VisitorStats
+	history    *service.VisitorHistory
+	analytics  *service.AnalyticsService
+	events     service.EventPublisher
+
+	// done is closed once the hub has stopped; writers tracks the
+	// WebSocket writers still running.
+	done    chan struct{}
+	writers sync.WaitGroup
+
+	// pending aggregates reactions by item and emoji until the next tick.
+	reactions service.ReactionStore
+	policy    service.ReactionPolicy
+	pending   map[string]map[string]int
+
+	// humans is the number of counted local clients; unverified holds the
+	// clients that may still be promoted after botGrace.
+	humans     int
+	unverified map[*visitorClient]bool
+	botGrace   time.Duration
+
+	// backplane shares local counts with other instances; remote is the
+	// latest sum of theirs.
+	backplane service.PresenceBackplane
+	heartbeat time.Duration
+	remote    model.PresenceCounts
 }
 
-func newVisitorHub() *visitorHub {
+func newVisitorHub(opts VisitorOptions) *visitorHub {
 	return &visitorHub{
-		clients:    make(map[*websocket.Conn]bool),
-		register:   make(chan *websocket.Conn),
-		unregister: make(chan *websocket.Conn),
+		unverified: make(map[*visitorClient]bool),
+		botGrace:   opts.BotGrace,
+		stats:      opts.Stats,
+		history:    opts.History,
+		analytics:  opts.Analytics,
+		events:     opts.Events,
+		reactions:  opts.Reactions,
+		policy:     opts.ReactionPolicy,
+		pending:    make(map[string]map[string]int),
+		backplane:  opts.Backplane,
+		heartbeat:  opts.Heartbeat,
+		clients:    make(map[*visitorClient]bool),
+		rooms:      make(map[string]map[*visitorClient]bool),
+		register:   make(chan *visitorClient),
+		unregister: make(chan *visitorClient),
+		inbound:    make(chan clientMessage),
+		announce:   make(chan announcement, 16),
+		done:       make(chan struct{}),
 	}
 }
 
-func (h *visitorHub) run() {
+// announcement is a message for every client with a capability.
+type announcement struct {
+	capability string
+	msg        []byte
+}
+
+// Announce sends msg to every visitor connected to this instance whose
+// client negotiated capability.
+func (h *VisitorHandler) Announce(capability string, msg []byte) {
+	select {
+	case h.hub.announce <- announcement{capability: capability, msg: msg}:
+	case <-h.hub.done:
+	}
+}
+
+func (h *visitorHub) run(ctx context.Context) {
+	ticker := time.NewTicker(broadcastInterval)
+	defer ticker.Stop()
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	sample := time.NewTicker(historyInterval)
+	defer sample.Stop()
+	h.backplane.Publish(h.snapshot())
+
+	// local means our own counts changed and must be published; dirty means
+	// the totals changed and must be broadcast. Remote updates only set
+	// dirty, so instances do not echo each other's publishes.
+	local, dirty := false, false
+
 	for {
 		select {
-		case conn := <-h.register:
-			h.mu.Lock()
-			h.clients[conn] = true
-			count := len(h.clients)
-			h.mu.Unlock()
-			slog.Info("[visitor] Connected", "total", count)
-			h.broadcast(count)
+		case <-ctx.Done():
+			h.shutdown()
+			return
+
+		case c := <-h.register:
+			h.clients[c] = true
+			if c.conn != nil {
+				h.writers.Add(1)
+			}
+			h.join(c, roomCountry, c.loc.Country)
+			if c.class == classBot {
+				slog.Debug("[visitor] Bot connected", "agent", c.agent, "signals", c.botSignals)
+				h.events.Publish(service.EventVisitorBot, h.visitorEvent(c))
+			} else {
+				h.unverified[c] = true
+			}
+			// The newcomer gets the count now.
+			h.queue(c, h.presence(c, h.kindCounts(roomSection), h.kindCounts(roomCountry)))
 
-		case conn := <-h.unregister:
-			h.mu.Lock()
-			if _, ok := h.clients[conn]; ok {
-				delete(h.clients, conn)
-				conn.Close()
+		case m := <-h.inbound:
+			if !h.clients[m.client] {
+				continue
+			}
+			if h.handle(m) {
+				local = true
+			}
+			// Any valid message is an interaction.
+			if m.err == nil && m.client.class == classUnverified {
+				h.promote(m.client)
+				local = true
+			}
+
+		case a := <-h.announce:
+			if h.announceAll(a) > 0 {
+				local = true
+			}
+
+		case c := <-h.unregister:
+			if !h.remove(c) || c.class != classHuman {
+				continue
+			}
+			total := h.total()
+			slog.Info("[visitor] Disconnected", "local", h.humans, "total", total)
+			h.stats.Disconnected(total)
+			local = true
+
+		case counts := <-h.backplane.Remote():
+			h.remote = counts
+			h.stats.Observe(h.total())
+			dirty = true
+
+		case <-heartbeat.C:
+			h.backplane.Publish(h.snapshot())
+
+		case now := <-sample.C:
+			h.history.Sample(now, h.total())
+
+		case now := <-ticker.C:
+			for c := range h.unverified {
+				if now.Sub(c.connectedAt) >= h.botGrace {
+					h.promote(c)
+					local = true
+				}
+			}
+			if local {
+				h.backplane.Publish(h.snapshot())
+				local, dirty = false, true
+			}
+			if dirty {
+				// Evictions change the count again, so publish and announce
+				// that next tick.
+				local = h.broadcast() > 0
+				dirty = false
+			}
+			if len(h.pending) > 0 && h.flushReactions() > 0 {
+				local = true
 			}
-			count := len(h.clients)
-			h.mu.Unlock()
-			slog.Info("[visitor] Disconnected", "total", count)
-			h.broadcast(count)
 		}
 	}
 }
 
-func (h *visitorHub) broadcast(count int) {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+// add hands c to the hub. It reports false once the hub has stopped.
+func (h *visitorHub) add(c *visitorClient) bool {
+	select {
+	case h.register <- c:
+		return true
+	case <-h.done:
+		return false
+	}
+}
+
+// drop unregisters c, if the hub is still running.
+func (h *visitorHub) drop(c *visitorClient) {
+	select {
+	case h.unregister <- c:
+	case <-h.done:
+	}
+}
+
+// shutdown saves pending reactions, closes every client with a going-away
+// frame and waits for the WebSocket writers to send it.
+func (h *visitorHub) shutdown() {
+	if len(h.pending) > 0 {
+		h.flushReactions()
+	}
+	closeMsg := websocket.FormatCloseMessage(websocket.CloseGoingAway, shutdownReason)
+	n := len(h.clients)
+	for c := range h.clients {
+		c.closeMsg = closeMsg
+		h.remove(c)
+	}
+	close(h.done)
+	h.writers.Wait()
+	slog.Info("[visitor] Hub stopped", "closed", n)
+}
+
+// total is the number of visitors across all instances.
+func (h *visitorHub) total() int { return h.humans + h.remote.Count }
+
+// roomCount is the number of visitors in a room across all instances.
+func (h *visitorHub) roomCount(key string) int { return len(h.rooms[key]) + h.remote.Rooms[key] }
+
+// snapshot captures the local counts for the backplane.
+func (h *visitorHub) snapshot() model.PresenceSnapshot {
+	rooms := make(map[string]int, len(h.rooms))
+	for key, members := range h.rooms {
+		rooms[key] = len(members)
+	}
+	return model.PresenceSnapshot{Count: h.humans, Rooms: rooms}
+}
+
+// promote starts counting an unverified client.
+func (h *visitorHub) promote(c *visitorClient) {
+	delete(h.unverified, c)
+	c.class = classHuman
+	h.humans++
+	for kind, name := range c.rooms {
+		h.enter(c, kind+":"+name)
+	}
+	total := h.total()
+	slog.Info("[visitor] Connected", "local", h.humans, "total", total)
+	h.stats.Connected(total)
+	h.events.Publish(service.EventVisitorConnect, h.visitorEvent(c))
+}
 
-	msg, _ := json.Marshal(map[string]int{"count": count})
-	for conn := range h.clients {
-		if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
-			slog.Error("[visitor] Broadcast write failed", "error", err)
-			conn.Close()
-			delete(h.clients, conn)
+// markBot stops counting c, reporting signal as the reason. It reports
+// whether the counts changed.
+func (h *visitorHub) markBot(c *visitorClient, signal string) bool {
+	if c.class == classBot {
+		return false
+	}
+	counted := c.class == classHuman
+	delete(h.unverified, c)
+	if counted {
+		h.humans--
+		for kind, name := range c.rooms {
+			h.exit(c, kind+":"+name)
 		}
+		h.stats.Observe(h.total())
 	}
+	c.class = classBot
+	c.botSignals = append(c.botSignals, signal)
+	slog.Debug("[visitor] Reclassified as bot", "agent", c.agent, "signals", c.botSignals)
+	h.events.Publish(service.EventVisitorBot, h.visitorEvent(c))
+	return counted
 }
 
-func (h *visitorHub) getCount() int {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
-	return len(h.clients)
+// handle applies a client message and reports whether presence changed.
+func (h *visitorHub) handle(m clientMessage) bool {
+	c := m.client
+	if m.err != nil {
+		slog.Debug("[visitor] Rejected message", "code", m.err.Code, "error", m.err.Message)
+		h.queue(c, wsproto.Encode(wsproto.TypeError, "", m.err))
+		return false
+	}
+
+	switch p := m.payload.(type) {
+	case *wsproto.Hello:
+		changed := false
+		if p.Automated {
+			changed = h.markBot(c, botdetect.SignalHeadless)
+		}
+		c.session = c.id
+		c.caps = map[string]bool{}
+		shared := wsproto.Negotiate(p.Capabilities)
+		for _, name := range shared {
+			c.caps[name] = true
+		}
+		slog.Debug("[visitor] Hello", "client", p.Client, "session", c.session, "capabilities", shared)
+		h.queue(c, wsproto.Encode(wsproto.TypeWelcome, m.env.ID, wsproto.Welcome{
+			Session:      c.session,
+			Version:      wsproto.Version,
+			Capabilities: shared,
+			PingInterval: int(pingPeriod / time.Second),
+		}))
+		return changed || c.class == classHuman
+
+	case *wsproto.View:
+		if c.session == "" {
+			h.queue(c, wsproto.Encode(wsproto.TypeError, "", &wsproto.Error{
+				Code: wsproto.ErrNoHello, Message: "send hello before " + m.env.Type, Ref: m.env.ID,
+			}))
+			return false
+		}
+		changed := false
+		for kind, v := range map[string]*string{roomPage: p.Page, roomSection: p.Section, roomItem: p.Item} {
+			if v == nil {
+				continue
+			}
+			var name string
+			switch kind {
+			case roomPage:
+				name = normalizePage(*v)
+			case roomSection:
+				name = normalizeSection(*v)
+			case roomItem:
+				name = normalizeItem(*v)
+			}
+			if c.rooms[kind] != name {
+				h.leave(c, kind)
+				h.join(c, kind, name)
+				changed = true
+			}
+		}
+		if changed && c.class == classHuman {
+			h.events.Publish(service.EventVisitorView, h.visitorEvent(c))
+		}
+		return changed && c.class == classHuman
+
+	case *wsproto.React:
+		if c.session == "" {
+			h.queue(c, wsproto.Encode(wsproto.TypeError, "", &wsproto.Error{
+				Code: wsproto.ErrNoHello, Message: "send hello before " + m.env.Type, Ref: m.env.ID,
+			}))
+			return false
+		}
+		item := normalizeItem(p.Item)
+		if item == "" || !h.policy.Allows(p.Emoji) || !h.policy.AllowsItem(item) ||
+			h.pending[item] == nil && !h.reactions.Accepts(item) {
+			h.queue(c, wsproto.Encode(wsproto.TypeError, "", &wsproto.Error{
+				Code: wsproto.ErrBadPayload, Message: "unknown item or emoji", Ref: m.env.ID,
+			}))
+			return false
+		}
+		if c.class == classBot {
+			return false
+		}
+		n := c.allowance.take(p.Count, h.policy.PerSecond, h.policy.Burst, time.Now())
+		if n == 0 {
+			h.queue(c, wsproto.Encode(wsproto.TypeError, "", &wsproto.Error{
+				Code: wsproto.ErrRateLimited, Message: "too many reactions; slow down", Ref: m.env.ID,
+			}))
+			return false
+		}
+		if h.pending[item] == nil {
+			h.pending[item] = map[string]int{}
+		}
+		h.pending[item][p.Emoji] += n
+		return false
+	}
+	return false
+}
+
+// flushReactions records the reactions gathered since the last tick and
+// sends each item's burst to its viewers. It returns how many slow, counted
+// clients were evicted.
+func (h *visitorHub) flushReactions() int {
+	evicted := 0
+	for item, burst := range h.pending {
+		totals, err := h.reactions.Add(item, burst)
+		if err != nil {
+			slog.Error("[visitor] Failed to record reactions", "item", item, "error", err)
+			continue
+		}
+		msg := wsproto.Encode(wsproto.TypeReactions, "", wsproto.Reactions{Item: item, Burst: burst, Totals: totals})
+		for c := range h.rooms[roomItem+":"+item] {
+			if c.caps[wsproto.CapReactions] && h.evict(c, msg) {
+				evicted++
+			}
+		}
+	}
+	clear(h.pending)
+	return h.evicted(evicted)
+}
+
+func newSessionID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (h *visitorHub) join(c *visitorClient, kind, name string) {
+	if name == "" {
+		return
+	}
+	c.rooms[kind] = name
+	if c.class == classHuman {
+		h.enter(c, kind+":"+name)
+	}
+}
+
+func (h *visitorHub) leave(c *visitorClient, kind string) {
+	name, ok := c.rooms[kind]
+	if !ok {
+		return
+	}
+	if c.class == classHuman {
+		h.exit(c, kind+":"+name)
+	}
+	delete(c.rooms, kind)
+}
+
+// enter adds c to the members of room key.
+func (h *visitorHub) enter(c *visitorClient, key string) {
+	if h.rooms[key] == nil {
+		h.rooms[key] = map[*visitorClient]bool{}
+	}
+	h.rooms[key][c] = true
+}
+
+// exit removes c from the members of room key.
+func (h *visitorHub) exit(c *visitorClient, key string) {
+	delete(h.rooms[key], c)
+	if len(h.rooms[key]) == 0 {
+		delete(h.rooms, key)
+	}
+}
+
+// kindCounts returns the cluster-wide member count of every room of kind,
+// keyed by room name.
+func (h *visitorHub) kindCounts(kind string) map[string]int {
+	counts := map[string]int{}
+	for key, members := range h.rooms {
+		if name, ok := strings.CutPrefix(key, kind+":"); ok {
+			counts[name] = len(members)
+		}
+	}
+	for key, n := range h.remote.Rooms {
+		if name, ok := strings.CutPrefix(key, kind+":"); ok {
+			counts[name] += n
+		}
+	}
+	return counts
+}
+
+// presence builds c's view of the current counts: the bare count for
+// legacy clients, room and section counts for clients that negotiated the
+// presence capability and viewers by country for those with geo.
+func (h *visitorHub) presence(c *visitorClient, sections, countries map[string]int) []byte {
+	if c.session == "" {
+		data, _ := json.Marshal(map[string]int{"count": h.total()})
+		return data
+	}
+	msg := wsproto.Presence{Count: h.total()}
+	if c.caps[wsproto.CapPresence] {
+		msg.Sections = sections
+		if len(c.rooms) > 0 {
+			msg.Rooms = make(map[string]int, len(c.rooms))
+			for kind, name := range c.rooms {
+				key := kind + ":" + name
+				msg.Rooms[key] = h.roomCount(key)
+			}
+		}
+	}
+	if c.caps[wsproto.CapGeo] {
+		msg.Countries = countries
+	}
+	return wsproto.Encode(wsproto.TypePresence, "", msg)
+}
+
+// broadcast queues each client's presence without blocking and returns how
+// many slow, counted clients were evicted.
+func (h *visitorHub) broadcast() int {
+	sections, countries := h.kindCounts(roomSection), h.kindCounts(roomCountry)
+	evicted := 0
+	for c := range h.clients {
+		if h.evict(c, h.presence(c, sections, countries)) {
+			evicted++
+		}
+	}
+	return h.evicted(evicted)
+}
+
+// announceAll queues a for every client with its capability and returns
+// how many slow, counted clients were evicted.
+func (h *visitorHub) announceAll(a announcement) int {
+	evicted := 0
+	for c := range h.clients {
+		if c.caps[a.capability] && h.evict(c, a.msg) {
+			evicted++
+		}
+	}
+	return h.evicted(evicted)
+}
+
+// evict queues msg for c and reports whether that evicted a counted
+// client. Bots and unverified clients are dropped too but, as when they
+// disconnect, never change the count.
+func (h *visitorHub) evict(c *visitorClient, msg []byte) bool {
+	counted := c.class == classHuman
+	return !h.queue(c, msg) && counted
+}
+
+// evicted records n evictions of counted clients and returns n.
+func (h *visitorHub) evicted(n int) int {
+	if n > 0 {
+		total := h.total()
+		slog.Warn("[visitor] Evicted slow clients", "evicted", n, "local", h.humans, "total", total)
+		h.stats.Disconnected(total)
+	}
+	return n
+}
+
+// visitorEvent describes c for the admin feed.
+func (h *visitorHub) visitorEvent(c *visitorClient) model.VisitorEvent {
+	ev := model.VisitorEvent{
+		ID:       c.id,
+		Page:     c.rooms[roomPage],
+		Section:  c.rooms[roomSection],
+		Device:   service.DeviceClass(c.userAgent),
+		Visitors: h.total(),
+	}
+	if c.class == classBot {
+		ev.BotSignals = c.botSignals
+	}
+	if c.loc.Country != "" {
+		loc := c.loc
+		ev.Geo = &loc
+	}
+	return ev
+}
+
+// queue hands msg to c's writer, evicting c if its queue is full.
+func (h *visitorHub) queue(c *visitorClient, msg []byte) bool {
+	select {
+	case c.send <- msg:
+		return true
+	default:
+		h.remove(c)
+		return false
+	}
+}
+
+// remove drops c and closes its queue, which stops its writer. It reports
+// whether c was still registered.
+func (h *visitorHub) remove(c *visitorClient) bool {
+	if _, ok := h.clients[c]; !ok {
+		return false
+	}
+	delete(h.clients, c)
+	close(c.send)
+	duration := time.Since(c.connectedAt)
+	switch c.class {
+	case classUnverified:
+		// Gone before interacting or reaching the grace period.
+		delete(h.unverified, c)
+		c.class = classBot
+		c.botSignals = append(c.botSignals, botdetect.SignalShortSession)
+		h.events.Publish(service.EventVisitorBot, h.visitorEvent(c))
+		fallthrough
+	case classBot:
+		h.analytics.RecordBot(c.agent, c.botSignals)
+		return true
+	}
+
+	h.humans--
+	ev := h.visitorEvent(c)
+	ev.Duration = duration.Seconds()
+	ev.Visitors = h.total()
+	for kind := range c.rooms {
+		h.leave(c, kind)
+	}
+	h.analytics.RecordSession(c.ip, c.userAgent, c.loc, duration)
+	h.events.Publish(service.EventVisitorDisconnect, ev)
+	return true
 }
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"portfolio-backend/internal/httputil"
+	"portfolio-backend/internal/model"
+	"portfolio-backend/internal/service"
+)
+
+// History serves the concurrent visitor series with its peaks.
+//
+//	GET /api/visitors/history?range=24h|7d|30d
+//
+// range defaults to 24h. Points are 5 minutes, 30 minutes or 2 hours wide
+// respectively, with the average and highest per-minute sample of each.
+func (h *VisitorHandler) History(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendJSON(w, http.StatusMethodNotAllowed, model.APIResponse{
+			Success: false, Message: "Method not allowed",
+		})
+		return
+	}
+
+	rng := r.URL.Query().Get("range")
+	if rng == "" {
+		rng = "24h"
+	}
+	hist, err := h.history.Report(rng, time.Now())
+	if errors.Is(err, service.ErrUnknownRange) {
+		httputil.SendJSON(w, http.StatusBadRequest, model.APIResponse{
+			Success: false, Message: "Invalid range; use 24h, 7d or 30d",
+		})
+		return
+	}
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{Success: true, Data: hist})
+}
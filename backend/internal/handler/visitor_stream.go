@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/httputil"
+	"portfolio-backend/internal/model"
+	"portfolio-backend/internal/wsproto"
+)
+
+const (
+	// sseKeepalive is how often an idle stream gets a comment, so proxies
+	// do not time it out.
+	sseKeepalive = 15 * time.Second
+	// sseRetry is the reconnect delay suggested to EventSource clients.
+	sseRetry = 5 * time.Second
+)
+
+// Count serves the current visitor count and the peak of the last 24 hours.
+//
+//	GET /api/visitors
+func (h *VisitorHandler) Count(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendJSON(w, http.StatusMethodNotAllowed, model.APIResponse{
+			Success: false, Message: "Method not allowed",
+		})
+		return
+	}
+
+	sum := h.stats.Summary(time.Now().Add(-24 * time.Hour))
+	httputil.SendJSON(w, http.StatusOK, model.APIResponse{
+		Success: true,
+		Data:    model.VisitorCount{Count: sum.Current, Peak24h: sum.Peak, PeakAt: sum.PeakAt},
+	})
+}
+
+// Stream serves the visitor WebSocket's server messages as Server-Sent
+// Events, for networks that block WebSockets.
+//
+//	GET /api/visitors/stream?capabilities=presence,geo&page=/blog
+//
+// A listener counts as a visitor on page and receives the same envelopes
+// as a WebSocket client that said hello with capabilities, one per data
+// line. Event IDs are "<session>.<seq>"; a client reconnecting with
+// Last-Event-ID (or ?lastEventId=) keeps its session. Comments are sent
+// while idle to keep the stream open.
+//
+// Like Handle, Stream must be wrapped in the limiter's CheckOrigin, ahead
+// of CORS, so refused origins are counted with the connection metrics.
+func (h *VisitorHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httputil.SendJSON(w, http.StatusMethodNotAllowed, model.APIResponse{
+			Success: false, Message: "Method not allowed",
+		})
+		return
+	}
+	ip, loc := h.locator.Locate(r)
+	release, reason := h.limiter.Acquire(ip)
+	if release == nil {
+		h.limiter.Refuse(w, r, reason)
+		return
+	}
+	defer release()
+
+	q := r.URL.Query()
+	c := &visitorClient{
+		id:          newSessionID(),
+		hub:         h.hub,
+		send:        make(chan []byte, sendBuffer),
+		ip:          ip,
+		userAgent:   r.UserAgent(),
+		agent:       h.bots.Agent(r.UserAgent()),
+		loc:         loc,
+		connectedAt: time.Now(),
+		class:       classUnverified,
+		botSignals:  h.bots.Request(r, false),
+		caps:        map[string]bool{},
+		rooms:       map[string]string{},
+	}
+	if len(c.botSignals) > 0 {
+		c.class = classBot
+	}
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = q.Get("lastEventId")
+	}
+	if session, ok := parseStreamID(lastID); ok {
+		c.id = session
+	}
+	c.session = c.id
+	for _, name := range wsproto.Negotiate(splitList(q.Get("capabilities"))) {
+		c.caps[name] = true
+	}
+	if page := normalizePage(q.Get("page")); page != "" {
+		c.rooms[roomPage] = page
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+	if err := rc.Flush(); err != nil {
+		slog.Error("[visitor] Event stream not supported", "error", err)
+		return
+	}
+
+	slog.Debug("[visitor] New event stream", "remoteAddr", r.RemoteAddr, "session", c.id)
+	if !h.hub.add(c) {
+		return
+	}
+	defer h.hub.drop(c)
+
+	keepalive := time.NewTicker(sseKeepalive)
+	defer keepalive.Stop()
+	var seq uint64
+	for {
+		var event string
+		select {
+		case msg, ok := <-c.send:
+			if !ok {
+				return
+			}
+			seq++
+			event = fmt.Sprintf("id: %s.%d\ndata: %s\n\n", c.id, seq, msg)
+		case <-keepalive.C:
+			event = ": keepalive\n\n"
+		case <-r.Context().Done():
+			return
+		}
+		rc.SetWriteDeadline(time.Now().Add(writeWait))
+		if _, err := fmt.Fprint(w, event); err != nil {
+			slog.Debug("[visitor] Stream write failed", "error", err)
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			slog.Debug("[visitor] Stream write failed", "error", err)
+			return
+		}
+	}
+}
+
+// parseStreamID returns the session of a stream event ID.
+func parseStreamID(id string) (string, bool) {
+	session, seq, ok := strings.Cut(id, ".")
+	if !ok || len(session) != 16 {
+		return "", false
+	}
+	if _, err := hex.DecodeString(session); err != nil {
+		return "", false
+	}
+	if _, err := strconv.ParseUint(seq, 10, 64); err != nil {
+		return "", false
+	}
+	return session, true
+}
@@ -0,0 +1,441 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"portfolio-backend/internal/botdetect"
+	"portfolio-backend/internal/middleware"
+	"portfolio-backend/internal/service"
+	"portfolio-backend/internal/wsproto"
+)
+
+// discardEvents is an EventPublisher that drops everything.
+type discardEvents struct{}
+
+func (discardEvents) Publish(string, any) {}
+
+// testVisitorOptions returns options backed by temporary stores. Clients
+// are counted once botGrace has passed.
+func testVisitorOptions(t *testing.T, botGrace time.Duration) VisitorOptions {
+	t.Helper()
+	dir := t.TempDir()
+	history, err := service.NewVisitorHistory(filepath.Join(dir, "history.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	analytics, err := service.NewAnalyticsService(filepath.Join(dir, "analytics.json"), time.Hour, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reactions, err := service.NewFileReactionStore(filepath.Join(dir, "reactions.json"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bots, err := botdetect.New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return VisitorOptions{
+		Stats:          service.NewVisitorStats(time.Hour),
+		History:        history,
+		Analytics:      analytics,
+		Backplane:      service.NewMemoryBus().Backplane("test"),
+		Heartbeat:      time.Second,
+		Locator:        NewClientLocator(nil, false),
+		Events:         discardEvents{},
+		Reactions:      reactions,
+		ReactionPolicy: service.ReactionPolicy{Emojis: []string{"👏"}, PerSecond: 1, Burst: 1},
+		Bots:           bots,
+		BotGrace:       botGrace,
+		Origins:        middleware.NewOriginPolicy(nil),
+		Limiter:        NewConnectionLimiter(ConnectionLimits{}),
+	}
+}
+
+// startHub runs h until the returned stop function is called, which waits
+// for the hub to finish shutting down.
+func startHub(t *testing.T, h *visitorHub) (stop func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.run(ctx)
+	}()
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			cancel()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("hub did not stop")
+			}
+		})
+	}
+	t.Cleanup(stop)
+	return stop
+}
+
+// fakeClient is a connectionless visitor, like an event stream. Unless
+// stalled, it drains its queue and keeps what it receives.
+type fakeClient struct {
+	c *visitorClient
+
+	mu     sync.Mutex
+	msgs   [][]byte
+	closed chan struct{}
+}
+
+func connectFake(t *testing.T, h *visitorHub, class string, caps ...string) *fakeClient {
+	t.Helper()
+	f := newFake(h, class, caps...)
+	if !h.add(f.c) {
+		t.Fatal("hub refused client")
+	}
+	go f.drain()
+	return f
+}
+
+func newFake(h *visitorHub, class string, caps ...string) *fakeClient {
+	c := &visitorClient{
+		id:          newSessionID(),
+		hub:         h,
+		send:        make(chan []byte, sendBuffer),
+		connectedAt: time.Now(),
+		class:       class,
+		caps:        map[string]bool{},
+		rooms:       map[string]string{},
+	}
+	for _, name := range caps {
+		c.caps[name] = true
+	}
+	return &fakeClient{c: c, closed: make(chan struct{})}
+}
+
+func (f *fakeClient) drain() {
+	defer close(f.closed)
+	for msg := range f.c.send {
+		f.mu.Lock()
+		f.msgs = append(f.msgs, msg)
+		f.mu.Unlock()
+	}
+}
+
+func (f *fakeClient) messages() [][]byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][]byte(nil), f.msgs...)
+}
+
+// lastCount is the count in the latest legacy presence message, or -1.
+func (f *fakeClient) lastCount() int {
+	msgs := f.messages()
+	for i := len(msgs) - 1; i >= 0; i-- {
+		var p struct {
+			Count *int `json:"count"`
+		}
+		if json.Unmarshal(msgs[i], &p) == nil && p.Count != nil {
+			return *p.Count
+		}
+	}
+	return -1
+}
+
+func (f *fakeClient) waitClosed(t *testing.T) {
+	t.Helper()
+	select {
+	case <-f.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("client queue was not closed")
+	}
+}
+
+// sendMessage hands raw to the hub as if f's connection had read it.
+func sendMessage(h *visitorHub, f *fakeClient, raw string) {
+	env, payload, perr := wsproto.Decode([]byte(raw))
+	h.inbound <- clientMessage{client: f.c, env: env, payload: payload, err: perr}
+}
+
+// refused reports whether f was sent an error answering message ref.
+func (f *fakeClient) refused(ref string) bool {
+	for _, msg := range f.messages() {
+		var env struct {
+			Type    string        `json:"type"`
+			Payload wsproto.Error `json:"payload"`
+		}
+		if json.Unmarshal(msg, &env) == nil && env.Type == wsproto.TypeError && env.Payload.Ref == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// eventually polls cond until it holds or the deadline passes.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestVisitorHubConcurrentClients(t *testing.T) {
+	h := newVisitorHub(testVisitorOptions(t, 0))
+	startHub(t, h)
+	// A bot is never counted but still sees every broadcast.
+	observer := connectFake(t, h, classBot)
+
+	const n = 100
+	clients := make([]*fakeClient, n)
+	var wg sync.WaitGroup
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			clients[i] = connectFake(t, h, classUnverified)
+		}(i)
+	}
+	wg.Wait()
+	eventually(t, fmt.Sprintf("count %d", n), func() bool { return observer.lastCount() == n })
+
+	for _, f := range clients {
+		wg.Add(1)
+		go func(f *fakeClient) {
+			defer wg.Done()
+			h.drop(f.c)
+		}(f)
+	}
+	wg.Wait()
+	eventually(t, "count 0", func() bool { return observer.lastCount() == 0 })
+	for _, f := range clients {
+		f.waitClosed(t)
+	}
+}
+
+func TestVisitorHubEvictsSlowConsumer(t *testing.T) {
+	h := newVisitorHub(testVisitorOptions(t, time.Hour))
+	startHub(t, h)
+
+	healthy := connectFake(t, h, classUnverified, "news")
+	slow := newFake(h, classUnverified, "news")
+	if !h.add(slow.c) {
+		t.Fatal("hub refused client")
+	}
+
+	announcements := func() int {
+		got := 0
+		for _, msg := range healthy.messages() {
+			if strings.HasPrefix(string(msg), `{"n":`) {
+				got++
+			}
+		}
+		return got
+	}
+	// The slow client is never drained: its welcome count and the
+	// announcements fill its queue and the next one evicts it. The healthy
+	// client keeps up, so it gets them all.
+	for i := 0; i < sendBuffer+1; i++ {
+		h.announce <- announcement{capability: "news", msg: []byte(fmt.Sprintf(`{"n":%d}`, i))}
+		eventually(t, fmt.Sprintf("announcement %d", i), func() bool { return announcements() == i+1 })
+	}
+	go slow.drain()
+	slow.waitClosed(t)
+	if got := len(slow.messages()); got != sendBuffer {
+		t.Errorf("slow client got %d messages before eviction, want %d", got, sendBuffer)
+	}
+
+	select {
+	case <-healthy.closed:
+		t.Error("healthy client was evicted")
+	default:
+	}
+}
+
+func TestVisitorHubCountsOnlyEvictedHumans(t *testing.T) {
+	opts := testVisitorOptions(t, time.Hour)
+	h := newVisitorHub(opts)
+
+	// Drive the hub directly, as its run loop would, with every client
+	// stalled on a full queue.
+	var clients []*fakeClient
+	for _, class := range []string{classHuman, classBot, classUnverified} {
+		f := newFake(h, class, "news")
+		h.clients[f.c] = true
+		switch class {
+		case classHuman:
+			h.promote(f.c)
+		case classUnverified:
+			h.unverified[f.c] = true
+		}
+		for i := 0; i < sendBuffer; i++ {
+			f.c.send <- []byte("{}")
+		}
+		clients = append(clients, f)
+	}
+
+	if n := h.announceAll(announcement{capability: "news", msg: []byte(`{"n":1}`)}); n != 1 {
+		t.Errorf("announceAll reported %d evictions, want only the human", n)
+	}
+	if len(h.clients) != 0 || len(h.unverified) != 0 || h.humans != 0 {
+		t.Errorf("after evicting: %d clients, %d unverified, %d humans; want none", len(h.clients), len(h.unverified), h.humans)
+	}
+	if sum := opts.Stats.Summary(time.Now().Add(-time.Hour)); sum.Sessions != 1 || sum.Current != 0 {
+		t.Errorf("stats = %d sessions, %d current; want 1 and 0", sum.Sessions, sum.Current)
+	}
+	for _, f := range clients {
+		go f.drain()
+		f.waitClosed(t)
+	}
+}
+
+func TestVisitorHubBroadcastFanOut(t *testing.T) {
+	h := newVisitorHub(testVisitorOptions(t, 0))
+	stop := startHub(t, h)
+
+	const n = 20
+	clients := make([]*fakeClient, n)
+	for i := range clients {
+		var caps []string
+		if i%2 == 0 {
+			caps = []string{"news"}
+		}
+		clients[i] = connectFake(t, h, classUnverified, caps...)
+	}
+	for i, f := range clients {
+		eventually(t, fmt.Sprintf("client %d to see count %d", i, n), func() bool { return f.lastCount() == n })
+	}
+
+	h.announce <- announcement{capability: "news", msg: []byte(`{"news":true}`)}
+	stop()
+	for i, f := range clients {
+		f.waitClosed(t)
+		got := 0
+		for _, msg := range f.messages() {
+			if string(msg) == `{"news":true}` {
+				got++
+			}
+		}
+		if want := 1 - i%2; got != want {
+			t.Errorf("client %d got the announcement %d times, want %d", i, got, want)
+		}
+	}
+}
+
+func TestVisitorHubShutdownLeaksNoGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	opts := testVisitorOptions(t, 0)
+	vh := NewVisitorHandler(opts)
+	ctx, cancel := context.WithCancel(context.Background())
+	hubDone := make(chan struct{})
+	go func() {
+		defer close(hubDone)
+		vh.RunHub(ctx)
+	}()
+	srv := httptest.NewServer(opts.Limiter.CheckOrigin(opts.Origins, vh.Handle))
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+	var conns []*websocket.Conn
+	for i := 0; i < 10; i++ {
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		conns = append(conns, conn)
+	}
+	streams := []*fakeClient{connectFake(t, vh.hub, classUnverified), connectFake(t, vh.hub, classUnverified)}
+	eventually(t, "connections to open", func() bool { return opts.Limiter.Metrics().Open == len(conns) })
+
+	cancel()
+	select {
+	case <-hubDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("hub did not stop")
+	}
+	for _, conn := range conns {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+					t.Errorf("read after shutdown: %v, want going-away close", err)
+				}
+				break
+			}
+		}
+		conn.Close()
+	}
+	for _, f := range streams {
+		f.waitClosed(t)
+	}
+	if vh.hub.add(newFake(vh.hub, classUnverified).c) {
+		t.Error("hub accepted a client after shutdown")
+	}
+	srv.Close()
+
+	eventually(t, "connection slots to be released", func() bool { return opts.Limiter.Metrics().Open == 0 })
+	eventually(t, "goroutines to exit", func() bool { return runtime.NumGoroutine() <= before })
+}
+
+func TestVisitorHubLimitsReactionItems(t *testing.T) {
+	react := func(id, item string) string {
+		return fmt.Sprintf(`{"type":"react","v":1,"id":%q,"payload":{"item":%q,"emoji":"👏"}}`, id, item)
+	}
+	for _, tc := range []struct {
+		name     string
+		items    []string
+		maxItems int
+		accepted string
+		refused  string
+	}{
+		{"configured items", []string{"project:known"}, 0, "project:known", "project:unknown"},
+		{"item cap", nil, 1, "project:known", "project:new"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := testVisitorOptions(t, 0)
+			opts.ReactionPolicy.Items = tc.items
+			opts.ReactionPolicy.Burst = 10
+			store, err := service.NewFileReactionStore(filepath.Join(t.TempDir(), "reactions.json"), tc.maxItems)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Add("project:known", map[string]int{"👏": 1}); err != nil {
+				t.Fatal(err)
+			}
+			opts.Reactions = store
+			h := newVisitorHub(opts)
+			stop := startHub(t, h)
+			f := connectFake(t, h, classUnverified)
+
+			sendMessage(h, f, `{"type":"hello","v":1,"id":"h","payload":{"capabilities":["reactions"]}}`)
+			sendMessage(h, f, react("bad", tc.refused))
+			sendMessage(h, f, react("good", tc.accepted))
+			eventually(t, "the refusal", func() bool { return f.refused("bad") })
+			stop()
+
+			if f.refused("good") {
+				t.Errorf("reaction to %s was refused", tc.accepted)
+			}
+			if got := store.Totals(tc.accepted)["👏"]; got != 2 {
+				t.Errorf("%s has %d reactions, want 2", tc.accepted, got)
+			}
+			if all := store.All(); len(all) != 1 {
+				t.Errorf("store holds %d items, want 1: %v", len(all), all)
+			}
+		})
+	}
+}
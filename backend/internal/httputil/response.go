@@ -2,6 +2,8 @@ package httputil
 
 import (
 	"encoding/json"
+	"fmt"
+	"html"
 	"net/http"
 )
 
@@ -11,3 +13,13 @@ func SendJSON(w http.ResponseWriter, status int, data any) {
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(data)
 }
+
+// SendPage writes a minimal standalone HTML page, for links opened from
+// emails. body is trusted HTML; title is escaped.
+func SendPage(w http.ResponseWriter, status int, title, body string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<!doctype html><html><head><meta charset="utf-8"><meta name="viewport" content="width=device-width">`+
+		`<title>%[1]s</title></head><body style="font-family:sans-serif;max-width:32rem;margin:4rem auto">`+
+		`<h1>%[1]s</h1>%[2]s</body></html>`, html.EscapeString(title), body)
+}
@@ -0,0 +1,109 @@
+// Package ical writes minimal RFC 5545 calendars carrying a single event,
+// suitable for iTIP (RFC 5546) REQUEST and CANCEL invitations by email.
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// iTIP methods.
+const (
+	MethodRequest = "REQUEST"
+	MethodCancel  = "CANCEL"
+)
+
+// Person is an organizer or attendee.
+type Person struct {
+	Name  string
+	Email string
+}
+
+// Event is a single meeting. Times are written in UTC.
+type Event struct {
+	UID         string
+	Sequence    int
+	Start       time.Time
+	End         time.Time
+	Stamp       time.Time
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Organizer   Person
+	Attendees   []Person
+}
+
+const timeFormat = "20060102T150405Z"
+
+// Encode renders ev as a VCALENDAR for method. A CANCEL marks the event cancelled.
+func Encode(method string, ev Event) []byte {
+	var b bytes.Buffer
+	line := func(name, value string) { writeLine(&b, name+":"+value) }
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//portfolio-backend//booking//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", method)
+	line("BEGIN", "VEVENT")
+	line("UID", ev.UID)
+	line("SEQUENCE", fmt.Sprint(ev.Sequence))
+	line("DTSTAMP", ev.Stamp.UTC().Format(timeFormat))
+	line("DTSTART", ev.Start.UTC().Format(timeFormat))
+	line("DTEND", ev.End.UTC().Format(timeFormat))
+	line("SUMMARY", escapeText(ev.Summary))
+	if ev.Description != "" {
+		line("DESCRIPTION", escapeText(ev.Description))
+	}
+	if ev.Location != "" {
+		line("LOCATION", escapeText(ev.Location))
+	}
+	if ev.URL != "" {
+		line("URL", ev.URL)
+	}
+	writeLine(&b, "ORGANIZER"+cnParam(ev.Organizer.Name)+":mailto:"+ev.Organizer.Email)
+	for _, a := range ev.Attendees {
+		writeLine(&b, "ATTENDEE"+cnParam(a.Name)+";ROLE=REQ-PARTICIPANT;PARTSTAT=ACCEPTED:mailto:"+a.Email)
+	}
+	if method == MethodCancel {
+		line("STATUS", "CANCELLED")
+	} else {
+		line("STATUS", "CONFIRMED")
+	}
+	line("END", "VEVENT")
+	line("END", "VCALENDAR")
+	return b.Bytes()
+}
+
+func cnParam(name string) string {
+	if name == "" {
+		return ""
+	}
+	// Parameter values cannot contain DQUOTE; quoting covers ':', ';' and ','.
+	return `;CN="` + strings.ReplaceAll(name, `"`, "'") + `"`
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+func escapeText(s string) string { return textEscaper.Replace(s) }
+
+// writeLine folds content lines longer than 75 octets (RFC 5545 §3.1)
+// without splitting UTF-8 sequences, and terminates them with CRLF.
+func writeLine(b *bytes.Buffer, s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		limit = 74 // continuation lines start with a space
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+}
@@ -3,6 +3,7 @@ package logger
 import (
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/gookit/slog"
 	"github.com/gookit/slog/handler"
@@ -17,6 +18,8 @@ func Init() {
 		l.ReportCaller = true
 		l.CallerSkip = 6
 		l.Level = slog.TraceLevel
+		// Fatal only exits with an exit func; logs are flushed before it runs.
+		l.ExitFunc = os.Exit
 
 		f := l.Formatter.(*slog.TextFormatter)
 		f.SetTemplate("{{datetime}} [{{level}}] [{{caller}}] {{message}} {{data}} {{extra}}\n")
@@ -33,7 +36,32 @@ func Init() {
 			handler.WithBackupNum(7),
 		)
 		if err == nil {
-			slog.PushHandler(fileH)
+			slog.PushHandler(&lockedHandler{SyncCloseHandler: fileH})
 		}
 	}
 }
+
+// fileMu serializes writes to logs/app.log; see FileLock.
+var fileMu sync.Mutex
+
+// FileLock pauses writes to the log file while held, so the file can be
+// rewritten in place (e.g. to erase personal data) without losing lines
+// logged meanwhile. Nothing may be logged while holding it.
+func FileLock() sync.Locker { return &fileMu }
+
+// lockedHandler writes through the file handler under fileMu.
+type lockedHandler struct {
+	*handler.SyncCloseHandler
+}
+
+func (h *lockedHandler) Handle(r *slog.Record) error {
+	fileMu.Lock()
+	defer fileMu.Unlock()
+	return h.SyncCloseHandler.Handle(r)
+}
+
+func (h *lockedHandler) Flush() error {
+	fileMu.Lock()
+	defer fileMu.Unlock()
+	return h.SyncCloseHandler.Flush()
+}
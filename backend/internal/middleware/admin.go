@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/httputil"
+	"portfolio-backend/internal/model"
+)
+
+// AdminAuth requires a matching "Authorization: Bearer <token>" header.
+// Browsers cannot set headers on WebSocket handshakes, so upgrade requests
+// may pass the token as ?access_token= instead. When token is empty the
+// admin API is disabled entirely.
+func AdminAuth(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			httputil.SendJSON(w, http.StatusServiceUnavailable, model.APIResponse{
+				Success: false, Message: "Admin API is not configured",
+			})
+			return
+		}
+
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if got == "" && strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			got = r.URL.Query().Get("access_token")
+		}
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			slog.Warn("[admin] Unauthorized request", "path", r.URL.Path, "remoteAddr", r.RemoteAddr)
+			httputil.SendJSON(w, http.StatusUnauthorized, model.APIResponse{
+				Success: false, Message: "Unauthorized",
+			})
+			return
+		}
+
+		next(w, r)
+	}
+}
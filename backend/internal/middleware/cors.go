@@ -1,13 +1,96 @@
 package middleware
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
 
-// CORS wraps an http.HandlerFunc with permissive CORS headers.
-func CORS(next http.HandlerFunc) http.HandlerFunc {
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/httputil"
+	"portfolio-backend/internal/model"
+)
+
+// OriginPolicy decides which browser origins may call the API and open
+// WebSockets. It is shared by CORS and the WebSocket upgraders so both
+// admit the same sites.
+type OriginPolicy struct {
+	any     bool
+	allowed map[string]bool
+}
+
+// NewOriginPolicy allows origins such as "https://example.com". An empty
+// list, or one containing "*", allows every origin.
+func NewOriginPolicy(origins []string) *OriginPolicy {
+	p := &OriginPolicy{any: len(origins) == 0, allowed: map[string]bool{}}
+	for _, o := range origins {
+		if o == "*" {
+			p.any = true
+		}
+		p.allowed[normalizeOrigin(o)] = true
+	}
+	return p
+}
+
+// AllowURL also allows the origin of rawURL. The server adds its own public
+// URL so that pages it serves, such as the booking cancellation and
+// unsubscribe forms, can post back to it. Call it before serving requests.
+func (p *OriginPolicy) AllowURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("%q is not an absolute URL", rawURL)
+	}
+	p.allowed[normalizeOrigin(u.Scheme+"://"+u.Host)] = true
+	return nil
+}
+
+// AllowsAny reports whether every origin is allowed.
+func (p *OriginPolicy) AllowsAny() bool { return p.any }
+
+// Allows reports whether a request with the given Origin header may be
+// served. Requests without one do not come from a cross-site page and are
+// always allowed.
+func (p *OriginPolicy) Allows(origin string) bool {
+	return origin == "" || p.any || p.allowed[normalizeOrigin(origin)]
+}
+
+// CheckOrigin is a websocket.Upgrader CheckOrigin function.
+func (p *OriginPolicy) CheckOrigin(r *http.Request) bool {
+	return p.Allows(r.Header.Get("Origin"))
+}
+
+func normalizeOrigin(o string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(o)), "/")
+}
+
+// CORS wraps an http.HandlerFunc with CORS headers for the origins policy
+// allows. Requests from other origins are refused, so simple requests such
+// as form posts are not acted on either.
+func CORS(origins *OriginPolicy, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		origin := r.Header.Get("Origin")
+		if !origins.Allows(origin) {
+			slog.Debug("[cors] Origin not allowed", "origin", origin, "path", r.URL.Path)
+			httputil.SendJSON(w, http.StatusForbidden, model.APIResponse{
+				Success: false, Message: "Origin not allowed",
+			})
+			return
+		}
+
+		if origins.AllowsAny() {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Add("Vary", "Origin")
+			if origin != "" {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+		}
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusOK)
@@ -1,11 +1,30 @@
 package model
 
+import (
+	"time"
+
+	"portfolio-backend/internal/envelope"
+)
+
 // ContactRequest represents a contact form submission.
 type ContactRequest struct {
-	Name    string `json:"name"`
-	Email   string `json:"email"`
-	Subject string `json:"subject"`
-	Message string `json:"message"`
+	Name        string       `json:"name"`
+	Email       string       `json:"email"`
+	Subject     string       `json:"subject"`
+	Message     string       `json:"message"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Attachment is a file uploaded with a contact submission. Content is only
+// held in memory while the request is processed; the stored copy lives in
+// the blob directory under its content address, which is a keyed hash when
+// encryption is enabled despite the field name.
+type Attachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+	Content     []byte `json:"-"`
 }
 
 // ChatMessage represents a single message in a chat history.
@@ -26,3 +45,547 @@ type APIResponse struct {
 	Message string `json:"message"`
 	Data    any    `json:"data,omitempty"`
 }
+
+// ContactRecord is a stored contact submission together with its reply thread.
+type ContactRecord struct {
+	ID         string          `json:"id"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	MessageID  string          `json:"messageId"`
+	Contact    ContactRequest  `json:"contact"`
+	Thread     []ThreadMessage `json:"thread"`
+	Anonymized bool            `json:"anonymized,omitempty"`
+
+	// When encryption at rest is enabled, Contact is stored zeroed and its
+	// content lives in Sealed. EmailIndex is a keyed hash of the address so
+	// lookups by sender work without decrypting.
+	Sealed     *envelope.Envelope `json:"sealed,omitempty"`
+	EmailIndex string             `json:"emailIndex,omitempty"`
+
+	Lead *LeadScore `json:"lead,omitempty"`
+	// Geo is where the submission came from, when GeoIP is available.
+	Geo *GeoLocation `json:"geo,omitempty"`
+}
+
+// Lead categories.
+const (
+	LeadJobOffer      = "job_offer"
+	LeadFreelance     = "freelance"
+	LeadCollaboration = "collaboration"
+	LeadSpam          = "spam"
+	LeadOther         = "other"
+)
+
+// Lead priorities.
+const (
+	PriorityHigh   = "high"
+	PriorityMedium = "medium"
+	PriorityLow    = "low"
+)
+
+// LeadScore is the classification of a contact submission.
+type LeadScore struct {
+	Category string   `json:"category"`
+	Priority string   `json:"priority"`
+	Score    int      `json:"score"`
+	Reasons  []string `json:"reasons,omitempty"`
+	Source   string   `json:"source"`
+	// Notified is set once at least one notification target received the
+	// lead; the rest are left for the digest.
+	Notified bool `json:"notified,omitempty"`
+}
+
+// Message directions within a contact thread.
+const (
+	DirectionOutbound = "outbound"
+	DirectionInbound  = "inbound"
+)
+
+// ThreadMessage is a single email exchanged with a contact after submission.
+type ThreadMessage struct {
+	MessageID  string    `json:"messageId"`
+	Direction  string    `json:"direction"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Subject    string    `json:"subject"`
+	Body       string    `json:"body"`
+	InReplyTo  string    `json:"inReplyTo,omitempty"`
+	References []string  `json:"references,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+
+	// Sealed holds From, To, Subject and Body when encryption is enabled.
+	Sealed *envelope.Envelope `json:"sealed,omitempty"`
+}
+
+// ReplyRequest is the admin payload for answering a contact.
+type ReplyRequest struct {
+	Subject string `json:"subject"`
+	Message string `json:"message"`
+}
+
+// Email is a generic outbound email handed to an EmailService.
+type Email struct {
+	To      []string          `json:"to"`
+	ReplyTo string            `json:"replyTo,omitempty"`
+	Subject string            `json:"subject"`
+	HTML    string            `json:"html,omitempty"`
+	Text    string            `json:"text,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// InboundEmail is a parsed email received through the inbound webhook.
+type InboundEmail struct {
+	MessageID  string   `json:"messageId"`
+	From       string   `json:"from"`
+	To         string   `json:"to"`
+	Subject    string   `json:"subject"`
+	Text       string   `json:"text"`
+	InReplyTo  string   `json:"inReplyTo"`
+	References []string `json:"references"`
+}
+
+// Lead event types sent to notification webhooks.
+const (
+	LeadContact  = "contact"
+	LeadChatHire = "chat_hire"
+)
+
+// LeadEvent describes a new lead for outgoing notifications.
+type LeadEvent struct {
+	Type      string    `json:"type"`
+	ContactID string    `json:"contactId,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	Email     string    `json:"email,omitempty"`
+	Subject   string    `json:"subject,omitempty"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	Category string `json:"category,omitempty"`
+	Priority string `json:"priority,omitempty"`
+	Score    int    `json:"score,omitempty"`
+}
+
+// ChatExchange is one visitor question and the assistant's answer.
+type ChatExchange struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Message   string    `json:"message"`
+	Response  string    `json:"response"`
+
+	// Sealed holds Message and Response when encryption is enabled.
+	Sealed *envelope.Envelope `json:"sealed,omitempty"`
+}
+
+// Outbox delivery statuses.
+const (
+	OutboxSent   = "sent"
+	OutboxFailed = "failed"
+)
+
+// OutboxEntry records a single outbound email delivery attempt.
+type OutboxEntry struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	To        []string  `json:"to"`
+	ReplyTo   string    `json:"replyTo,omitempty"`
+	Subject   string    `json:"subject"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// AuditEntry records a privacy-relevant administrative action. Subjects are
+// stored as hashes so the audit trail does not itself retain personal data.
+type AuditEntry struct {
+	Time        time.Time      `json:"time"`
+	Action      string         `json:"action"`
+	SubjectHash string         `json:"subjectHash,omitempty"`
+	Actor       string         `json:"actor,omitempty"`
+	Details     map[string]int `json:"details,omitempty"`
+}
+
+// DataExport is everything held about one email address.
+type DataExport struct {
+	Email       string           `json:"email"`
+	GeneratedAt time.Time        `json:"generatedAt"`
+	Contacts    []ContactRecord  `json:"contacts"`
+	Chat        []ChatExchange   `json:"chat"`
+	Outbox      []OutboxEntry    `json:"outbox"`
+	Bookings    []Booking        `json:"bookings"`
+	Subscribers []Subscriber     `json:"subscribers"`
+	Guestbook   []GuestbookEntry `json:"guestbook"`
+	LogLines    []string         `json:"logLines"`
+}
+
+// ConnectionMetrics counts long-lived visitor connections since startup.
+// Rejected is keyed by reason: origin, capacity, per-ip or rate.
+type ConnectionMetrics struct {
+	Open     int              `json:"open"`
+	Max      int              `json:"max"`
+	PerIP    int              `json:"perIp"`
+	Accepted int64            `json:"accepted"`
+	Rejected map[string]int64 `json:"rejected"`
+}
+
+// VisitorCount is the public visitor counter.
+type VisitorCount struct {
+	Count   int       `json:"count"`
+	Peak24h int       `json:"peak24h"`
+	PeakAt  time.Time `json:"peakAt,omitempty"`
+}
+
+// VisitorPeak is the highest concurrent visitor count and when it was seen.
+type VisitorPeak struct {
+	Count int       `json:"count"`
+	At    time.Time `json:"at,omitempty"`
+}
+
+// DailyVisitorPeak is the peak of one UTC day (YYYY-MM-DD).
+type DailyVisitorPeak struct {
+	Date  string    `json:"date"`
+	Count int       `json:"count"`
+	At    time.Time `json:"at"`
+}
+
+// VisitorHistoryPoint summarizes the per-minute samples of one step.
+type VisitorHistoryPoint struct {
+	At  time.Time `json:"at"`
+	Avg float64   `json:"avg"`
+	Max int       `json:"max"`
+}
+
+// VisitorHistory is the concurrent visitor series for a range. Step is the
+// width of each point in seconds; steps without samples are omitted.
+type VisitorHistory struct {
+	Range      string                `json:"range"`
+	Step       int                   `json:"stepSeconds"`
+	Points     []VisitorHistoryPoint `json:"points"`
+	Peak       VisitorPeak           `json:"peak"`
+	Today      VisitorPeak           `json:"today"`
+	DailyPeaks []DailyVisitorPeak    `json:"dailyPeaks"`
+}
+
+// VisitorSummary describes visitor activity over a time window.
+type VisitorSummary struct {
+	Current  int       `json:"current"`
+	Sessions int       `json:"sessions"`
+	Peak     int       `json:"peak"`
+	PeakAt   time.Time `json:"peakAt,omitempty"`
+}
+
+// DigestContact is a contact as listed in a digest. It carries no personal
+// data; the admin contact view has the rest.
+type DigestContact struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Category  string    `json:"category,omitempty"`
+	Priority  string    `json:"priority,omitempty"`
+}
+
+// QuestionCount is a chat question and how often it was asked.
+type QuestionCount struct {
+	Question string `json:"question"`
+	Count    int    `json:"count"`
+}
+
+// Digest summarizes site activity between From and To.
+type Digest struct {
+	Period       string          `json:"period"`
+	From         time.Time       `json:"from"`
+	To           time.Time       `json:"to"`
+	Contacts     []DigestContact `json:"contacts"`
+	ChatMessages int             `json:"chatMessages"`
+	TopQuestions []QuestionCount `json:"topQuestions"`
+	Visitors     VisitorSummary  `json:"visitors"`
+	FailedEmails []OutboxEntry   `json:"failedEmails"`
+}
+
+// Booking statuses.
+const (
+	BookingPending   = "pending"
+	BookingConfirmed = "confirmed"
+	BookingCancelled = "cancelled"
+)
+
+// BookingRequest is the payload for reserving a meeting slot.
+type BookingRequest struct {
+	Start time.Time `json:"start"`
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Note  string    `json:"note"`
+}
+
+// Booking is a reserved meeting. A new booking is pending: it holds its
+// slot until HoldUntil and is confirmed from a link emailed to the visitor.
+// The token in that link also cancels the meeting; only its hash is kept.
+type Booking struct {
+	ID              string     `json:"id"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	Start           time.Time  `json:"start"`
+	End             time.Time  `json:"end"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	Note            string     `json:"note,omitempty"`
+	Status          string     `json:"status"`
+	HoldUntil       *time.Time `json:"holdUntil,omitempty"`
+	ConfirmedAt     *time.Time `json:"confirmedAt,omitempty"`
+	CancelTokenHash string     `json:"cancelTokenHash"`
+	CancelledAt     *time.Time `json:"cancelledAt,omitempty"`
+	Sequence        int        `json:"sequence"`
+}
+
+// Slot is a bookable time range.
+type Slot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Subscriber statuses.
+const (
+	SubscriberPending      = "pending"
+	SubscriberActive       = "active"
+	SubscriberUnsubscribed = "unsubscribed"
+)
+
+// Subscriber is a newsletter subscription.
+type Subscriber struct {
+	ID             string     `json:"id"`
+	Email          string     `json:"email"`
+	Status         string     `json:"status"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	ConfirmSentAt  time.Time  `json:"confirmSentAt"`
+	ConfirmedAt    *time.Time `json:"confirmedAt,omitempty"`
+	UnsubscribedAt *time.Time `json:"unsubscribedAt,omitempty"`
+}
+
+// NewsletterIssue is an issue to mail to active subscribers. HTML and Text
+// are Go templates executed with the subscriber's Email and UnsubscribeURL.
+type NewsletterIssue struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+// IssueSend records the delivery of an issue. Recipients are the subscriber
+// IDs active when it started; Sent and Failed grow as it progresses, so a
+// send interrupted by a restart resumes with the rest.
+type IssueSend struct {
+	ID         string          `json:"id"`
+	Issue      NewsletterIssue `json:"issue"`
+	StartedAt  time.Time       `json:"startedAt"`
+	FinishedAt *time.Time      `json:"finishedAt,omitempty"`
+	Recipients []string        `json:"recipients"`
+	Sent       []string        `json:"sent"`
+	Failed     []string        `json:"failed"`
+}
+
+// PresenceSnapshot is one server instance's local visitor counts, shared
+// with the other instances over the presence backplane. Rooms is keyed by
+// "kind:name", e.g. "section:projects".
+type PresenceSnapshot struct {
+	Instance string         `json:"instance"`
+	Count    int            `json:"count"`
+	Rooms    map[string]int `json:"rooms,omitempty"`
+	// Gone is set by an instance that is shutting down.
+	Gone bool `json:"gone,omitempty"`
+}
+
+// PresenceCounts is the combined presence of other server instances.
+type PresenceCounts struct {
+	Instances int            `json:"instances"`
+	Count     int            `json:"count"`
+	Rooms     map[string]int `json:"rooms,omitempty"`
+}
+
+// Analytics event types accepted by the collect beacon.
+const (
+	AnalyticsPageView = "pageview"
+	AnalyticsDownload = "download"
+)
+
+// AnalyticsEvent is a beacon sent by the site. UTM parameters are read from
+// the query string of URL.
+type AnalyticsEvent struct {
+	Type     string `json:"type"`
+	URL      string `json:"url"`
+	Referrer string `json:"referrer,omitempty"`
+	// Target is the downloaded file for download events.
+	Target string `json:"target,omitempty"`
+}
+
+// AnalyticsBucket aggregates site activity over one UTC hour or day.
+// Uniques are counted with a salt that changes daily, so they cannot be
+// summed into distinct visitors across days.
+type AnalyticsBucket struct {
+	Start          time.Time      `json:"start"`
+	PageViews      int            `json:"pageViews"`
+	Uniques        int            `json:"uniques"`
+	Sessions       int            `json:"sessions"`
+	SessionSeconds int64          `json:"sessionSeconds"`
+	Downloads      int            `json:"downloads"`
+	Pages          map[string]int `json:"pages,omitempty"`
+	Referrers      map[string]int `json:"referrers,omitempty"`
+	UTMSources     map[string]int `json:"utmSources,omitempty"`
+	UTMMediums     map[string]int `json:"utmMediums,omitempty"`
+	UTMCampaigns   map[string]int `json:"utmCampaigns,omitempty"`
+	Devices        map[string]int `json:"devices,omitempty"`
+	Files          map[string]int `json:"files,omitempty"`
+	// Countries and Cities count unique visitors by location.
+	Countries map[string]int `json:"countries,omitempty"`
+	Cities    map[string]int `json:"cities,omitempty"`
+	// Bots counts beacons and live sessions from bots, which are left out
+	// of every other field, by matched User-Agent pattern and by signal.
+	Bots       int            `json:"bots"`
+	BotAgents  map[string]int `json:"botAgents,omitempty"`
+	BotSignals map[string]int `json:"botSignals,omitempty"`
+}
+
+// AnalyticsPoint is one bucket in an analytics time series, or the totals
+// over the whole range.
+type AnalyticsPoint struct {
+	Start      time.Time `json:"start"`
+	PageViews  int       `json:"pageViews"`
+	Uniques    int       `json:"uniques"`
+	Sessions   int       `json:"sessions"`
+	AvgSession float64   `json:"avgSessionSeconds"`
+	Downloads  int       `json:"downloads"`
+	Bots       int       `json:"bots"`
+}
+
+// KeyCount is an entry in a ranked list.
+type KeyCount struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// AnalyticsReport answers an admin analytics query.
+type AnalyticsReport struct {
+	From         time.Time        `json:"from"`
+	To           time.Time        `json:"to"`
+	Granularity  string           `json:"granularity"`
+	Totals       AnalyticsPoint   `json:"totals"`
+	Series       []AnalyticsPoint `json:"series"`
+	Pages        []KeyCount       `json:"pages"`
+	Referrers    []KeyCount       `json:"referrers"`
+	UTMSources   []KeyCount       `json:"utmSources"`
+	UTMMediums   []KeyCount       `json:"utmMediums"`
+	UTMCampaigns []KeyCount       `json:"utmCampaigns"`
+	Devices      []KeyCount       `json:"devices"`
+	Files        []KeyCount       `json:"files"`
+	Countries    []KeyCount       `json:"countries"`
+	Cities       []KeyCount       `json:"cities"`
+	BotAgents    []KeyCount       `json:"botAgents"`
+	BotSignals   []KeyCount       `json:"botSignals"`
+}
+
+// GeoLocation is where an IP address is registered, from the local GeoIP
+// database. Country is the ISO 3166-1 alpha-2 code.
+type GeoLocation struct {
+	Country     string `json:"country"`
+	CountryName string `json:"countryName,omitempty"`
+	City        string `json:"city,omitempty"`
+}
+
+// AdminEvent is an entry in the admin live feed. Seq increases by one for
+// every event, so a client can resume from the last one it saw.
+type AdminEvent struct {
+	Seq     uint64    `json:"seq"`
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	Country string    `json:"country,omitempty"`
+	Data    any       `json:"data"`
+}
+
+// AdminEventFilter selects admin feed events. Types match an event type
+// exactly or by category ("visitor" matches "visitor.connect"). Countries
+// restrict visitor and contact events, dropping those from unknown
+// locations; other events pass. Empty fields match everything.
+type AdminEventFilter struct {
+	Types     []string `json:"types,omitempty"`
+	Countries []string `json:"countries,omitempty"`
+}
+
+// VisitorEvent describes a live visitor in the admin feed.
+type VisitorEvent struct {
+	ID       string       `json:"id"`
+	Page     string       `json:"page,omitempty"`
+	Section  string       `json:"section,omitempty"`
+	Geo      *GeoLocation `json:"geo,omitempty"`
+	Device   string       `json:"device"`
+	Duration float64      `json:"durationSeconds,omitempty"`
+	Visitors int          `json:"visitors"`
+	// BotSignals says why a visitor.bot event's connection is not counted.
+	BotSignals []string `json:"botSignals,omitempty"`
+}
+
+// ContactEvent announces a new contact submission in the admin feed.
+type ContactEvent struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Email       string       `json:"email"`
+	Subject     string       `json:"subject"`
+	Attachments int          `json:"attachments"`
+	Geo         *GeoLocation `json:"geo,omitempty"`
+}
+
+// ProviderErrorEvent reports a failed call to an external provider.
+type ProviderErrorEvent struct {
+	Provider string `json:"provider"`
+	Error    string `json:"error"`
+}
+
+// ReactionTotals is an item's all-time reaction count by emoji.
+type ReactionTotals struct {
+	Item   string         `json:"item"`
+	Totals map[string]int `json:"totals"`
+}
+
+// ReactionsResponse lists the accepted emojis and one page of reaction
+// totals, ordered by item.
+type ReactionsResponse struct {
+	Emojis  []string         `json:"emojis"`
+	Items   []ReactionTotals `json:"items"`
+	Page    int              `json:"page"`
+	PerPage int              `json:"perPage"`
+	Total   int              `json:"total"`
+}
+
+// Guestbook entry statuses. New entries wait in the moderation queue as
+// pending, or as spam when they trip enough spam checks; only approved
+// entries are public.
+const (
+	GuestbookPending  = "pending"
+	GuestbookApproved = "approved"
+	GuestbookRejected = "rejected"
+	GuestbookSpam     = "spam"
+)
+
+// GuestbookRequest is a guestbook submission. Nickname is a honeypot field
+// hidden from people; bots that fill it in are silently dropped.
+type GuestbookRequest struct {
+	Name     string `json:"name"`
+	Message  string `json:"message"`
+	Website  string `json:"website,omitempty"`
+	Nickname string `json:"nickname,omitempty"`
+}
+
+// GuestbookEntry is a stored guestbook submission.
+type GuestbookEntry struct {
+	ID          string     `json:"id"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	Name        string     `json:"name"`
+	Message     string     `json:"message"`
+	Website     string     `json:"website,omitempty"`
+	Country     string     `json:"country,omitempty"`
+	Status      string     `json:"status"`
+	SpamSignals []string   `json:"spamSignals,omitempty"`
+	ModeratedAt *time.Time `json:"moderatedAt,omitempty"`
+}
+
+// GuestbookPage is one page of approved guestbook entries, newest first.
+type GuestbookPage struct {
+	Entries []GuestbookEntry `json:"entries"`
+	Page    int              `json:"page"`
+	PerPage int              `json:"perPage"`
+	Total   int              `json:"total"`
+}
@@ -0,0 +1,167 @@
+// Package schedule parses cron-like expressions and computes their next run.
+//
+// Expressions use the standard five fields "minute hour day-of-month month
+// day-of-week", each accepting "*", single values, ranges ("1-5"), lists
+// ("1,15") and steps ("*/15", "0-30/10"). Day-of-week runs 0-6 from Sunday,
+// with 7 also meaning Sunday. The shortcuts @hourly, @daily, @weekly and
+// @monthly are accepted as well.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed expression. Each field is a bitmask of allowed values.
+type Schedule struct {
+	expr   string
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+
+	// domAny/dowAny record a "*" field: cron matches either day field when
+	// both are restricted, but only the restricted one otherwise.
+	domAny bool
+	dowAny bool
+}
+
+var shortcuts = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+type bounds struct{ min, max int }
+
+var fieldBounds = [5]bounds{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// Parse parses a cron expression.
+func Parse(expr string) (*Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if s, ok := shortcuts[strings.ToLower(spec)]; ok {
+		spec = s
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule %q: want 5 fields, got %d", expr, len(fields))
+	}
+
+	var masks [5]uint64
+	for i, f := range fields {
+		m, err := parseField(f, fieldBounds[i])
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", expr, err)
+		}
+		masks[i] = m
+	}
+	// Fold 7 into 0 so Sunday has a single bit.
+	if masks[4]&(1<<7) != 0 {
+		masks[4] = masks[4]&^(1<<7) | 1
+	}
+
+	return &Schedule{
+		expr:   expr,
+		minute: masks[0],
+		hour:   masks[1],
+		dom:    masks[2],
+		month:  masks[3],
+		dow:    masks[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+// String returns the expression the schedule was parsed from.
+func (s *Schedule) String() string { return s.expr }
+
+// Next returns the first matching minute strictly after t, in t's location.
+// It returns the zero time if nothing matches within five years (e.g. "30 2 31 2 *").
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+func parseField(field string, b bounds) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("bad step in %q", part)
+			}
+			rng, step = part[:i], n
+		}
+
+		lo, hi := b.min, b.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, z, _ := strings.Cut(rng, "-")
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(a)
+			hi, err2 = strconv.Atoi(z)
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("bad range %q", rng)
+			}
+		default:
+			n, err := strconv.Atoi(rng)
+			if err != nil {
+				return 0, fmt.Errorf("bad value %q", rng)
+			}
+			lo = n
+			if step == 1 {
+				hi = n
+			}
+		}
+		if lo < b.min || hi > b.max || lo > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, b.min, b.max)
+		}
+		for v := lo; v <= hi; v += step {
+			mask |= 1 << uint(v)
+		}
+	}
+	return mask, nil
+}
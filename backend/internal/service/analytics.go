@@ -0,0 +1,400 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/model"
+)
+
+// Analytics granularities.
+const (
+	AnalyticsHourly = "hour"
+	AnalyticsDaily  = "day"
+)
+
+// ErrUnknownEvent is returned for beacon events of an unsupported type.
+var ErrUnknownEvent = errors.New("unknown analytics event type")
+
+const (
+	// maxBucketKeys caps each breakdown map per bucket; further keys are
+	// counted under otherKey so a flood of junk URLs cannot grow the file.
+	maxBucketKeys = 200
+	otherKey      = "(other)"
+	directKey     = "(direct)"
+	unknownBotKey = "(unidentified)"
+	// maxKeyLength truncates page paths, referrers and UTM values.
+	maxKeyLength = 200
+	// topN is the length of ranked lists in reports.
+	topN = 10
+)
+
+// AnalyticsService records page views, downloads and visitor sessions into
+// hourly and daily buckets without cookies or stored IP addresses. Visitors
+// are told apart by a hash of IP address and User-Agent with a random salt
+// that is replaced every UTC day, so the same person cannot be followed from
+// one day to the next.
+type AnalyticsService struct {
+	path       string
+	hourlyKeep time.Duration
+	dailyKeep  time.Duration
+
+	mu    sync.Mutex
+	state analyticsState
+	dirty bool
+}
+
+// analyticsState is the persisted form. The salt and seen-sets only cover
+// the open hour and day and are replaced when those roll over.
+type analyticsState struct {
+	Salt     string                  `json:"salt"`
+	Day      time.Time               `json:"day"`
+	Hour     time.Time               `json:"hour"`
+	DaySeen  map[string]bool         `json:"daySeen"`
+	HourSeen map[string]bool         `json:"hourSeen"`
+	Hourly   []model.AnalyticsBucket `json:"hourly"`
+	Daily    []model.AnalyticsBucket `json:"daily"`
+}
+
+// NewAnalyticsService loads analytics from path. Hourly buckets are kept for
+// hourlyKeep and daily ones for dailyKeep.
+func NewAnalyticsService(path string, hourlyKeep, dailyKeep time.Duration) (*AnalyticsService, error) {
+	a := &AnalyticsService{path: path, hourlyKeep: hourlyKeep, dailyKeep: dailyKeep}
+	if err := loadJSONFile(path, &a.state); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Record counts a beacon event from the visitor at ip using userAgent, who
+// was located at loc.
+func (a *AnalyticsService) Record(ev model.AnalyticsEvent, ip, userAgent string, loc model.GeoLocation) error {
+	if ev.Type != model.AnalyticsPageView && ev.Type != model.AnalyticsDownload {
+		return ErrUnknownEvent
+	}
+	page, _ := url.Parse(ev.URL)
+	if page == nil {
+		page = &url.URL{}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	hour, day := a.bucketsLocked(time.Now())
+	a.markUniqueLocked(ip, userAgent, loc, hour, day)
+
+	for _, b := range []*model.AnalyticsBucket{hour, day} {
+		switch ev.Type {
+		case model.AnalyticsPageView:
+			b.PageViews++
+			b.Pages = countKey(b.Pages, pagePath(page))
+			b.Referrers = countKey(b.Referrers, referrerHost(ev.Referrer, page.Hostname()))
+			b.Devices = countKey(b.Devices, DeviceClass(userAgent))
+			q := page.Query()
+			if v := utmValue(q, "utm_source"); v != "" {
+				b.UTMSources = countKey(b.UTMSources, v)
+			}
+			if v := utmValue(q, "utm_medium"); v != "" {
+				b.UTMMediums = countKey(b.UTMMediums, v)
+			}
+			if v := utmValue(q, "utm_campaign"); v != "" {
+				b.UTMCampaigns = countKey(b.UTMCampaigns, v)
+			}
+		case model.AnalyticsDownload:
+			b.Downloads++
+			target, _ := url.Parse(ev.Target)
+			if target == nil {
+				target = &url.URL{}
+			}
+			b.Files = countKey(b.Files, pagePath(target))
+		}
+	}
+	a.dirty = true
+	return nil
+}
+
+// RecordSession counts a finished live-visitor session.
+func (a *AnalyticsService) RecordSession(ip, userAgent string, loc model.GeoLocation, duration time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	hour, day := a.bucketsLocked(time.Now())
+	a.markUniqueLocked(ip, userAgent, loc, hour, day)
+	for _, b := range []*model.AnalyticsBucket{hour, day} {
+		b.Sessions++
+		b.SessionSeconds += int64(duration / time.Second)
+	}
+	a.dirty = true
+}
+
+// RecordBot counts a beacon or live session from a bot. agent is the bot
+// User-Agent pattern it matched, if any, and signals why it is a bot.
+func (a *AnalyticsService) RecordBot(agent string, signals []string) {
+	if agent == "" {
+		agent = unknownBotKey
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	hour, day := a.bucketsLocked(time.Now())
+	for _, b := range []*model.AnalyticsBucket{hour, day} {
+		b.Bots++
+		b.BotAgents = countKey(b.BotAgents, truncateKey(agent))
+		for _, s := range signals {
+			b.BotSignals = countKey(b.BotSignals, s)
+		}
+	}
+	a.dirty = true
+}
+
+// Report aggregates the buckets of the given granularity starting in [from, to).
+func (a *AnalyticsService) Report(granularity string, from, to time.Time) model.AnalyticsReport {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	buckets := a.state.Daily
+	if granularity == AnalyticsHourly {
+		buckets = a.state.Hourly
+	}
+	rep := model.AnalyticsReport{From: from, To: to, Granularity: granularity, Series: []model.AnalyticsPoint{}}
+	var sessionSeconds int64
+	merged := make([]map[string]int, 11)
+	for _, b := range buckets {
+		if b.Start.Before(from) || !b.Start.Before(to) {
+			continue
+		}
+		rep.Series = append(rep.Series, bucketPoint(b))
+		rep.Totals.PageViews += b.PageViews
+		rep.Totals.Uniques += b.Uniques
+		rep.Totals.Sessions += b.Sessions
+		rep.Totals.Downloads += b.Downloads
+		rep.Totals.Bots += b.Bots
+		sessionSeconds += b.SessionSeconds
+		for i, m := range []map[string]int{b.Pages, b.Referrers, b.UTMSources, b.UTMMediums, b.UTMCampaigns, b.Devices, b.Files, b.Countries, b.Cities, b.BotAgents, b.BotSignals} {
+			if merged[i] == nil {
+				merged[i] = map[string]int{}
+			}
+			for k, n := range m {
+				merged[i][k] += n
+			}
+		}
+	}
+	rep.Totals.Start = from
+	if rep.Totals.Sessions > 0 {
+		rep.Totals.AvgSession = float64(sessionSeconds) / float64(rep.Totals.Sessions)
+	}
+	rep.Pages = topKeys(merged[0])
+	rep.Referrers = topKeys(merged[1])
+	rep.UTMSources = topKeys(merged[2])
+	rep.UTMMediums = topKeys(merged[3])
+	rep.UTMCampaigns = topKeys(merged[4])
+	rep.Devices = topKeys(merged[5])
+	rep.Files = topKeys(merged[6])
+	rep.Countries = topKeys(merged[7])
+	rep.Cities = topKeys(merged[8])
+	rep.BotAgents = topKeys(merged[9])
+	rep.BotSignals = topKeys(merged[10])
+	return rep
+}
+
+// Flush writes pending changes to disk, dropping buckets past retention.
+func (a *AnalyticsService) Flush() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	// Discard the previous day's salt even if nothing was recorded today.
+	if a.rotateLocked(now) {
+		a.dirty = true
+	}
+	if !a.dirty {
+		return nil
+	}
+	a.state.Hourly = pruneBuckets(a.state.Hourly, now.Add(-a.hourlyKeep))
+	a.state.Daily = pruneBuckets(a.state.Daily, now.Add(-a.dailyKeep))
+	if err := saveJSONFile(a.path, a.state); err != nil {
+		return err
+	}
+	a.dirty = false
+	return nil
+}
+
+// Run flushes analytics every interval until ctx is done. Callers flush once
+// more after that.
+func (a *AnalyticsService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := a.Flush(); err != nil {
+			slog.Error("[analytics] Failed to save analytics", "error", err)
+		}
+	}
+}
+
+// bucketsLocked returns the open hourly and daily buckets for now.
+func (a *AnalyticsService) bucketsLocked(now time.Time) (*model.AnalyticsBucket, *model.AnalyticsBucket) {
+	a.rotateLocked(now)
+	return openBucket(&a.state.Hourly, a.state.Hour), openBucket(&a.state.Daily, a.state.Day)
+}
+
+// rotateLocked moves the open hour and day to now's, replacing the salt and
+// seen-sets that belong to earlier ones. It reports whether anything changed.
+func (a *AnalyticsService) rotateLocked(now time.Time) bool {
+	now = now.UTC()
+	hour := now.Truncate(time.Hour)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	s := &a.state
+	changed := false
+	if !s.Day.Equal(day) || s.Salt == "" {
+		salt := make([]byte, 16)
+		rand.Read(salt)
+		s.Salt = hex.EncodeToString(salt)
+		s.Day = day
+		s.DaySeen = map[string]bool{}
+		changed = true
+	}
+	if !s.Hour.Equal(hour) {
+		s.Hour = hour
+		s.HourSeen = map[string]bool{}
+		changed = true
+	}
+	return changed
+}
+
+// markUniqueLocked counts the visitor, and where they are, once per bucket.
+func (a *AnalyticsService) markUniqueLocked(ip, userAgent string, loc model.GeoLocation, hour, day *model.AnalyticsBucket) {
+	sum := sha256.Sum256([]byte(a.state.Salt + "|" + ip + "|" + userAgent))
+	id := hex.EncodeToString(sum[:8])
+	for _, b := range []struct {
+		seen   map[string]bool
+		bucket *model.AnalyticsBucket
+	}{{a.state.HourSeen, hour}, {a.state.DaySeen, day}} {
+		if b.seen[id] {
+			continue
+		}
+		b.seen[id] = true
+		b.bucket.Uniques++
+		if loc.Country != "" {
+			b.bucket.Countries = countKey(b.bucket.Countries, loc.Country)
+			if loc.City != "" {
+				b.bucket.Cities = countKey(b.bucket.Cities, loc.City+", "+loc.Country)
+			}
+		}
+	}
+}
+
+// openBucket returns the last bucket of list if it starts at start, or
+// appends a new one.
+func openBucket(list *[]model.AnalyticsBucket, start time.Time) *model.AnalyticsBucket {
+	if n := len(*list); n > 0 && (*list)[n-1].Start.Equal(start) {
+		return &(*list)[n-1]
+	}
+	*list = append(*list, model.AnalyticsBucket{Start: start})
+	return &(*list)[len(*list)-1]
+}
+
+func pruneBuckets(list []model.AnalyticsBucket, cutoff time.Time) []model.AnalyticsBucket {
+	i := 0
+	for i < len(list) && list[i].Start.Before(cutoff) {
+		i++
+	}
+	return list[i:]
+}
+
+func bucketPoint(b model.AnalyticsBucket) model.AnalyticsPoint {
+	p := model.AnalyticsPoint{
+		Start: b.Start, PageViews: b.PageViews, Uniques: b.Uniques,
+		Sessions: b.Sessions, Downloads: b.Downloads, Bots: b.Bots,
+	}
+	if b.Sessions > 0 {
+		p.AvgSession = float64(b.SessionSeconds) / float64(b.Sessions)
+	}
+	return p
+}
+
+// countKey increments key in m, folding new keys into otherKey once m is full.
+func countKey(m map[string]int, key string) map[string]int {
+	if m == nil {
+		m = map[string]int{}
+	}
+	if _, ok := m[key]; !ok && len(m) >= maxBucketKeys {
+		key = otherKey
+	}
+	m[key]++
+	return m
+}
+
+func topKeys(m map[string]int) []model.KeyCount {
+	out := make([]model.KeyCount, 0, len(m))
+	for k, n := range m {
+		out = append(out, model.KeyCount{Key: k, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Key < out[j].Key
+	})
+	if len(out) > topN {
+		out = out[:topN]
+	}
+	return out
+}
+
+func pagePath(u *url.URL) string {
+	p := u.Path
+	if p == "" {
+		p = "/"
+	}
+	return truncateKey(p)
+}
+
+// referrerHost reduces a referrer to its host, reporting links from the
+// site itself as direct traffic.
+func referrerHost(ref, siteHost string) string {
+	u, err := url.Parse(ref)
+	if err != nil || u.Host == "" {
+		return directKey
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if host == strings.TrimPrefix(strings.ToLower(siteHost), "www.") {
+		return directKey
+	}
+	return truncateKey(host)
+}
+
+func utmValue(q url.Values, name string) string {
+	return truncateKey(strings.ToLower(strings.TrimSpace(q.Get(name))))
+}
+
+func truncateKey(s string) string {
+	if len(s) > maxKeyLength {
+		return s[:maxKeyLength]
+	}
+	return s
+}
+
+// DeviceClass buckets a User-Agent into "mobile", "tablet" or "desktop".
+func DeviceClass(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case strings.Contains(ua, "ipad") || strings.Contains(ua, "tablet") ||
+		(strings.Contains(ua, "android") && !strings.Contains(ua, "mobile")):
+		return "tablet"
+	case strings.Contains(ua, "mobi") || strings.Contains(ua, "iphone") || strings.Contains(ua, "android"):
+		return "mobile"
+	default:
+		return "desktop"
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"portfolio-backend/internal/model"
+)
+
+// AttachmentPolicy limits what visitors may upload with the contact form.
+type AttachmentPolicy struct {
+	MaxFiles     int
+	MaxFileSize  int64
+	MaxTotalSize int64
+	AllowedTypes []string
+}
+
+// AttachmentError is a rejected upload with the HTTP status to report.
+type AttachmentError struct {
+	Status  int
+	Message string
+}
+
+func (e *AttachmentError) Error() string { return e.Message }
+
+// officeTypes maps zip-based document extensions to their real MIME type,
+// since content sniffing only sees a zip archive.
+var officeTypes = map[string]string{
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".odt":  "application/vnd.oasis.opendocument.text",
+}
+
+// ReadAttachments validates uploaded files against the policy, sniffing each
+// file's real content type instead of trusting the client-supplied header.
+func (p AttachmentPolicy) ReadAttachments(files []*multipart.FileHeader) ([]model.Attachment, error) {
+	if len(files) > p.MaxFiles {
+		return nil, &AttachmentError{
+			Status:  http.StatusBadRequest,
+			Message: fmt.Sprintf("At most %d attachments are allowed", p.MaxFiles),
+		}
+	}
+
+	var out []model.Attachment
+	var total int64
+	for _, fh := range files {
+		name := filepath.Base(strings.ReplaceAll(fh.Filename, "\\", "/"))
+		if fh.Size > p.MaxFileSize {
+			return nil, &AttachmentError{
+				Status:  http.StatusRequestEntityTooLarge,
+				Message: fmt.Sprintf("%s exceeds the %d MB limit", name, p.MaxFileSize>>20),
+			}
+		}
+		total += fh.Size
+		if total > p.MaxTotalSize {
+			return nil, &AttachmentError{
+				Status:  http.StatusRequestEntityTooLarge,
+				Message: fmt.Sprintf("Attachments exceed the %d MB total limit", p.MaxTotalSize>>20),
+			}
+		}
+
+		data, err := readFileHeader(fh, p.MaxFileSize)
+		if err != nil {
+			return nil, err
+		}
+
+		ctype := sniffContentType(name, data)
+		if !p.allowed(ctype) {
+			return nil, &AttachmentError{
+				Status:  http.StatusUnsupportedMediaType,
+				Message: fmt.Sprintf("%s has unsupported type %s", name, ctype),
+			}
+		}
+
+		out = append(out, model.Attachment{
+			Filename:    name,
+			ContentType: ctype,
+			Size:        int64(len(data)),
+			Content:     data,
+		})
+	}
+	return out, nil
+}
+
+func (p AttachmentPolicy) allowed(ctype string) bool {
+	for _, t := range p.AllowedTypes {
+		if strings.EqualFold(t, ctype) {
+			return true
+		}
+	}
+	return false
+}
+
+func readFileHeader(fh *multipart.FileHeader, limit int64) ([]byte, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open upload: %w", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("read upload: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return nil, &AttachmentError{Status: http.StatusRequestEntityTooLarge, Message: "Attachment too large"}
+	}
+	return data, nil
+}
+
+func sniffContentType(name string, data []byte) string {
+	ctype, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	if ctype == "application/zip" {
+		if office, ok := officeTypes[strings.ToLower(filepath.Ext(name))]; ok {
+			return office
+		}
+	}
+	return ctype
+}
+
+// StoreAttachments writes attachment content to blobs and fills in their hashes.
+func StoreAttachments(blobs BlobStore, atts []model.Attachment) error {
+	for i := range atts {
+		sum, err := blobs.Put(atts[i].Content)
+		if err != nil {
+			return err
+		}
+		atts[i].SHA256 = sum
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"portfolio-backend/internal/model"
+)
+
+// AuditLog records administrative actions on personal data.
+type AuditLog interface {
+	Record(entry model.AuditEntry) error
+}
+
+// FileAuditLog appends audit entries as JSON lines. The file is append-only;
+// it is never rewritten by retention or erasure.
+type FileAuditLog struct {
+	filePath string
+	mu       sync.Mutex
+}
+
+// NewFileAuditLog creates an AuditLog that writes to the given file path.
+func NewFileAuditLog(path string) *FileAuditLog {
+	return &FileAuditLog{filePath: path}
+}
+
+func (a *FileAuditLog) Record(entry model.AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode audit entry: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(a.filePath), 0755); err != nil {
+		return fmt.Errorf("create audit dir: %w", err)
+	}
+	f, err := os.OpenFile(a.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open audit log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write audit entry: %w", err)
+	}
+	return nil
+}
+
+// HashSubject returns a stable, non-reversible identifier for an email address.
+func HashSubject(email string) string {
+	h := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"portfolio-backend/internal/envelope"
+)
+
+// ErrBlobNotFound is returned when a blob with the requested hash does not exist.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps binary content addressed by a 64-character hex digest.
+type BlobStore interface {
+	Put(data []byte) (string, error)
+	Get(sum string) ([]byte, error)
+	Delete(sum string) error
+}
+
+// sealedBlobMagic prefixes blob files whose content is an encrypted envelope.
+var sealedBlobMagic = []byte("ENV1")
+
+// FileBlobStore stores each blob as a file named after its content address
+// (see RecordSealer.BlobAddress), so identical uploads are kept once. With
+// encryption on the address is keyed; blobs stored before that keep their
+// plain SHA-256 names.
+type FileBlobStore struct {
+	dir    string
+	sealer *RecordSealer
+}
+
+// NewFileBlobStore creates a blob store rooted at dir. Blob content is
+// encrypted when sealer is enabled.
+func NewFileBlobStore(dir string, sealer *RecordSealer) *FileBlobStore {
+	return &FileBlobStore{dir: dir, sealer: sealer}
+}
+
+func (s *FileBlobStore) Put(data []byte) (string, error) {
+	sum := s.sealer.BlobAddress(data)
+	path := s.path(sum)
+
+	if _, err := os.Stat(path); err == nil {
+		return sum, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("create blob dir: %w", err)
+	}
+	content, err := s.encode(sum, data)
+	if err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(path, content); err != nil {
+		return "", fmt.Errorf("store blob: %w", err)
+	}
+	return sum, nil
+}
+
+func (s *FileBlobStore) Get(sum string) ([]byte, error) {
+	if !validSum(sum) {
+		return nil, ErrBlobNotFound
+	}
+	data, err := os.ReadFile(s.path(sum))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.decode(sum, data)
+}
+
+func (s *FileBlobStore) Delete(sum string) error {
+	if !validSum(sum) {
+		return ErrBlobNotFound
+	}
+	err := os.Remove(s.path(sum))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Reseal encrypts plaintext blobs and re-encrypts blobs sealed with a
+// rotated-out key. It returns the number of blobs rewritten.
+func (s *FileBlobStore) Reseal() (int, error) {
+	if !s.sealer.Enabled() {
+		return 0, nil
+	}
+	changed := 0
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil || d.IsDir() || !validSum(d.Name()) {
+			return err
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if env, ok := parseSealedBlob(raw); ok && !s.sealer.NeedsRotation(env) {
+			return nil
+		}
+		data, err := s.decode(d.Name(), raw)
+		if err != nil {
+			return err
+		}
+		content, err := s.encode(d.Name(), data)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(path, content); err != nil {
+			return err
+		}
+		changed++
+		return nil
+	})
+	return changed, err
+}
+
+func (s *FileBlobStore) encode(sum string, data []byte) ([]byte, error) {
+	keys := s.sealer.Keys()
+	if keys == nil {
+		return data, nil
+	}
+	env, err := keys.Seal(data, []byte("blob:"+sum))
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(env)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte(nil), sealedBlobMagic...), encoded...), nil
+}
+
+func (s *FileBlobStore) decode(sum string, raw []byte) ([]byte, error) {
+	env, ok := parseSealedBlob(raw)
+	if !ok {
+		return raw, nil
+	}
+	keys := s.sealer.Keys()
+	if keys == nil {
+		return nil, fmt.Errorf("blob %s is encrypted but no keys are configured", sum)
+	}
+	return keys.Open(env, []byte("blob:"+sum))
+}
+
+func parseSealedBlob(raw []byte) (*envelope.Envelope, bool) {
+	if !bytes.HasPrefix(raw, sealedBlobMagic) {
+		return nil, false
+	}
+	var env envelope.Envelope
+	if err := json.Unmarshal(raw[len(sealedBlobMagic):], &env); err != nil {
+		return nil, false
+	}
+	return &env, true
+}
+
+// path shards blobs by the first two hex characters to keep directories small.
+func (s *FileBlobStore) path(sum string) string {
+	return filepath.Join(s.dir, sum[:2], sum)
+}
+
+func validSum(sum string) bool {
+	if len(sum) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(sum)
+	return err == nil
+}
@@ -0,0 +1,458 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/ical"
+	"portfolio-backend/internal/model"
+)
+
+// Booking errors.
+var (
+	ErrSlotUnavailable    = errors.New("slot is not available")
+	ErrBookingNotFound    = errors.New("booking not found")
+	ErrInvalidToken       = errors.New("invalid booking token")
+	ErrBookingExpired     = errors.New("booking was not confirmed in time")
+	ErrBookingRateLimited = errors.New("too many bookings")
+)
+
+const blackoutLayout = "2006-01-02"
+
+// timeRange is a span of minutes after local midnight.
+type timeRange struct{ from, to int }
+
+// WeeklyHours lists bookable time ranges per weekday, indexed by time.Weekday.
+type WeeklyHours [7][]timeRange
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseWeeklyHours parses rules such as "mon-fri 09:00-12:00,13:00-17:00; sat 10:00-12:00".
+// Each rule is a day list (names or ranges) followed by comma-separated time ranges.
+func ParseWeeklyHours(spec string) (WeeklyHours, error) {
+	var wh WeeklyHours
+	for _, rule := range strings.Split(spec, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		days, times, ok := strings.Cut(rule, " ")
+		if !ok {
+			return wh, fmt.Errorf("hours rule %q: want \"<days> <hh:mm-hh:mm>\"", rule)
+		}
+		var ranges []timeRange
+		for _, t := range strings.Split(strings.ReplaceAll(times, " ", ""), ",") {
+			r, err := parseTimeRange(t)
+			if err != nil {
+				return wh, fmt.Errorf("hours rule %q: %w", rule, err)
+			}
+			ranges = append(ranges, r)
+		}
+		for _, d := range strings.Split(strings.ToLower(days), ",") {
+			first, last, isRange := strings.Cut(d, "-")
+			if !isRange {
+				last = first
+			}
+			a, ok1 := weekdays[first]
+			z, ok2 := weekdays[last]
+			if !ok1 || !ok2 {
+				return wh, fmt.Errorf("hours rule %q: unknown day %q", rule, d)
+			}
+			for wd := a; ; wd = (wd + 1) % 7 {
+				wh[wd] = append(wh[wd], ranges...)
+				if wd == z {
+					break
+				}
+			}
+		}
+	}
+	return wh, nil
+}
+
+func parseTimeRange(s string) (timeRange, error) {
+	a, z, ok := strings.Cut(s, "-")
+	if !ok {
+		return timeRange{}, fmt.Errorf("bad time range %q", s)
+	}
+	from, err1 := parseClock(a)
+	to, err2 := parseClock(z)
+	if err1 != nil || err2 != nil || from >= to {
+		return timeRange{}, fmt.Errorf("bad time range %q", s)
+	}
+	return timeRange{from, to}, nil
+}
+
+func parseClock(s string) (int, error) {
+	h, m, ok := strings.Cut(s, ":")
+	hh, err1 := strconv.Atoi(h)
+	mm, err2 := strconv.Atoi(m)
+	if !ok || err1 != nil || err2 != nil || hh < 0 || hh > 24 || mm < 0 || mm > 59 || hh*60+mm > 24*60 {
+		return 0, fmt.Errorf("bad time %q", s)
+	}
+	return hh*60 + mm, nil
+}
+
+// Availability describes when the owner can be booked.
+type Availability struct {
+	Location  *time.Location
+	Hours     WeeklyHours
+	Slot      time.Duration
+	Buffer    time.Duration
+	MinNotice time.Duration
+	Horizon   time.Duration
+	Blackout  map[string]bool // dates as 2006-01-02 in Location
+}
+
+// ParseBlackout parses YYYY-MM-DD dates.
+func ParseBlackout(dates []string) (map[string]bool, error) {
+	out := make(map[string]bool, len(dates))
+	for _, d := range dates {
+		if _, err := time.Parse(blackoutLayout, d); err != nil {
+			return nil, fmt.Errorf("blackout date %q: want YYYY-MM-DD", d)
+		}
+		out[d] = true
+	}
+	return out, nil
+}
+
+// MeetingInfo describes the meeting shown in invitations.
+type MeetingInfo struct {
+	Title     string
+	Location  string
+	OwnerName string
+	// PublicURL is the externally reachable base URL for cancellation links.
+	PublicURL string
+}
+
+// BookingPolicy limits how visitors book.
+type BookingPolicy struct {
+	// PerHour is how many bookings one client IP may request an hour; zero
+	// disables the limit.
+	PerHour int
+	// ConfirmTTL is how long a new booking holds its slot while waiting
+	// for the visitor to confirm it by email.
+	ConfirmTTL time.Duration
+}
+
+// BookingService offers free slots and reserves meetings. A booking holds
+// its slot until the visitor confirms it from an emailed link; only then is
+// an iCalendar invitation sent to both the visitor and the owner.
+type BookingService struct {
+	store      BookingStore
+	email      EmailService
+	avail      Availability
+	meeting    MeetingInfo
+	confirmTTL time.Duration
+	limit      *hourlyLimit // by client IP
+	ownerEmail string
+	msgDomain  string
+}
+
+// NewBookingService creates a booking service.
+func NewBookingService(store BookingStore, email EmailService, avail Availability, meeting MeetingInfo, policy BookingPolicy, ownerEmail, msgDomain string) *BookingService {
+	return &BookingService{
+		store:      store,
+		email:      email,
+		avail:      avail,
+		meeting:    meeting,
+		confirmTTL: policy.ConfirmTTL,
+		limit:      newHourlyLimit(policy.PerHour),
+		ownerEmail: ownerEmail,
+		msgDomain:  msgDomain,
+	}
+}
+
+// Location returns the owner's timezone.
+func (s *BookingService) Location() *time.Location { return s.avail.Location }
+
+// SlotLength returns the meeting duration.
+func (s *BookingService) SlotLength() time.Duration { return s.avail.Slot }
+
+// Slots returns free slots starting in [from, to), clamped to the notice
+// period and booking horizon.
+func (s *BookingService) Slots(from, to time.Time) []model.Slot {
+	bookings := s.store.List()
+	now := time.Now()
+	var free []model.Slot
+	for _, slot := range s.candidates(from, to, now) {
+		taken := false
+		for _, b := range bookings {
+			if overlapsBooking(b, slot.Start, slot.End, s.avail.Buffer, now) {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			free = append(free, slot)
+		}
+	}
+	return free
+}
+
+// candidates generates every slot allowed by the availability rules,
+// ignoring existing bookings.
+func (s *BookingService) candidates(from, to, now time.Time) []model.Slot {
+	a := s.avail
+	if a.Slot <= 0 {
+		return nil
+	}
+	if earliest := now.Add(a.MinNotice); from.Before(earliest) {
+		from = earliest
+	}
+	if latest := now.Add(a.Horizon); to.After(latest) {
+		to = latest
+	}
+
+	var slots []model.Slot
+	local := from.In(a.Location)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, a.Location)
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if a.Blackout[day.Format(blackoutLayout)] {
+			continue
+		}
+		for _, r := range a.Hours[day.Weekday()] {
+			// time.Date normalizes minute overflow and stays correct across DST changes.
+			end := time.Date(day.Year(), day.Month(), day.Day(), 0, r.to, 0, 0, a.Location)
+			start := time.Date(day.Year(), day.Month(), day.Day(), 0, r.from, 0, 0, a.Location)
+			for ; !start.Add(a.Slot).After(end); start = start.Add(a.Slot) {
+				if start.Before(from) || !start.Before(to) {
+					continue
+				}
+				slots = append(slots, model.Slot{Start: start, End: start.Add(a.Slot)})
+			}
+		}
+	}
+	return slots
+}
+
+// Book holds the slot starting at req.Start for a visitor at ip until the
+// booking is confirmed. It returns the pending booking and the raw token
+// that confirms and cancels it, which is only stored hashed.
+func (s *BookingService) Book(req model.BookingRequest, ip string) (model.Booking, string, error) {
+	now := time.Now()
+	if !s.limit.allow(ip, now) {
+		return model.Booking{}, "", ErrBookingRateLimited
+	}
+	var slot *model.Slot
+	for _, c := range s.candidates(req.Start, req.Start.Add(time.Minute), now) {
+		if c.Start.Equal(req.Start) {
+			slot = &c
+			break
+		}
+	}
+	if slot == nil {
+		return model.Booking{}, "", ErrSlotUnavailable
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return model.Booking{}, "", fmt.Errorf("generate token: %w", err)
+	}
+	raw := hex.EncodeToString(token)
+
+	holdUntil := now.Add(s.confirmTTL)
+	b, err := s.store.Create(model.Booking{
+		CreatedAt:       now,
+		Start:           slot.Start.UTC(),
+		End:             slot.End.UTC(),
+		Name:            req.Name,
+		Email:           req.Email,
+		Note:            req.Note,
+		Status:          model.BookingPending,
+		HoldUntil:       &holdUntil,
+		CancelTokenHash: hashToken(raw),
+	}, s.avail.Buffer)
+	if err != nil {
+		return model.Booking{}, "", err
+	}
+	slog.Info("[booking] Held", "id", b.ID, "start", b.Start.Format(time.RFC3339), "until", holdUntil.Format(time.RFC3339))
+	return b, raw, nil
+}
+
+// Confirm confirms pending booking id if token matches, reporting whether
+// it was still pending. Confirming twice is a no-op; a hold that ran out,
+// or was cancelled, cannot be confirmed.
+func (s *BookingService) Confirm(id, token string) (model.Booking, bool, error) {
+	b, err := s.lookup(id, token)
+	if err != nil {
+		return model.Booking{}, false, err
+	}
+	now := time.Now()
+	switch {
+	case b.Status == model.BookingConfirmed:
+		return b, false, nil
+	case b.Status != model.BookingPending || b.HoldUntil == nil || !now.Before(*b.HoldUntil):
+		return model.Booking{}, false, ErrBookingExpired
+	}
+
+	b.Status = model.BookingConfirmed
+	b.ConfirmedAt = &now
+	b.HoldUntil = nil
+	if err := s.store.Update(b); err != nil {
+		return model.Booking{}, false, err
+	}
+	slog.Info("[booking] Confirmed", "id", b.ID, "start", b.Start.Format(time.RFC3339))
+	return b, true, nil
+}
+
+// Cancel cancels booking id if token matches, reporting whether it was
+// confirmed, and so whether invitations need cancelling. Cancelling twice
+// is a no-op.
+func (s *BookingService) Cancel(id, token string) (model.Booking, bool, error) {
+	b, err := s.lookup(id, token)
+	if err != nil {
+		return model.Booking{}, false, err
+	}
+	if b.Status == model.BookingCancelled {
+		return b, false, nil
+	}
+
+	now := time.Now()
+	wasConfirmed := b.Status == model.BookingConfirmed
+	b.Status = model.BookingCancelled
+	b.CancelledAt = &now
+	b.HoldUntil = nil
+	b.Sequence++
+	if err := s.store.Update(b); err != nil {
+		return model.Booking{}, false, err
+	}
+	slog.Info("[booking] Cancelled", "id", b.ID)
+	return b, wasConfirmed, nil
+}
+
+func (s *BookingService) lookup(id, token string) (model.Booking, error) {
+	b, ok := s.store.Get(id)
+	if !ok {
+		return model.Booking{}, ErrBookingNotFound
+	}
+	if subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(b.CancelTokenHash)) != 1 {
+		return model.Booking{}, ErrInvalidToken
+	}
+	return b, nil
+}
+
+// SendConfirmation emails the visitor the link that confirms pending
+// booking b. Nothing goes to the owner until the visitor confirms.
+func (s *BookingService) SendConfirmation(ctx context.Context, b model.Booking, token string) {
+	confirmURL := s.bookingURL("confirm", b.ID, token)
+	when := b.Start.In(s.avail.Location).Format("Mon Jan 2, 2006 15:04 MST")
+	intro := fmt.Sprintf("Please confirm your %s with %s on %s.", s.meeting.Title, s.meeting.OwnerName, when)
+	held := "The time is held for you until " + b.HoldUntil.In(s.avail.Location).Format("15:04 MST") +
+		". If you did not ask for this meeting, ignore this email."
+	err := s.email.Deliver(ctx, model.Email{
+		To:      []string{b.Email},
+		ReplyTo: s.ownerEmail,
+		Subject: "Confirm your " + s.meeting.Title + ": " + when,
+		Text:    intro + "\n\n" + confirmURL + "\n\n" + held + "\n",
+		HTML: fmt.Sprintf(`<p>%s</p><p><a href="%s">Confirm the meeting</a></p><p>%s</p>`,
+			html.EscapeString(intro), html.EscapeString(confirmURL), html.EscapeString(held)),
+	})
+	if err != nil {
+		slog.Error("[booking] Failed to send confirmation request", "error", err, "id", b.ID)
+	}
+}
+
+// SendInvites emails the invitation (or cancellation, for cancelled
+// bookings) to the visitor and the owner.
+func (s *BookingService) SendInvites(ctx context.Context, b model.Booking, token string) {
+	method := ical.MethodRequest
+	if b.Status == model.BookingCancelled {
+		method = ical.MethodCancel
+	}
+	cancelURL := s.bookingURL("cancel", b.ID, token)
+
+	ics := ical.Encode(method, ical.Event{
+		UID:         b.ID + "@" + s.msgDomain,
+		Sequence:    b.Sequence,
+		Start:       b.Start,
+		End:         b.End,
+		Stamp:       time.Now(),
+		Summary:     s.meeting.Title + " with " + b.Name,
+		Description: b.Note,
+		Location:    s.meeting.Location,
+		Organizer:   ical.Person{Name: s.meeting.OwnerName, Email: s.ownerEmail},
+		Attendees:   []ical.Person{{Name: b.Name, Email: b.Email}},
+	})
+	invite := model.Attachment{
+		Filename:    "invite.ics",
+		ContentType: "text/calendar; method=" + method + "; charset=UTF-8",
+		Size:        int64(len(ics)),
+		Content:     ics,
+	}
+
+	when := b.Start.In(s.avail.Location).Format("Mon Jan 2, 2006 15:04 MST")
+	for _, m := range []struct {
+		to, replyTo, with, subject, intro string
+	}{
+		{b.Email, s.ownerEmail, s.meeting.OwnerName, s.meeting.Title + " confirmed: " + when,
+			fmt.Sprintf("Your %s with %s is booked for %s.", s.meeting.Title, s.meeting.OwnerName, when)},
+		{s.ownerEmail, b.Email, b.Name, "New booking: " + b.Name + ", " + when,
+			fmt.Sprintf("%s <%s> booked %q for %s.", b.Name, b.Email, s.meeting.Title, when)},
+	} {
+		subject, intro := m.subject, m.intro
+		if method == ical.MethodCancel {
+			subject = "Cancelled: " + s.meeting.Title + ", " + when
+			intro = fmt.Sprintf("The %s with %s on %s has been cancelled.", s.meeting.Title, m.with, when)
+		}
+
+		text := intro + "\n"
+		htmlBody := "<p>" + html.EscapeString(intro) + "</p>"
+		if b.Note != "" {
+			text += "\nNote: " + b.Note + "\n"
+			htmlBody += "<p><strong>Note:</strong> " + strings.ReplaceAll(html.EscapeString(b.Note), "\n", "<br>") + "</p>"
+		}
+		if s.meeting.Location != "" {
+			text += "\nWhere: " + s.meeting.Location + "\n"
+			htmlBody += "<p><strong>Where:</strong> " + html.EscapeString(s.meeting.Location) + "</p>"
+		}
+		if method == ical.MethodRequest {
+			text += "\nNeed to cancel? " + cancelURL + "\n"
+			htmlBody += fmt.Sprintf(`<p><a href="%s">Cancel this meeting</a></p>`, html.EscapeString(cancelURL))
+		}
+
+		err := s.email.Deliver(ctx, model.Email{
+			To:          []string{m.to},
+			ReplyTo:     m.replyTo,
+			Subject:     subject,
+			Text:        text,
+			HTML:        htmlBody,
+			Attachments: []model.Attachment{invite},
+		})
+		if err != nil {
+			slog.Error("[booking] Failed to send invitation", "error", err, "id", b.ID, "method", method)
+		}
+	}
+}
+
+// CancelURL returns the link that cancels booking id.
+func (s *BookingService) CancelURL(id, token string) string { return s.bookingURL("cancel", id, token) }
+
+// ConfirmURL returns the link that confirms booking id.
+func (s *BookingService) ConfirmURL(id, token string) string {
+	return s.bookingURL("confirm", id, token)
+}
+
+func (s *BookingService) bookingURL(action, id, token string) string {
+	q := url.Values{"id": {id}, "token": {token}}
+	return strings.TrimRight(s.meeting.PublicURL, "/") + "/api/booking/" + action + "?" + q.Encode()
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
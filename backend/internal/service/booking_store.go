@@ -0,0 +1,110 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"portfolio-backend/internal/model"
+)
+
+// ErrSlotTaken is returned when a booking overlaps one that holds its slot.
+var ErrSlotTaken = errors.New("slot already booked")
+
+// BookingStore persists meeting bookings.
+type BookingStore interface {
+	// Create stores b unless it overlaps a booking that holds its slot,
+	// with buffer kept free on either side.
+	Create(b model.Booking, buffer time.Duration) (model.Booking, error)
+	Get(id string) (model.Booking, bool)
+	// List returns bookings ordered by start time.
+	List() []model.Booking
+	Update(b model.Booking) error
+}
+
+// FileBookingStore keeps bookings in memory and mirrors them to a JSON file.
+type FileBookingStore struct {
+	filePath string
+
+	mu       sync.RWMutex
+	bookings []model.Booking
+}
+
+// NewFileBookingStore loads existing bookings from path.
+func NewFileBookingStore(path string) (*FileBookingStore, error) {
+	s := &FileBookingStore{filePath: path}
+	if err := loadJSONFile(path, &s.bookings); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileBookingStore) Create(b model.Booking, buffer time.Duration) (model.Booking, error) {
+	if b.ID == "" {
+		b.ID = newID()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, other := range s.bookings {
+		if overlapsBooking(other, b.Start, b.End, buffer, b.CreatedAt) {
+			return model.Booking{}, ErrSlotTaken
+		}
+	}
+	s.bookings = append(s.bookings, b)
+	if err := saveJSONFile(s.filePath, s.bookings); err != nil {
+		s.bookings = s.bookings[:len(s.bookings)-1]
+		return model.Booking{}, err
+	}
+	return b, nil
+}
+
+func (s *FileBookingStore) Get(id string) (model.Booking, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, b := range s.bookings {
+		if b.ID == id {
+			return b, true
+		}
+	}
+	return model.Booking{}, false
+}
+
+func (s *FileBookingStore) List() []model.Booking {
+	s.mu.RLock()
+	out := append([]model.Booking(nil), s.bookings...)
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+func (s *FileBookingStore) Update(b model.Booking) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.bookings {
+		if s.bookings[i].ID != b.ID {
+			continue
+		}
+		prev := s.bookings[i]
+		s.bookings[i] = b
+		if err := saveJSONFile(s.filePath, s.bookings); err != nil {
+			s.bookings[i] = prev
+			return err
+		}
+		return nil
+	}
+	return ErrBookingNotFound
+}
+
+// overlapsBooking reports whether [start, end) comes within buffer of a
+// booking that holds its slot at now: a confirmed one, or a pending one
+// whose hold has not run out.
+func overlapsBooking(b model.Booking, start, end time.Time, buffer time.Duration, now time.Time) bool {
+	switch {
+	case b.Status == model.BookingConfirmed:
+	case b.Status == model.BookingPending && b.HoldUntil != nil && now.Before(*b.HoldUntil):
+	default:
+		return false
+	}
+	return start.Before(b.End.Add(buffer)) && end.After(b.Start.Add(-buffer))
+}
@@ -1,7 +1,9 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,19 +17,29 @@ import (
 
 // ChatService generates responses for visitor chat messages.
 type ChatService interface {
-	GetResponse(message string, history []model.ChatMessage) (string, error)
+	GetResponse(ctx context.Context, message string, history []model.ChatMessage) (string, error)
 }
 
+// CompletionProvider runs one-off LLM completions outside the visitor chat.
+type CompletionProvider interface {
+	Complete(ctx context.Context, system, message string) (string, error)
+}
+
+// ErrNoLLM is returned by CompletionProvider implementations without credentials.
+var ErrNoLLM = errors.New("no LLM provider configured")
+
 // GroqChatService calls the Groq LLM API for intelligent responses,
 // falling back to keyword-based replies when no API key is configured.
 type GroqChatService struct {
 	apiKey string
 	client *http.Client
+	events EventPublisher
 }
 
 // NewGroqChatService creates a ChatService backed by Groq.
-// If apiKey is empty, all responses use the local fallback.
-func NewGroqChatService(apiKey string) *GroqChatService {
+// If apiKey is empty, all responses use the local fallback. API failures
+// are reported to events.
+func NewGroqChatService(apiKey string, events EventPublisher) *GroqChatService {
 	if apiKey == "" {
 		slog.Warn("[chat] Groq API key not configured; using local responses")
 	} else {
@@ -36,10 +48,11 @@ func NewGroqChatService(apiKey string) *GroqChatService {
 	return &GroqChatService{
 		apiKey: apiKey,
 		client: &http.Client{Timeout: 30 * time.Second},
+		events: events,
 	}
 }
 
-func (s *GroqChatService) GetResponse(message string, history []model.ChatMessage) (string, error) {
+func (s *GroqChatService) GetResponse(ctx context.Context, message string, history []model.ChatMessage) (string, error) {
 	if s.apiKey == "" {
 		slog.Debug("[chat] Using local fallback", "message", message)
 		return localResponse(message), nil
@@ -47,9 +60,10 @@ func (s *GroqChatService) GetResponse(message string, history []model.ChatMessag
 
 	slog.Debug("[chat] Calling Groq API", "message", message, "historyLen", len(history), "model", "llama-3.1-8b-instant")
 
-	resp, err := s.callAPI(message, history)
+	resp, err := s.callAPI(ctx, systemPrompt, message, history, 0.7, 500)
 	if err != nil {
 		slog.Error("[chat] Groq API error; falling back to local", "error", err)
+		s.events.Publish(EventProviderError, model.ProviderErrorEvent{Provider: "groq", Error: err.Error()})
 		return localResponse(message), nil
 	}
 
@@ -77,9 +91,23 @@ KEY ACHIEVEMENTS:
 
 Be helpful, concise, and encourage visitors to contact Bhavy for opportunities.`
 
-func (s *GroqChatService) callAPI(message string, history []model.ChatMessage) (string, error) {
+// Complete runs a single-turn completion with a custom system prompt. It is
+// used for internal tasks such as lead classification and returns an error
+// when no API key is configured.
+func (s *GroqChatService) Complete(ctx context.Context, system, message string) (string, error) {
+	if s.apiKey == "" {
+		return "", ErrNoLLM
+	}
+	resp, err := s.callAPI(ctx, system, message, nil, 0, 200)
+	if err != nil {
+		s.events.Publish(EventProviderError, model.ProviderErrorEvent{Provider: "groq", Error: err.Error()})
+	}
+	return resp, err
+}
+
+func (s *GroqChatService) callAPI(ctx context.Context, system, message string, history []model.ChatMessage, temperature float64, maxTokens int) (string, error) {
 	messages := []map[string]string{
-		{"role": "system", "content": systemPrompt},
+		{"role": "system", "content": system},
 	}
 	for _, h := range history {
 		role := h.Role
@@ -94,8 +122,8 @@ func (s *GroqChatService) callAPI(message string, history []model.ChatMessage) (
 	body := map[string]any{
 		"model":       "llama-3.1-8b-instant",
 		"messages":    messages,
-		"temperature": 0.7,
-		"max_tokens":  500,
+		"temperature": temperature,
+		"max_tokens":  maxTokens,
 	}
 
 	jsonBody, err := json.Marshal(body)
@@ -103,7 +131,7 @@ func (s *GroqChatService) callAPI(message string, history []model.ChatMessage) (
 		return "", fmt.Errorf("marshal: %w", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, "https://api.groq.com/openai/v1/chat/completions", strings.NewReader(string(jsonBody)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.groq.com/openai/v1/chat/completions", strings.NewReader(string(jsonBody)))
 	if err != nil {
 		return "", fmt.Errorf("create request: %w", err)
 	}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"sync"
+
+	"portfolio-backend/internal/model"
+)
+
+// ChatStore keeps transcripts of chat exchanges.
+type ChatStore interface {
+	Append(ex model.ChatExchange) error
+	List() []model.ChatExchange
+	DeleteWhere(match func(model.ChatExchange) bool) (int, error)
+}
+
+// FileChatStore keeps chat exchanges in memory and mirrors them to a JSON file.
+type FileChatStore struct {
+	filePath string
+	sealer   *RecordSealer
+
+	mu        sync.RWMutex
+	exchanges []model.ChatExchange
+}
+
+// NewFileChatStore loads existing exchanges from path. Exchanges are sealed
+// with sealer before they are kept.
+func NewFileChatStore(path string, sealer *RecordSealer) (*FileChatStore, error) {
+	s := &FileChatStore{filePath: path, sealer: sealer}
+	if err := loadJSONFile(path, &s.exchanges); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileChatStore) Append(ex model.ChatExchange) error {
+	if ex.ID == "" {
+		ex.ID = newID()
+	}
+	if err := s.sealer.SealChat(&ex); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exchanges = append(s.exchanges, ex)
+	if err := saveJSONFile(s.filePath, s.exchanges); err != nil {
+		s.exchanges = s.exchanges[:len(s.exchanges)-1]
+		return err
+	}
+	return nil
+}
+
+// List returns exchanges oldest first.
+func (s *FileChatStore) List() []model.ChatExchange {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]model.ChatExchange(nil), s.exchanges...)
+}
+
+func (s *FileChatStore) DeleteWhere(match func(model.ChatExchange) bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := make([]model.ChatExchange, 0, len(s.exchanges))
+	for _, ex := range s.exchanges {
+		if !match(ex) {
+			kept = append(kept, ex)
+		}
+	}
+	removed := len(s.exchanges) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+	if err := saveJSONFile(s.filePath, kept); err != nil {
+		return 0, err
+	}
+	s.exchanges = kept
+	return removed, nil
+}
+
+// Reseal encrypts plaintext exchanges and re-encrypts those sealed with a
+// rotated-out key. It returns the number of exchanges changed.
+func (s *FileChatStore) Reseal() (int, error) {
+	if !s.sealer.Enabled() {
+		return 0, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	updated := append([]model.ChatExchange(nil), s.exchanges...)
+	changed := 0
+	for i := range updated {
+		ex := &updated[i]
+		if ex.Sealed != nil && !s.sealer.NeedsRotation(ex.Sealed) {
+			continue
+		}
+		if err := s.sealer.OpenChat(ex); err != nil {
+			return 0, err
+		}
+		if err := s.sealer.SealChat(ex); err != nil {
+			return 0, err
+		}
+		changed++
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+	if err := saveJSONFile(s.filePath, updated); err != nil {
+		return 0, err
+	}
+	s.exchanges = updated
+	return changed, nil
+}
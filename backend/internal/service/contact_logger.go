@@ -3,6 +3,8 @@ package service
 import (
 	"fmt"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"portfolio-backend/internal/model"
@@ -16,26 +18,102 @@ type ContactLogger interface {
 // FileContactLogger appends contact entries to a file.
 type FileContactLogger struct {
 	filePath string
+	sealer   *RecordSealer
+	mu       sync.Mutex
 }
 
 // NewFileContactLogger creates a ContactLogger that writes to the given file path.
-func NewFileContactLogger(path string) *FileContactLogger {
-	return &FileContactLogger{filePath: path}
+// When sealer is enabled, the entry body is encrypted and only the timestamp
+// and email index stay readable.
+func NewFileContactLogger(path string, sealer *RecordSealer) *FileContactLogger {
+	return &FileContactLogger{filePath: path, sealer: sealer}
 }
 
+// Locker pauses appends while held, so the file can be rewritten in place.
+func (l *FileContactLogger) Locker() sync.Locker { return &l.mu }
+
 func (l *FileContactLogger) Log(req model.ContactRequest) error {
+	body, err := l.sealer.SealLine(req.Email, fmt.Sprintf("%s <%s> - %s: %s",
+		req.Name, req.Email, req.Subject, req.Message))
+	if err != nil {
+		return fmt.Errorf("seal log entry: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
 	f, err := os.OpenFile(l.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		return fmt.Errorf("open log file: %w", err)
 	}
 	defer f.Close()
 
-	entry := fmt.Sprintf("[%s] %s <%s> - %s: %s\n",
-		time.Now().Format(time.RFC3339),
-		req.Name, req.Email, req.Subject, req.Message)
+	entry := fmt.Sprintf("[%s] %s\n", time.Now().Format(time.RFC3339), body)
 
 	if _, err := f.WriteString(entry); err != nil {
 		return fmt.Errorf("write log entry: %w", err)
 	}
 	return nil
 }
+
+// Reseal encrypts plaintext entries and re-encrypts entries sealed with a
+// rotated-out key. It returns the number of lines rewritten.
+func (l *FileContactLogger) Reseal() (int, error) {
+	if !l.sealer.Enabled() {
+		return 0, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	data, err := os.ReadFile(l.filePath)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read log file: %w", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	changed := 0
+	for i, line := range lines {
+		prefix, body, ok := strings.Cut(line, "] ")
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(body, sealedLinePrefix) && !l.lineNeedsRotation(body) {
+			continue
+		}
+		plain, err := l.sealer.OpenLine(body)
+		if err != nil {
+			return 0, fmt.Errorf("open log line %d: %w", i+1, err)
+		}
+		sealed, err := l.sealer.SealLine(lineEmail(plain), plain)
+		if err != nil {
+			return 0, fmt.Errorf("seal log line %d: %w", i+1, err)
+		}
+		lines[i] = prefix + "] " + sealed
+		changed++
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+	if err := writeFileAtomic(l.filePath, []byte(strings.Join(lines, "\n")+"\n")); err != nil {
+		return 0, err
+	}
+	return changed, nil
+}
+
+func (l *FileContactLogger) lineNeedsRotation(body string) bool {
+	env, ok := parseSealedLine(body)
+	return !ok || l.sealer.NeedsRotation(env)
+}
+
+// lineEmail extracts the address from a "Name <email> - …" entry.
+func lineEmail(entry string) string {
+	start := strings.IndexByte(entry, '<')
+	end := strings.IndexByte(entry, '>')
+	if start < 0 || end < start {
+		return ""
+	}
+	return entry[start+1 : end]
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"portfolio-backend/internal/model"
+)
+
+// ContactStore persists contact submissions and their reply threads.
+type ContactStore interface {
+	// Create stores a new submission; geo is nil when the origin is unknown.
+	Create(req model.ContactRequest, geo *model.GeoLocation) (model.ContactRecord, error)
+	Get(id string) (model.ContactRecord, bool)
+	List() []model.ContactRecord
+	AppendMessage(id string, msg model.ThreadMessage) (model.ContactRecord, error)
+	FindByMessageID(messageID string) (model.ContactRecord, bool)
+	Update(rec model.ContactRecord) error
+	// SetLead records the lead score of a contact without touching the
+	// rest of the record, so it cannot undo concurrent changes.
+	SetLead(id string, lead model.LeadScore) error
+	Delete(id string) error
+}
+
+// FileContactStore keeps contact records in memory and mirrors them to a JSON file.
+type FileContactStore struct {
+	filePath  string
+	msgDomain string
+	sealer    *RecordSealer
+
+	mu      sync.RWMutex
+	records map[string]*model.ContactRecord
+}
+
+// NewFileContactStore loads existing records from path. msgDomain is used as
+// the right-hand side of generated Message-IDs. Records are sealed with
+// sealer before they are kept; callers must open them to read content.
+func NewFileContactStore(path, msgDomain string, sealer *RecordSealer) (*FileContactStore, error) {
+	var records []*model.ContactRecord
+	if err := loadJSONFile(path, &records); err != nil {
+		return nil, err
+	}
+	s := &FileContactStore{
+		filePath:  path,
+		msgDomain: msgDomain,
+		sealer:    sealer,
+		records:   make(map[string]*model.ContactRecord, len(records)),
+	}
+	for _, rec := range records {
+		s.records[rec.ID] = rec
+	}
+	return s, nil
+}
+
+func (s *FileContactStore) Create(req model.ContactRequest, geo *model.GeoLocation) (model.ContactRecord, error) {
+	id := newID()
+	rec := &model.ContactRecord{
+		ID:        id,
+		CreatedAt: time.Now(),
+		MessageID: NewMessageID("contact."+id, s.msgDomain),
+		Contact:   req,
+		Geo:       geo,
+	}
+	if err := s.sealer.SealContact(rec); err != nil {
+		return model.ContactRecord{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[id] = rec
+	if err := s.persist(); err != nil {
+		delete(s.records, id)
+		return model.ContactRecord{}, err
+	}
+	return *rec, nil
+}
+
+func (s *FileContactStore) Get(id string) (model.ContactRecord, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rec, ok := s.records[id]
+	if !ok {
+		return model.ContactRecord{}, false
+	}
+	return copyRecord(rec), true
+}
+
+// List returns all records, newest first.
+func (s *FileContactStore) List() []model.ContactRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sortedLocked()
+}
+
+func (s *FileContactStore) AppendMessage(id string, msg model.ThreadMessage) (model.ContactRecord, error) {
+	if err := s.sealer.SealMessage(&msg); err != nil {
+		return model.ContactRecord{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[id]
+	if !ok {
+		return model.ContactRecord{}, fmt.Errorf("contact %s not found", id)
+	}
+	rec.Thread = append(rec.Thread, msg)
+	if err := s.persist(); err != nil {
+		rec.Thread = rec.Thread[:len(rec.Thread)-1]
+		return model.ContactRecord{}, err
+	}
+	return copyRecord(rec), nil
+}
+
+// Update replaces a stored record.
+func (s *FileContactStore) Update(rec model.ContactRecord) error {
+	rec = copyRecord(&rec)
+	if err := s.sealer.SealContact(&rec); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.records[rec.ID]
+	if !ok {
+		return fmt.Errorf("contact %s not found", rec.ID)
+	}
+	s.records[rec.ID] = &rec
+	if err := s.persist(); err != nil {
+		s.records[rec.ID] = old
+		return err
+	}
+	return nil
+}
+
+func (s *FileContactStore) SetLead(id string, lead model.LeadScore) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[id]
+	if !ok {
+		return fmt.Errorf("contact %s not found", id)
+	}
+	old := rec.Lead
+	rec.Lead = &lead
+	if err := s.persist(); err != nil {
+		rec.Lead = old
+		return err
+	}
+	return nil
+}
+
+func (s *FileContactStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.records[id]
+	if !ok {
+		return nil
+	}
+	delete(s.records, id)
+	if err := s.persist(); err != nil {
+		s.records[id] = old
+		return err
+	}
+	return nil
+}
+
+// FindByMessageID returns the record whose root or any thread message has the given Message-ID.
+func (s *FileContactStore) FindByMessageID(messageID string) (model.ContactRecord, bool) {
+	messageID = NormalizeMessageID(messageID)
+	if messageID == "" {
+		return model.ContactRecord{}, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, rec := range s.records {
+		if rec.MessageID == messageID {
+			return copyRecord(rec), true
+		}
+		for _, m := range rec.Thread {
+			if m.MessageID == messageID {
+				return copyRecord(rec), true
+			}
+		}
+	}
+	return model.ContactRecord{}, false
+}
+
+// Reseal encrypts plaintext records and re-encrypts records sealed with a
+// rotated-out key under the active one. It returns the number of records changed.
+func (s *FileContactStore) Reseal() (int, error) {
+	if !s.sealer.Enabled() {
+		return 0, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := 0
+	var resealErr error
+	for id, rec := range s.records {
+		if !s.needsReseal(rec) {
+			continue
+		}
+		c := copyRecord(rec)
+		if resealErr = s.sealer.OpenContact(&c); resealErr != nil {
+			break
+		}
+		if resealErr = s.sealer.SealContact(&c); resealErr != nil {
+			break
+		}
+		s.records[id] = &c
+		changed++
+	}
+	// Keep whatever was re-sealed before a failure.
+	if changed > 0 {
+		if err := s.persist(); err != nil {
+			return 0, err
+		}
+	}
+	return changed, resealErr
+}
+
+func (s *FileContactStore) needsReseal(rec *model.ContactRecord) bool {
+	if (rec.Sealed == nil && !rec.Anonymized) || s.sealer.NeedsRotation(rec.Sealed) {
+		return true
+	}
+	for _, m := range rec.Thread {
+		if m.Sealed == nil || s.sealer.NeedsRotation(m.Sealed) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *FileContactStore) sortedLocked() []model.ContactRecord {
+	out := make([]model.ContactRecord, 0, len(s.records))
+	for _, rec := range s.records {
+		out = append(out, copyRecord(rec))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+// persist must be called with mu held.
+func (s *FileContactStore) persist() error {
+	return saveJSONFile(s.filePath, s.sortedLocked())
+}
+
+func copyRecord(rec *model.ContactRecord) model.ContactRecord {
+	c := *rec
+	c.Thread = append([]model.ThreadMessage(nil), rec.Thread...)
+	return c
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/model"
+	"portfolio-backend/internal/schedule"
+)
+
+// Digest periods.
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// topQuestions is how many chat questions a digest lists.
+const topQuestions = 5
+
+// DigestService builds periodic activity summaries from the stores and
+// mails them to the site owner. In dry-run mode digests are written to out
+// instead of being sent.
+//
+// Digests list contacts by ID and lead fields only. The one exception to
+// keeping personal data out of background jobs is chat: exchanges are
+// decrypted in Build to count the most asked questions, and only those
+// (truncated) questions leave it. Nothing decrypted is logged.
+type DigestService struct {
+	contacts ContactStore
+	chat     ChatStore
+	outbox   Outbox
+	visitors *VisitorStats
+	email    EmailService
+	sealer   *RecordSealer
+	toEmail  string
+
+	dryRun bool
+	out    io.Writer
+}
+
+// NewDigestService creates a digest service.
+func NewDigestService(
+	contacts ContactStore,
+	chat ChatStore,
+	outbox Outbox,
+	visitors *VisitorStats,
+	email EmailService,
+	sealer *RecordSealer,
+	toEmail string,
+	dryRun bool,
+	out io.Writer,
+) *DigestService {
+	return &DigestService{
+		contacts: contacts,
+		chat:     chat,
+		outbox:   outbox,
+		visitors: visitors,
+		email:    email,
+		sealer:   sealer,
+		toEmail:  toEmail,
+		dryRun:   dryRun,
+		out:      out,
+	}
+}
+
+// Build collects activity in [from, to).
+func (d *DigestService) Build(period string, from, to time.Time) model.Digest {
+	dg := model.Digest{
+		Period:   period,
+		From:     from,
+		To:       to,
+		Visitors: d.visitors.Summary(from),
+	}
+	inWindow := func(t time.Time) bool { return !t.Before(from) && t.Before(to) }
+
+	for _, rec := range d.contacts.List() {
+		if !inWindow(rec.CreatedAt) || rec.Anonymized {
+			continue
+		}
+		c := model.DigestContact{ID: rec.ID, CreatedAt: rec.CreatedAt}
+		if rec.Lead != nil {
+			c.Category, c.Priority = rec.Lead.Category, rec.Lead.Priority
+		}
+		dg.Contacts = append(dg.Contacts, c)
+	}
+
+	counts := map[string]*model.QuestionCount{}
+	for _, ex := range d.chat.List() {
+		if !inWindow(ex.CreatedAt) {
+			continue
+		}
+		if err := d.sealer.OpenChat(&ex); err != nil {
+			slog.Error("[digest] Failed to open chat exchange", "error", err, "id", ex.ID)
+			continue
+		}
+		dg.ChatMessages++
+		key := normalizeQuestion(ex.Message)
+		if key == "" {
+			continue
+		}
+		if q, ok := counts[key]; ok {
+			q.Count++
+		} else {
+			counts[key] = &model.QuestionCount{Question: truncate(strings.TrimSpace(ex.Message), 160), Count: 1}
+		}
+	}
+	for _, q := range counts {
+		dg.TopQuestions = append(dg.TopQuestions, *q)
+	}
+	sort.Slice(dg.TopQuestions, func(i, j int) bool {
+		a, b := dg.TopQuestions[i], dg.TopQuestions[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Question < b.Question
+	})
+	if len(dg.TopQuestions) > topQuestions {
+		dg.TopQuestions = dg.TopQuestions[:topQuestions]
+	}
+
+	for _, e := range d.outbox.List() {
+		if e.Status == model.OutboxFailed && inWindow(e.CreatedAt) {
+			dg.FailedEmails = append(dg.FailedEmails, e)
+		}
+	}
+	return dg
+}
+
+// Send mails dg to the owner, or prints it in dry-run mode.
+func (d *DigestService) Send(ctx context.Context, dg model.Digest) error {
+	subject := digestSubject(dg)
+	if d.dryRun {
+		_, err := fmt.Fprintf(d.out, "=== %s ===\n%s\n", subject, renderDigestText(dg))
+		slog.Info("[digest] Dry run: digest printed", "period", dg.Period)
+		return err
+	}
+	err := d.email.Deliver(ctx, model.Email{
+		To:      []string{d.toEmail},
+		Subject: subject,
+		Text:    renderDigestText(dg),
+		HTML:    renderDigestHTML(dg),
+	})
+	if err != nil {
+		return fmt.Errorf("send digest: %w", err)
+	}
+	slog.Info("[digest] Digest sent", "period", dg.Period, "contacts", len(dg.Contacts))
+	return nil
+}
+
+// Run sends a digest covering the preceding window every time sched fires,
+// until ctx is done.
+func (d *DigestService) Run(ctx context.Context, period string, sched *schedule.Schedule, window time.Duration) {
+	slog.Info("[digest] Scheduled", "period", period, "schedule", sched.String())
+
+	for {
+		next := sched.Next(time.Now())
+		if next.IsZero() {
+			slog.Warn("[digest] Schedule never fires", "period", period, "schedule", sched.String())
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		to := time.Now()
+		if err := d.Send(ctx, d.Build(period, to.Add(-window), to)); err != nil {
+			slog.Error("[digest] Digest failed", "period", period, "error", err)
+		}
+	}
+}
+
+// normalizeQuestion folds case, whitespace and trailing punctuation so
+// repeated questions are counted together.
+func normalizeQuestion(s string) string {
+	s = strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	return strings.TrimRight(s, "?!. ")
+}
+
+func digestSubject(dg model.Digest) string {
+	period := "Daily"
+	if dg.Period == DigestWeekly {
+		period = "Weekly"
+	}
+	return fmt.Sprintf("Portfolio %s Digest: %d new contacts", period, len(dg.Contacts))
+}
+
+func renderDigestText(dg model.Digest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Activity from %s to %s\n\n",
+		dg.From.Format("2006-01-02 15:04"), dg.To.Format("2006-01-02 15:04 MST"))
+
+	fmt.Fprintf(&b, "Visitors: %d sessions, peak %d concurrent", dg.Visitors.Sessions, dg.Visitors.Peak)
+	if !dg.Visitors.PeakAt.IsZero() {
+		fmt.Fprintf(&b, " (%s)", dg.Visitors.PeakAt.Format("Jan 2 15:04"))
+	}
+	fmt.Fprintf(&b, ", %d online now\n\n", dg.Visitors.Current)
+
+	fmt.Fprintf(&b, "New contacts (%d):\n", len(dg.Contacts))
+	for _, c := range dg.Contacts {
+		fmt.Fprintf(&b, "  - %s %s", c.CreatedAt.Format("Jan 2 15:04"), c.ID)
+		if c.Category != "" {
+			fmt.Fprintf(&b, " [%s, %s]", c.Category, c.Priority)
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "\nChat: %d messages\n", dg.ChatMessages)
+	for _, q := range dg.TopQuestions {
+		fmt.Fprintf(&b, "  %dx %s\n", q.Count, q.Question)
+	}
+
+	fmt.Fprintf(&b, "\nFailed email deliveries (%d):\n", len(dg.FailedEmails))
+	for _, e := range dg.FailedEmails {
+		fmt.Fprintf(&b, "  - %s %q to %s: %s\n",
+			e.CreatedAt.Format("Jan 2 15:04"), e.Subject, strings.Join(e.To, ", "), e.Error)
+	}
+	return b.String()
+}
+
+func renderDigestHTML(dg model.Digest) string {
+	esc := html.EscapeString
+	var b strings.Builder
+	fmt.Fprintf(&b, "<h2>%s</h2><p>%s &ndash; %s</p>", esc(digestSubject(dg)),
+		dg.From.Format("Jan 2 15:04"), dg.To.Format("Jan 2 15:04 MST"))
+
+	fmt.Fprintf(&b, "<h3>Visitors</h3><p>%d sessions, peak <strong>%d</strong> concurrent, %d online now</p>",
+		dg.Visitors.Sessions, dg.Visitors.Peak, dg.Visitors.Current)
+
+	fmt.Fprintf(&b, "<h3>New contacts (%d)</h3>", len(dg.Contacts))
+	if len(dg.Contacts) > 0 {
+		b.WriteString("<ul>")
+		for _, c := range dg.Contacts {
+			fmt.Fprintf(&b, "<li>%s <strong>%s</strong>", c.CreatedAt.Format("Jan 2 15:04"), esc(c.ID))
+			if c.Category != "" {
+				fmt.Fprintf(&b, " <em>(%s, %s)</em>", esc(c.Category), esc(c.Priority))
+			}
+			b.WriteString("</li>")
+		}
+		b.WriteString("</ul>")
+	}
+
+	fmt.Fprintf(&b, "<h3>Chat (%d messages)</h3>", dg.ChatMessages)
+	if len(dg.TopQuestions) > 0 {
+		b.WriteString("<ol>")
+		for _, q := range dg.TopQuestions {
+			fmt.Fprintf(&b, "<li>%s &times;%d</li>", esc(q.Question), q.Count)
+		}
+		b.WriteString("</ol>")
+	}
+
+	fmt.Fprintf(&b, "<h3>Failed email deliveries (%d)</h3>", len(dg.FailedEmails))
+	if len(dg.FailedEmails) > 0 {
+		b.WriteString("<ul>")
+		for _, e := range dg.FailedEmails {
+			fmt.Fprintf(&b, "<li>%s &ldquo;%s&rdquo; to %s: %s</li>", e.CreatedAt.Format("Jan 2 15:04"),
+				esc(e.Subject), esc(strings.Join(e.To, ", ")), esc(e.Error))
+		}
+		b.WriteString("</ul>")
+	}
+	return b.String()
+}
@@ -2,8 +2,11 @@ package service
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"html"
 	"io"
 	"net/http"
 	"strings"
@@ -14,41 +17,39 @@ import (
 	"portfolio-backend/internal/model"
 )
 
-// EmailService sends notification emails for contact form submissions.
+// EmailService sends notification emails for contact form submissions
+// and arbitrary outbound messages such as replies.
 type EmailService interface {
-	Send(req model.ContactRequest) error
+	Send(ctx context.Context, req model.ContactRequest) error
+	Deliver(ctx context.Context, msg model.Email) error
 }
 
 // ResendEmailService delivers emails through the Resend HTTP API.
 type ResendEmailService struct {
-	apiKey  string
-	toEmail string
-	client  *http.Client
+	apiKey    string
+	fromEmail string
+	toEmail   string
+	client    *http.Client
 }
 
 // NewResendEmailService creates an EmailService backed by Resend.
-// If apiKey is empty, Send becomes a no-op.
-func NewResendEmailService(apiKey, toEmail string) *ResendEmailService {
+// If apiKey is empty, Send and Deliver become no-ops.
+func NewResendEmailService(apiKey, fromEmail, toEmail string) *ResendEmailService {
 	if apiKey == "" {
 		slog.Warn("[email] Resend API key not configured; emails will be skipped")
 	} else {
-		slog.Info("[email] Resend email service initialized", "toEmail", toEmail)
+		slog.Info("[email] Resend email service initialized", "fromEmail", fromEmail, "toEmail", toEmail)
 	}
 	return &ResendEmailService{
-		apiKey:  apiKey,
-		toEmail: toEmail,
-		client:  &http.Client{Timeout: 10 * time.Second},
+		apiKey:    apiKey,
+		fromEmail: fromEmail,
+		toEmail:   toEmail,
+		client:    &http.Client{Timeout: 10 * time.Second},
 	}
 }
 
-func (s *ResendEmailService) Send(req model.ContactRequest) error {
-	if s.apiKey == "" {
-		slog.Notice("[email] Skipped: no API key")
-		return nil
-	}
-
-	subject := fmt.Sprintf("Portfolio Contact: %s", req.Subject)
-	html := fmt.Sprintf(
+func (s *ResendEmailService) Send(ctx context.Context, req model.ContactRequest) error {
+	body := fmt.Sprintf(
 		"<h2>New Contact from Portfolio</h2>"+
 			"<p><strong>Name:</strong> %s</p>"+
 			"<p><strong>Email:</strong> %s</p>"+
@@ -56,23 +57,70 @@ func (s *ResendEmailService) Send(req model.ContactRequest) error {
 			"<hr>"+
 			"<p><strong>Message:</strong></p>"+
 			"<p>%s</p>",
-		req.Name, req.Email, req.Subject,
-		strings.ReplaceAll(req.Message, "\n", "<br>"))
-
-	payload, err := json.Marshal(map[string]any{
-		"from":     "Portfolio <onboarding@resend.dev>",
-		"to":       []string{s.toEmail},
-		"reply_to": req.Email,
-		"subject":  subject,
-		"html":     html,
+		html.EscapeString(req.Name), html.EscapeString(req.Email), html.EscapeString(req.Subject),
+		strings.ReplaceAll(html.EscapeString(req.Message), "\n", "<br>"))
+
+	if len(req.Attachments) > 0 {
+		body += "<hr><p><strong>Attachments:</strong></p><ul>"
+		for _, a := range req.Attachments {
+			body += fmt.Sprintf("<li>%s (%s, %d KB)</li>",
+				html.EscapeString(a.Filename), html.EscapeString(a.ContentType), (a.Size+1023)/1024)
+		}
+		body += "</ul>"
+	}
+
+	return s.Deliver(ctx, model.Email{
+		To:          []string{s.toEmail},
+		ReplyTo:     req.Email,
+		Subject:     fmt.Sprintf("Portfolio Contact: %s", req.Subject),
+		HTML:        body,
+		Attachments: req.Attachments,
 	})
+}
+
+func (s *ResendEmailService) Deliver(ctx context.Context, msg model.Email) error {
+	if s.apiKey == "" {
+		slog.Notice("[email] Skipped: no API key", "subject", msg.Subject)
+		return nil
+	}
+
+	body := map[string]any{
+		"from":    s.fromEmail,
+		"to":      msg.To,
+		"subject": msg.Subject,
+	}
+	if msg.ReplyTo != "" {
+		body["reply_to"] = msg.ReplyTo
+	}
+	if msg.HTML != "" {
+		body["html"] = msg.HTML
+	}
+	if msg.Text != "" {
+		body["text"] = msg.Text
+	}
+	if len(msg.Headers) > 0 {
+		body["headers"] = msg.Headers
+	}
+	if len(msg.Attachments) > 0 {
+		var atts []map[string]string
+		for _, a := range msg.Attachments {
+			atts = append(atts, map[string]string{
+				"filename":     a.Filename,
+				"content":      base64.StdEncoding.EncodeToString(a.Content),
+				"content_type": a.ContentType,
+			})
+		}
+		body["attachments"] = atts
+	}
+
+	payload, err := json.Marshal(body)
 	if err != nil {
 		return fmt.Errorf("marshal payload: %w", err)
 	}
 
-	slog.Debug("[email] Sending via Resend", "to", s.toEmail, "subject", subject)
+	slog.Debug("[email] Sending via Resend", "to", msg.To, "subject", msg.Subject)
 
-	httpReq, err := http.NewRequest(http.MethodPost, "https://api.resend.com/emails", bytes.NewReader(payload))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.resend.com/emails", bytes.NewReader(payload))
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
@@ -86,14 +134,14 @@ func (s *ResendEmailService) Send(req model.ContactRequest) error {
 	}
 	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
+	respBody, _ := io.ReadAll(resp.Body)
 	slog.WithData(slog.M{
 		"status": resp.StatusCode,
-		"body":   string(body),
+		"body":   string(respBody),
 	}).Info("[email] Resend response")
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("resend returned %d: %s", resp.StatusCode, body)
+		return fmt.Errorf("resend returned %d: %s", resp.StatusCode, respBody)
 	}
 	return nil
 }
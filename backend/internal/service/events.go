@@ -0,0 +1,199 @@
+package service
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"portfolio-backend/internal/model"
+)
+
+// Admin feed event types.
+const (
+	EventVisitorConnect    = "visitor.connect"
+	EventVisitorView       = "visitor.view"
+	EventVisitorDisconnect = "visitor.disconnect"
+	EventVisitorBot        = "visitor.bot"
+	EventChatMessage       = "chat.message"
+	EventContactNew        = "contact.new"
+	EventEmailSent         = "email.sent"
+	EventEmailFailed       = "email.failed"
+	EventProviderError     = "provider.error"
+	EventGuestbookNew      = "guestbook.new"
+)
+
+// EventPublisher receives operational events for the admin live feed.
+// Publish must not block.
+type EventPublisher interface {
+	Publish(eventType string, data any)
+}
+
+// subscriptionBuffer is how many events may queue for one subscriber before
+// it is dropped as too slow.
+const subscriptionBuffer = 64
+
+// EventBus fans events out to admin feed subscribers and keeps the most
+// recent ones in a ring buffer for backfill.
+type EventBus struct {
+	mu   sync.Mutex
+	ring []model.AdminEvent
+	next int // ring index of the next event
+	seq  uint64
+	subs map[*EventSubscription]bool
+}
+
+// NewEventBus creates a bus that remembers the last size events.
+func NewEventBus(size int) *EventBus {
+	if size < 1 {
+		size = 1
+	}
+	return &EventBus{ring: make([]model.AdminEvent, 0, size), subs: map[*EventSubscription]bool{}}
+}
+
+// Publish records an event and queues it for every matching subscriber.
+// Subscribers whose queue is full are closed.
+func (b *EventBus) Publish(eventType string, data any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	ev := model.AdminEvent{Seq: b.seq, Type: eventType, Time: time.Now(), Country: eventCountry(data), Data: data}
+	if len(b.ring) < cap(b.ring) {
+		b.ring = append(b.ring, ev)
+	} else {
+		b.ring[b.next] = ev
+	}
+	b.next = (b.next + 1) % cap(b.ring)
+
+	for s := range b.subs {
+		if !s.filter.matches(ev) {
+			continue
+		}
+		select {
+		case s.events <- ev:
+		default:
+			b.closeLocked(s)
+		}
+	}
+}
+
+// Subscribe starts a subscription and returns it together with buffered
+// events that match filter: those after seq since when since is non-zero,
+// otherwise the last backfill.
+func (b *EventBus) Subscribe(filter model.AdminEventFilter, backfill int, since uint64) (*EventSubscription, []model.AdminEvent) {
+	s := &EventSubscription{bus: b, events: make(chan model.AdminEvent, subscriptionBuffer), filter: compileFilter(filter)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var past []model.AdminEvent
+	// Oldest first: the ring starts at next once it has wrapped.
+	n, start := len(b.ring), 0
+	if n == cap(b.ring) {
+		start = b.next
+	}
+	for i := 0; i < n; i++ {
+		ev := b.ring[(start+i)%n]
+		if (since == 0 || ev.Seq > since) && s.filter.matches(ev) {
+			past = append(past, ev)
+		}
+	}
+	if since == 0 && len(past) > backfill {
+		past = past[len(past)-backfill:]
+	}
+	b.subs[s] = true
+	return s, past
+}
+
+func (b *EventBus) closeLocked(s *EventSubscription) {
+	if b.subs[s] {
+		delete(b.subs, s)
+		close(s.events)
+	}
+}
+
+// EventSubscription is one admin feed listener.
+type EventSubscription struct {
+	bus    *EventBus
+	events chan model.AdminEvent
+	filter eventFilter // guarded by bus.mu
+}
+
+// Events delivers matching events. It is closed when the subscription ends,
+// including when the subscriber fell too far behind.
+func (s *EventSubscription) Events() <-chan model.AdminEvent { return s.events }
+
+// SetFilter replaces the subscription's filter.
+func (s *EventSubscription) SetFilter(filter model.AdminEventFilter) {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.filter = compileFilter(filter)
+}
+
+// Close ends the subscription.
+func (s *EventSubscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.closeLocked(s)
+}
+
+type eventFilter struct {
+	types     []string
+	countries map[string]bool
+}
+
+func compileFilter(f model.AdminEventFilter) eventFilter {
+	var c eventFilter
+	for _, t := range f.Types {
+		if t = strings.TrimSpace(t); t != "" {
+			c.types = append(c.types, t)
+		}
+	}
+	for _, cc := range f.Countries {
+		if cc = strings.ToUpper(strings.TrimSpace(cc)); cc != "" {
+			if c.countries == nil {
+				c.countries = map[string]bool{}
+			}
+			c.countries[cc] = true
+		}
+	}
+	return c
+}
+
+func (f eventFilter) matches(ev model.AdminEvent) bool {
+	if len(f.types) > 0 {
+		ok := false
+		for _, t := range f.types {
+			if ev.Type == t || strings.HasPrefix(ev.Type, t+".") {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if f.countries == nil || !locatedEvent(ev.Type) {
+		return true
+	}
+	return f.countries[ev.Country]
+}
+
+// locatedEvent reports whether events of type t carry a location, and so
+// are subject to country filters.
+func locatedEvent(t string) bool {
+	return strings.HasPrefix(t, "visitor.") || t == EventContactNew
+}
+
+// eventCountry is the country an event's data is located in, if any.
+func eventCountry(data any) string {
+	var geo *model.GeoLocation
+	switch d := data.(type) {
+	case model.VisitorEvent:
+		geo = d.Geo
+	case model.ContactEvent:
+		geo = d.Geo
+	}
+	if geo == nil {
+		return ""
+	}
+	return geo.Country
+}
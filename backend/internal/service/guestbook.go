@@ -0,0 +1,143 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/model"
+)
+
+// Guestbook errors.
+var (
+	ErrGuestbookNotFound    = errors.New("guestbook entry not found")
+	ErrGuestbookRateLimited = errors.New("too many guestbook entries")
+	ErrGuestbookDuplicate   = errors.New("duplicate guestbook entry")
+)
+
+// guestbookSpamThreshold is how many spam signals send an entry to the spam
+// folder instead of the moderation queue.
+const guestbookSpamThreshold = 2
+
+// GuestbookService accepts guestbook entries, screens them for spam and
+// holds them for moderation before they are shown publicly.
+type GuestbookService struct {
+	store  GuestbookStore
+	events EventPublisher
+	limit  *hourlyLimit // by client IP
+}
+
+// NewGuestbookService creates the service. Each client IP may submit
+// perHour entries an hour; zero disables the limit.
+func NewGuestbookService(store GuestbookStore, events EventPublisher, perHour int) *GuestbookService {
+	return &GuestbookService{store: store, events: events, limit: newHourlyLimit(perHour)}
+}
+
+// Submit screens a validated request and stores it for moderation. Honeypot
+// hits are dropped without error, so bots cannot tell they were caught; the
+// returned entry then has no ID.
+func (g *GuestbookService) Submit(req model.GuestbookRequest, ip string, loc model.GeoLocation) (model.GuestbookEntry, error) {
+	if !g.limit.allow(ip, time.Now()) {
+		return model.GuestbookEntry{}, ErrGuestbookRateLimited
+	}
+	if req.Nickname != "" {
+		slog.Info("[guestbook] Dropped honeypot submission", "name", req.Name)
+		return model.GuestbookEntry{}, nil
+	}
+	for _, e := range g.store.List("") {
+		if strings.EqualFold(e.Message, req.Message) {
+			return model.GuestbookEntry{}, ErrGuestbookDuplicate
+		}
+	}
+
+	e := model.GuestbookEntry{
+		CreatedAt:   time.Now(),
+		Name:        req.Name,
+		Message:     req.Message,
+		Website:     req.Website,
+		Country:     loc.Country,
+		Status:      model.GuestbookPending,
+		SpamSignals: guestbookSpamSignals(req),
+	}
+	if len(e.SpamSignals) >= guestbookSpamThreshold {
+		e.Status = model.GuestbookSpam
+	}
+	e, err := g.store.Create(e)
+	if err != nil {
+		return model.GuestbookEntry{}, err
+	}
+	slog.Info("[guestbook] New entry", "id", e.ID, "name", e.Name, "status", e.Status)
+	g.events.Publish(EventGuestbookNew, e)
+	return e, nil
+}
+
+// Page returns approved entries, newest first; page counts from 1.
+func (g *GuestbookService) Page(page, perPage int) model.GuestbookPage {
+	approved := g.store.List(model.GuestbookApproved)
+	p := model.GuestbookPage{Entries: []model.GuestbookEntry{}, Page: page, PerPage: perPage, Total: len(approved)}
+	start := (page - 1) * perPage
+	if start >= len(approved) {
+		return p
+	}
+	end := min(start+perPage, len(approved))
+	for _, e := range approved[start:end] {
+		p.Entries = append(p.Entries, publicGuestbookEntry(e))
+	}
+	return p
+}
+
+// List returns entries with status, or every entry when status is empty,
+// newest first.
+func (g *GuestbookService) List(status string) []model.GuestbookEntry {
+	return g.store.List(status)
+}
+
+// Moderate approves or rejects an entry. The returned entry is the public
+// form of it.
+func (g *GuestbookService) Moderate(id, status string) (model.GuestbookEntry, error) {
+	if status != model.GuestbookApproved && status != model.GuestbookRejected {
+		return model.GuestbookEntry{}, fmt.Errorf("invalid guestbook status %q", status)
+	}
+	e, ok := g.store.Get(id)
+	if !ok {
+		return model.GuestbookEntry{}, ErrGuestbookNotFound
+	}
+	now := time.Now()
+	e.Status, e.ModeratedAt = status, &now
+	if err := g.store.Update(e); err != nil {
+		return model.GuestbookEntry{}, err
+	}
+	slog.Info("[guestbook] Entry moderated", "id", id, "status", status)
+	return publicGuestbookEntry(e), nil
+}
+
+// guestbookSpamSignals applies the lead scorer's spam rules, with a stricter
+// link limit since guestbook entries are published.
+func guestbookSpamSignals(req model.GuestbookRequest) []string {
+	var signals []string
+	text := strings.ToLower(req.Name + " " + req.Message)
+	for _, w := range spamWords {
+		if strings.Contains(text, w) {
+			signals = append(signals, "spam word: "+w)
+		}
+	}
+	if links := len(urlPattern.FindAllString(req.Message, -1)); links > 0 {
+		signals = append(signals, fmt.Sprintf("%d links in message", links))
+	}
+	if mostlyUpper(req.Message) {
+		signals = append(signals, "mostly capitals")
+	}
+	if isPlaceholder(req.Message) {
+		signals = append(signals, "placeholder message")
+	}
+	return signals
+}
+
+// publicGuestbookEntry strips moderation details from e.
+func publicGuestbookEntry(e model.GuestbookEntry) model.GuestbookEntry {
+	e.SpamSignals = nil
+	return e
+}
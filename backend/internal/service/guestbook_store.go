@@ -0,0 +1,110 @@
+package service
+
+import (
+	"sort"
+	"sync"
+
+	"portfolio-backend/internal/model"
+)
+
+// GuestbookStore persists guestbook entries.
+type GuestbookStore interface {
+	Create(e model.GuestbookEntry) (model.GuestbookEntry, error)
+	Get(id string) (model.GuestbookEntry, bool)
+	// List returns entries with the given status, or all entries when
+	// status is empty, newest first.
+	List(status string) []model.GuestbookEntry
+	Update(e model.GuestbookEntry) error
+	Delete(id string) error
+}
+
+// FileGuestbookStore keeps guestbook entries in memory and mirrors them to a JSON file.
+type FileGuestbookStore struct {
+	filePath string
+
+	mu      sync.RWMutex
+	entries []model.GuestbookEntry // oldest first
+}
+
+// NewFileGuestbookStore loads existing entries from path.
+func NewFileGuestbookStore(path string) (*FileGuestbookStore, error) {
+	s := &FileGuestbookStore{filePath: path}
+	if err := loadJSONFile(path, &s.entries); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(s.entries, func(i, j int) bool { return s.entries[i].CreatedAt.Before(s.entries[j].CreatedAt) })
+	return s, nil
+}
+
+func (s *FileGuestbookStore) Create(e model.GuestbookEntry) (model.GuestbookEntry, error) {
+	if e.ID == "" {
+		e.ID = newID()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, e)
+	if err := saveJSONFile(s.filePath, s.entries); err != nil {
+		s.entries = s.entries[:len(s.entries)-1]
+		return model.GuestbookEntry{}, err
+	}
+	return e, nil
+}
+
+func (s *FileGuestbookStore) Get(id string) (model.GuestbookEntry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, e := range s.entries {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return model.GuestbookEntry{}, false
+}
+
+func (s *FileGuestbookStore) List(status string) []model.GuestbookEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := []model.GuestbookEntry{}
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		if status == "" || s.entries[i].Status == status {
+			out = append(out, s.entries[i])
+		}
+	}
+	return out
+}
+
+func (s *FileGuestbookStore) Update(e model.GuestbookEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.entries {
+		if s.entries[i].ID != e.ID {
+			continue
+		}
+		prev := s.entries[i]
+		s.entries[i] = e
+		if err := saveJSONFile(s.filePath, s.entries); err != nil {
+			s.entries[i] = prev
+			return err
+		}
+		return nil
+	}
+	return ErrGuestbookNotFound
+}
+
+func (s *FileGuestbookStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.entries {
+		if s.entries[i].ID != id {
+			continue
+		}
+		prev := s.entries
+		s.entries = append(s.entries[:i:i], s.entries[i+1:]...)
+		if err := saveJSONFile(s.filePath, s.entries); err != nil {
+			s.entries = prev
+			return err
+		}
+		return nil
+	}
+	return ErrGuestbookNotFound
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/mail"
+	"net/textproto"
+	"strings"
+
+	"portfolio-backend/internal/model"
+)
+
+const maxInboundSize = 10 << 20
+
+// ParseInboundEmail decodes an inbound-email webhook request. It accepts a raw
+// RFC 5322 message (message/rfc822), a JSON payload in the shape used by
+// common providers (flat fields, Postmark-style header lists, or a {"data": …}
+// envelope), or a form post carrying either a raw "email" field or separate
+// from/to/subject/text/headers fields.
+func ParseInboundEmail(r *http.Request) (model.InboundEmail, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	// Form parsing reads r.Body itself, so the limit must wrap it in place.
+	r.Body = http.MaxBytesReader(nil, r.Body, maxInboundSize)
+	body := r.Body
+
+	switch mediaType {
+	case "message/rfc822", "text/plain":
+		return parseRawEmail(body)
+	case "multipart/form-data", "application/x-www-form-urlencoded":
+		if err := r.ParseMultipartForm(maxInboundSize); err != nil && err != http.ErrNotMultipart {
+			return model.InboundEmail{}, fmt.Errorf("parse form: %w", err)
+		}
+		if raw := r.FormValue("email"); raw != "" {
+			return parseRawEmail(strings.NewReader(raw))
+		}
+		return parseFormEmail(r)
+	default:
+		return parseJSONEmail(body)
+	}
+}
+
+func parseRawEmail(r io.Reader) (model.InboundEmail, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return model.InboundEmail{}, fmt.Errorf("read message: %w", err)
+	}
+	text, err := plainTextBody(textproto.MIMEHeader(msg.Header), msg.Body)
+	if err != nil {
+		return model.InboundEmail{}, fmt.Errorf("read body: %w", err)
+	}
+	return validInbound(inboundFromHeader(msg.Header, text))
+}
+
+func parseFormEmail(r *http.Request) (model.InboundEmail, error) {
+	header := mail.Header{}
+	if raw := r.FormValue("headers"); raw != "" {
+		tp := textproto.NewReader(bufio.NewReader(strings.NewReader(raw + "\r\n\r\n")))
+		if h, err := tp.ReadMIMEHeader(); err == nil {
+			header = mail.Header(h)
+		}
+	}
+	in := inboundFromHeader(header, r.FormValue("text"))
+	overlay(&in.From, r.FormValue("from"))
+	overlay(&in.To, r.FormValue("to"))
+	overlay(&in.Subject, r.FormValue("subject"))
+	return validInbound(in)
+}
+
+func parseJSONEmail(r io.Reader) (model.InboundEmail, error) {
+	var payload map[string]any
+	if err := json.NewDecoder(r).Decode(&payload); err != nil {
+		return model.InboundEmail{}, fmt.Errorf("decode json: %w", err)
+	}
+	if data, ok := payload["data"].(map[string]any); ok {
+		payload = data
+	}
+
+	header := mail.Header{}
+	switch h := lookup(payload, "headers").(type) {
+	case map[string]any:
+		for k, v := range h {
+			if s, ok := v.(string); ok {
+				header[textproto.CanonicalMIMEHeaderKey(k)] = []string{s}
+			}
+		}
+	case []any:
+		for _, item := range h {
+			kv, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			name, _ := lookup(kv, "name").(string)
+			value, _ := lookup(kv, "value").(string)
+			if name != "" {
+				header[textproto.CanonicalMIMEHeaderKey(name)] = []string{value}
+			}
+		}
+	}
+
+	in := inboundFromHeader(header, stringField(payload, "text", "textBody", "plain", "strippedTextReply"))
+	overlay(&in.From, stringField(payload, "from"))
+	overlay(&in.To, stringField(payload, "to"))
+	overlay(&in.Subject, stringField(payload, "subject"))
+	overlay(&in.MessageID, NormalizeMessageID(stringField(payload, "messageId", "message_id")))
+	overlay(&in.InReplyTo, NormalizeMessageID(stringField(payload, "inReplyTo", "in_reply_to")))
+	return validInbound(in)
+}
+
+func inboundFromHeader(h mail.Header, text string) model.InboundEmail {
+	var refs []string
+	for _, ref := range strings.Fields(h.Get("References")) {
+		refs = append(refs, NormalizeMessageID(ref))
+	}
+	dec := new(mime.WordDecoder)
+	subject, err := dec.DecodeHeader(h.Get("Subject"))
+	if err != nil {
+		subject = h.Get("Subject")
+	}
+	return model.InboundEmail{
+		MessageID:  NormalizeMessageID(h.Get("Message-Id")),
+		From:       h.Get("From"),
+		To:         h.Get("To"),
+		Subject:    subject,
+		Text:       strings.TrimSpace(text),
+		InReplyTo:  NormalizeMessageID(h.Get("In-Reply-To")),
+		References: refs,
+	}
+}
+
+// plainTextBody returns the first text/plain part of a (possibly multipart) body.
+func plainTextBody(h textproto.MIMEHeader, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return "", nil
+			}
+			if err != nil {
+				return "", err
+			}
+			text, err := plainTextBody(part.Header, part)
+			if err != nil {
+				return "", err
+			}
+			if text != "" {
+				return text, nil
+			}
+		}
+	}
+
+	if mediaType != "text/plain" {
+		return "", nil
+	}
+
+	switch strings.ToLower(h.Get("Content-Transfer-Encoding")) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+	data, err := io.ReadAll(body)
+	return string(data), err
+}
+
+func validInbound(in model.InboundEmail) (model.InboundEmail, error) {
+	if in.From == "" {
+		return in, fmt.Errorf("missing sender")
+	}
+	return in, nil
+}
+
+// lookup finds a key case-insensitively.
+func lookup(m map[string]any, key string) any {
+	if v, ok := m[key]; ok {
+		return v
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return nil
+}
+
+// stringField returns the first non-empty string among keys. Address objects
+// of the form {"email": …} and lists of addresses are flattened.
+func stringField(m map[string]any, keys ...string) string {
+	for _, key := range keys {
+		switch v := lookup(m, key).(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case map[string]any:
+			if s, _ := lookup(v, "email").(string); s != "" {
+				return s
+			}
+		case []any:
+			var parts []string
+			for _, item := range v {
+				switch a := item.(type) {
+				case string:
+					parts = append(parts, a)
+				case map[string]any:
+					if s, _ := lookup(a, "email").(string); s != "" {
+						parts = append(parts, s)
+					}
+				}
+			}
+			if len(parts) > 0 {
+				return strings.Join(parts, ", ")
+			}
+		}
+	}
+	return ""
+}
+
+func overlay(dst *string, v string) {
+	if v != "" {
+		*dst = v
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"errors"
+	"sync"
+
+	"portfolio-backend/internal/model"
+)
+
+// ErrIssueSendNotFound is returned when updating an unknown issue send.
+var ErrIssueSendNotFound = errors.New("issue send not found")
+
+// IssueStore persists the progress of newsletter sends.
+type IssueStore interface {
+	Create(send model.IssueSend) (model.IssueSend, error)
+	// List returns sends oldest first.
+	List() []model.IssueSend
+	Update(send model.IssueSend) error
+}
+
+// FileIssueStore keeps issue sends in memory and mirrors them to a JSON file.
+type FileIssueStore struct {
+	filePath string
+
+	mu    sync.RWMutex
+	sends []model.IssueSend
+}
+
+// NewFileIssueStore loads existing sends from path.
+func NewFileIssueStore(path string) (*FileIssueStore, error) {
+	s := &FileIssueStore{filePath: path}
+	if err := loadJSONFile(path, &s.sends); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileIssueStore) Create(send model.IssueSend) (model.IssueSend, error) {
+	if send.ID == "" {
+		send.ID = newID()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sends = append(s.sends, send)
+	if err := saveJSONFile(s.filePath, s.sends); err != nil {
+		s.sends = s.sends[:len(s.sends)-1]
+		return model.IssueSend{}, err
+	}
+	return send, nil
+}
+
+func (s *FileIssueStore) List() []model.IssueSend {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]model.IssueSend(nil), s.sends...)
+}
+
+func (s *FileIssueStore) Update(send model.IssueSend) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.sends {
+		if s.sends[i].ID != send.ID {
+			continue
+		}
+		prev := s.sends[i]
+		s.sends[i] = send
+		if err := saveJSONFile(s.filePath, s.sends); err != nil {
+			s.sends[i] = prev
+			return err
+		}
+		return nil
+	}
+	return ErrIssueSendNotFound
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/model"
+)
+
+// freeMailDomains are consumer mailbox providers; leads from other domains
+// are more likely to come from a company.
+var freeMailDomains = map[string]bool{
+	"gmail.com": true, "googlemail.com": true, "yahoo.com": true, "yahoo.co.in": true,
+	"outlook.com": true, "hotmail.com": true, "live.com": true, "msn.com": true,
+	"icloud.com": true, "me.com": true, "aol.com": true, "proton.me": true,
+	"protonmail.com": true, "gmx.com": true, "zoho.com": true, "yandex.com": true,
+	"mail.com": true, "rediffmail.com": true,
+}
+
+type keywordRule struct {
+	category string
+	weight   int
+	words    []string
+}
+
+var leadKeywords = []keywordRule{
+	{model.LeadJobOffer, 30, []string{"hiring", "job", "position", "role", "opening", "recruit", "interview", "full-time", "full time", "salary", "ctc"}},
+	{model.LeadFreelance, 25, []string{"freelance", "contract", "project", "budget", "quote", "hourly", "consulting", "gig"}},
+	{model.LeadCollaboration, 15, []string{"collaborat", "partner", "open source", "open-source", "co-founder", "cofounder", "side project"}},
+}
+
+var spamWords = []string{"seo", "backlink", "casino", "crypto", "forex", "viagra", "loan", "bitcoin", "guest post", "click here", "promotion", "traffic to your"}
+
+var urlPattern = regexp.MustCompile(`https?://`)
+
+// LeadScorer classifies contact submissions with rules and, optionally, an LLM.
+type LeadScorer struct {
+	llm       CompletionProvider
+	highScore int
+}
+
+// NewLeadScorer creates a scorer. llm may be nil to use rules only.
+// Leads scoring at least highScore are high priority.
+func NewLeadScorer(llm CompletionProvider, highScore int) *LeadScorer {
+	return &LeadScorer{llm: llm, highScore: highScore}
+}
+
+// Score classifies req. LLM failures fall back to the rule-based result.
+func (s *LeadScorer) Score(ctx context.Context, req model.ContactRequest) model.LeadScore {
+	score := scoreByRules(req)
+	score.Source = "rules"
+
+	if s.llm != nil {
+		if cat, conf, err := s.classifyLLM(ctx, req); err != nil {
+			if err != ErrNoLLM {
+				slog.Warn("[lead] LLM classification failed; using rules", "error", err)
+			}
+		} else if conf >= 0.6 {
+			if cat != score.Category {
+				score.Reasons = append(score.Reasons, fmt.Sprintf("llm: %s (%.0f%%)", cat, conf*100))
+			}
+			score.Category = cat
+			score.Source = "rules+llm"
+			switch cat {
+			case model.LeadJobOffer, model.LeadFreelance:
+				score.Score += 15
+			case model.LeadSpam:
+				score.Score -= 40
+			}
+		}
+	}
+
+	score.Priority = s.priority(score)
+	return score
+}
+
+func (s *LeadScorer) priority(score model.LeadScore) string {
+	switch {
+	case score.Category == model.LeadSpam:
+		return model.PriorityLow
+	case score.Score >= s.highScore:
+		return model.PriorityHigh
+	case score.Score >= s.highScore/2:
+		return model.PriorityMedium
+	default:
+		return model.PriorityLow
+	}
+}
+
+func scoreByRules(req model.ContactRequest) model.LeadScore {
+	var ls model.LeadScore
+	text := strings.ToLower(req.Subject + " " + req.Message)
+
+	if at := strings.LastIndex(req.Email, "@"); at >= 0 {
+		domain := strings.ToLower(req.Email[at+1:])
+		if freeMailDomains[domain] {
+			ls.Reasons = append(ls.Reasons, "free-mail address")
+		} else {
+			ls.Score += 20
+			ls.Reasons = append(ls.Reasons, "company domain "+domain)
+		}
+	}
+
+	best := 0
+	for _, rule := range leadKeywords {
+		for _, w := range rule.words {
+			if strings.Contains(text, w) {
+				ls.Score += rule.weight
+				ls.Reasons = append(ls.Reasons, fmt.Sprintf("keyword %q", w))
+				if rule.weight > best {
+					best = rule.weight
+					ls.Category = rule.category
+				}
+				break
+			}
+		}
+	}
+
+	msgLen := len([]rune(strings.TrimSpace(req.Message)))
+	switch {
+	case msgLen < 20:
+		ls.Score -= 20
+		ls.Reasons = append(ls.Reasons, "very short message")
+	case msgLen > 200:
+		ls.Score += 10
+		ls.Reasons = append(ls.Reasons, "detailed message")
+	}
+
+	if len(req.Attachments) > 0 {
+		ls.Score += 10
+		ls.Reasons = append(ls.Reasons, "has attachments")
+	}
+
+	spam := 0
+	for _, w := range spamWords {
+		if strings.Contains(text, w) {
+			spam++
+		}
+	}
+	if links := len(urlPattern.FindAllString(text, -1)); links > 2 {
+		spam += links - 2
+	}
+	if mostlyUpper(req.Message) {
+		spam++
+	}
+	if isPlaceholder(req.Message) {
+		spam++
+		ls.Reasons = append(ls.Reasons, "placeholder message")
+	}
+	if spam >= 2 || (spam == 1 && ls.Category == "") {
+		ls.Category = model.LeadSpam
+		ls.Score -= 30 * spam
+		ls.Reasons = append(ls.Reasons, fmt.Sprintf("%d spam signals", spam))
+	}
+
+	if ls.Category == "" {
+		ls.Category = model.LeadOther
+	}
+	return ls
+}
+
+func mostlyUpper(s string) bool {
+	letters, upper := 0, 0
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	return letters >= 20 && upper*10 >= letters*8
+}
+
+func isPlaceholder(msg string) bool {
+	switch strings.Trim(strings.ToLower(strings.TrimSpace(msg)), ".!? ") {
+	case "hi", "hello", "hey", "test", "testing", "asdf", "hi there", "hello there":
+		return true
+	}
+	return false
+}
+
+const classifyPrompt = `You classify messages sent through a software engineer's portfolio contact form.
+Reply with only a JSON object: {"category": "<job_offer|freelance|collaboration|spam|other>", "confidence": <0..1>}.
+job_offer: recruiters or companies offering employment. freelance: paid project or contract work.
+collaboration: unpaid partnerships, open source, co-founding. spam: marketing, SEO, scams, tests. other: anything else.`
+
+func (s *LeadScorer) classifyLLM(ctx context.Context, req model.ContactRequest) (string, float64, error) {
+	input := fmt.Sprintf("From: %s <%s>\nSubject: %s\n\n%s", req.Name, req.Email, req.Subject, truncate(req.Message, 2000))
+	out, err := s.llm.Complete(ctx, classifyPrompt, input)
+	if err != nil {
+		return "", 0, err
+	}
+
+	// Models sometimes wrap JSON in prose or code fences.
+	start, end := strings.IndexByte(out, '{'), strings.LastIndexByte(out, '}')
+	if start < 0 || end < start {
+		return "", 0, fmt.Errorf("no JSON in LLM output %q", truncate(out, 100))
+	}
+	var result struct {
+		Category   string  `json:"category"`
+		Confidence float64 `json:"confidence"`
+	}
+	if err := json.Unmarshal([]byte(out[start:end+1]), &result); err != nil {
+		return "", 0, fmt.Errorf("decode LLM output: %w", err)
+	}
+	switch result.Category {
+	case model.LeadJobOffer, model.LeadFreelance, model.LeadCollaboration, model.LeadSpam, model.LeadOther:
+		return result.Category, result.Confidence, nil
+	}
+	return "", 0, fmt.Errorf("unknown LLM category %q", result.Category)
+}
+
+// LeadRouter scores new contacts, stores the result on the contact record
+// and pushes high-priority leads to the notifier straight away. Everything
+// else is left for the periodic digest.
+type LeadRouter struct {
+	scorer *LeadScorer
+	store  ContactStore
+	notify Notifier
+}
+
+// NewLeadRouter creates a router.
+func NewLeadRouter(scorer *LeadScorer, store ContactStore, notify Notifier) *LeadRouter {
+	return &LeadRouter{scorer: scorer, store: store, notify: notify}
+}
+
+// Route classifies req (stored as contact id, which may be empty if storing
+// failed) and notifies when it is high priority. The score is stored before
+// notifying so it is on record even if notification hangs or fails.
+func (r *LeadRouter) Route(ctx context.Context, id string, req model.ContactRequest) model.LeadScore {
+	score := r.scorer.Score(ctx, req)
+	slog.Info("[lead] Classified contact",
+		"contact", id, "category", score.Category, "priority", score.Priority, "score", score.Score, "source", score.Source)
+	r.setLead(id, score)
+
+	if score.Priority != model.PriorityHigh {
+		return score
+	}
+	delivered, _ := r.notify.Notify(ctx, model.LeadEvent{
+		Type:      model.LeadContact,
+		ContactID: id,
+		Name:      req.Name,
+		Email:     req.Email,
+		Subject:   req.Subject,
+		Message:   req.Message,
+		CreatedAt: time.Now(),
+		Category:  score.Category,
+		Priority:  score.Priority,
+		Score:     score.Score,
+	})
+	// With no target enabled nothing was sent, so the lead is left for the digest.
+	if delivered > 0 {
+		score.Notified = true
+		r.setLead(id, score)
+	}
+	return score
+}
+
+func (r *LeadRouter) setLead(id string, score model.LeadScore) {
+	if id == "" {
+		return
+	}
+	if err := r.store.SetLead(id, score); err != nil {
+		slog.Error("[lead] Failed to store lead score", "error", err, "contact", id)
+	}
+}
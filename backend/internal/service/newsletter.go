@@ -0,0 +1,433 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/model"
+)
+
+// Newsletter errors.
+var (
+	ErrSubscriberNotFound = errors.New("subscriber not found")
+	ErrInvalidSignature   = errors.New("invalid or expired token")
+	ErrSendInProgress     = errors.New("an issue is already being sent")
+	ErrInvalidIssue       = errors.New("invalid issue")
+)
+
+// Token purposes, bound into the signature so one kind cannot stand in for another.
+const (
+	tokenConfirm     = "confirm"
+	tokenUnsubscribe = "unsubscribe"
+)
+
+// confirmResendAfter limits how often a pending address is re-sent its
+// confirmation, so the form cannot be used to flood someone's inbox.
+const confirmResendAfter = 10 * time.Minute
+
+// NewsletterService manages double opt-in subscriptions and mails issues to
+// active subscribers at a limited rate.
+type NewsletterService struct {
+	store      SubscriberStore
+	issues     IssueStore
+	email      EmailService
+	tasks      *Tasks
+	key        []byte
+	publicURL  string
+	confirmTTL time.Duration
+	interval   time.Duration
+
+	sending sync.Mutex
+}
+
+// NewNewsletterService creates a newsletter service. Tokens are signed with
+// key; issues are sent as tasks, at most perSecond emails per second.
+func NewNewsletterService(store SubscriberStore, issues IssueStore, email EmailService, tasks *Tasks, key []byte, publicURL string, confirmTTL time.Duration, perSecond float64) *NewsletterService {
+	if perSecond <= 0 {
+		perSecond = 1
+	}
+	return &NewsletterService{
+		store:      store,
+		issues:     issues,
+		email:      email,
+		tasks:      tasks,
+		key:        key,
+		publicURL:  strings.TrimRight(publicURL, "/"),
+		confirmTTL: confirmTTL,
+		interval:   time.Duration(float64(time.Second) / perSecond),
+	}
+}
+
+// Subscribe registers email as pending and sends a confirmation link.
+// Already active addresses are left alone, and the caller gets the same
+// result either way so the endpoint does not reveal who is subscribed.
+func (n *NewsletterService) Subscribe(ctx context.Context, email string) error {
+	now := time.Now()
+	sub, ok := n.store.FindByEmail(email)
+	switch {
+	case !ok:
+		var err error
+		sub, err = n.store.Create(model.Subscriber{
+			Email: email, Status: model.SubscriberPending, CreatedAt: now, ConfirmSentAt: now,
+		})
+		if err != nil {
+			return err
+		}
+	case sub.Status == model.SubscriberActive:
+		return nil
+	case sub.Status == model.SubscriberPending && now.Sub(sub.ConfirmSentAt) < confirmResendAfter:
+		return nil
+	default:
+		sub.Status = model.SubscriberPending
+		sub.ConfirmSentAt = now
+		if err := n.store.Update(sub); err != nil {
+			return err
+		}
+	}
+
+	link := n.publicURL + "/api/subscribe/confirm?" + url.Values{
+		"token": {n.sign(tokenConfirm, sub.ID, now.Add(n.confirmTTL))},
+	}.Encode()
+	slog.Info("[newsletter] Confirmation requested", "id", sub.ID)
+	return n.email.Deliver(ctx, model.Email{
+		To:      []string{sub.Email},
+		Subject: "Confirm your subscription",
+		Text:    "Please confirm your subscription by opening this link:\n\n" + link + "\n\nIf you did not ask to subscribe, ignore this email.",
+		HTML: fmt.Sprintf(`<p>Please confirm your subscription:</p><p><a href="%s">Confirm subscription</a></p>`+
+			`<p>If you did not ask to subscribe, ignore this email.</p>`, htmltemplate.HTMLEscapeString(link)),
+	})
+}
+
+// Confirm activates the subscriber named by a confirmation token.
+func (n *NewsletterService) Confirm(token string) (model.Subscriber, error) {
+	sub, err := n.lookup(tokenConfirm, token)
+	if err != nil {
+		return sub, err
+	}
+	if sub.Status == model.SubscriberActive {
+		return sub, nil
+	}
+	now := time.Now()
+	sub.Status = model.SubscriberActive
+	sub.ConfirmedAt = &now
+	sub.UnsubscribedAt = nil
+	if err := n.store.Update(sub); err != nil {
+		return sub, err
+	}
+	slog.Info("[newsletter] Subscription confirmed", "id", sub.ID)
+	return sub, nil
+}
+
+// Unsubscribe deactivates the subscriber named by an unsubscribe token.
+func (n *NewsletterService) Unsubscribe(token string) (model.Subscriber, error) {
+	sub, err := n.lookup(tokenUnsubscribe, token)
+	if err != nil {
+		return sub, err
+	}
+	if sub.Status == model.SubscriberUnsubscribed {
+		return sub, nil
+	}
+	now := time.Now()
+	sub.Status = model.SubscriberUnsubscribed
+	sub.UnsubscribedAt = &now
+	if err := n.store.Update(sub); err != nil {
+		return sub, err
+	}
+	slog.Info("[newsletter] Unsubscribed", "id", sub.ID)
+	return sub, nil
+}
+
+// Subscribers returns every subscription.
+func (n *NewsletterService) Subscribers() []model.Subscriber { return n.store.List() }
+
+// SendIssue validates the issue templates and mails the issue to every
+// active subscriber as a background task. Each delivery is recorded, so a
+// send cut short by shutdown carries on after Resume. It returns the number
+// of recipients.
+func (n *NewsletterService) SendIssue(issue model.NewsletterIssue) (int, error) {
+	if _, _, err := parseIssue(issue); err != nil {
+		return 0, err
+	}
+	if !n.sending.TryLock() {
+		return 0, ErrSendInProgress
+	}
+	send := model.IssueSend{Issue: issue, StartedAt: time.Now(), Recipients: []string{}, Sent: []string{}, Failed: []string{}}
+	for _, sub := range n.store.List() {
+		if sub.Status == model.SubscriberActive {
+			send.Recipients = append(send.Recipients, sub.ID)
+		}
+	}
+	send, err := n.issues.Create(send)
+	if err != nil {
+		n.sending.Unlock()
+		return 0, fmt.Errorf("record send: %w", err)
+	}
+	n.run([]model.IssueSend{send})
+	return len(send.Recipients), nil
+}
+
+// Resume carries on with sends that stopped before every recipient was
+// mailed. Call it once at startup.
+func (n *NewsletterService) Resume() {
+	var pending []model.IssueSend
+	for _, send := range n.issues.List() {
+		if send.FinishedAt == nil {
+			pending = append(pending, send)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+	n.sending.Lock()
+	slog.Info("[newsletter] Resuming interrupted sends", "sends", len(pending))
+	n.run(pending)
+}
+
+// run delivers sends one after another as a background task. The caller
+// holds n.sending, which is released when the task ends.
+func (n *NewsletterService) run(sends []model.IssueSend) {
+	started := n.tasks.Go(func(ctx context.Context) {
+		defer n.sending.Unlock()
+		for _, send := range sends {
+			if !n.deliverIssue(ctx, send) {
+				return
+			}
+		}
+	})
+	if !started {
+		n.sending.Unlock()
+		slog.Warn("[newsletter] Shutting down; the issue will be sent after restart", "sends", len(sends))
+	}
+}
+
+// parseIssue compiles the issue templates and renders them once, so
+// template errors are reported to the caller rather than failing for every
+// recipient.
+func parseIssue(issue model.NewsletterIssue) (*htmltemplate.Template, *template.Template, error) {
+	htmlTmpl, err := htmltemplate.New("html").Parse(issue.HTML)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: html template: %v", ErrInvalidIssue, err)
+	}
+	var textTmpl *template.Template
+	if issue.Text != "" {
+		if textTmpl, err = template.New("text").Parse(issue.Text); err != nil {
+			return nil, nil, fmt.Errorf("%w: text template: %v", ErrInvalidIssue, err)
+		}
+	}
+
+	sample := issueData{Email: "subscriber@example.com", UnsubscribeURL: "https://example.com/unsubscribe"}
+	if err := htmlTmpl.Execute(io.Discard, sample); err != nil {
+		return nil, nil, fmt.Errorf("%w: html template: %v", ErrInvalidIssue, err)
+	}
+	if textTmpl != nil {
+		if err := textTmpl.Execute(io.Discard, sample); err != nil {
+			return nil, nil, fmt.Errorf("%w: text template: %v", ErrInvalidIssue, err)
+		}
+	}
+	return htmlTmpl, textTmpl, nil
+}
+
+type issueData struct {
+	Email          string
+	UnsubscribeURL string
+}
+
+// deliverIssue mails send to the recipients it has not reached yet,
+// recording each one. It reports false if ctx ended the send early.
+func (n *NewsletterService) deliverIssue(ctx context.Context, send model.IssueSend) bool {
+	subject := send.Issue.Subject
+	htmlTmpl, textTmpl, err := parseIssue(send.Issue)
+	if err != nil {
+		slog.Error("[newsletter] Dropped issue", "error", err, "send", send.ID)
+		n.finishIssue(send)
+		return true
+	}
+
+	done := map[string]bool{}
+	for _, id := range append(append([]string(nil), send.Sent...), send.Failed...) {
+		done[id] = true
+	}
+	slog.Info("[newsletter] Sending issue", "subject", subject, "recipients", len(send.Recipients), "done", len(done))
+	tick := time.NewTicker(n.interval)
+	defer tick.Stop()
+
+	first := true
+	for _, id := range send.Recipients {
+		if done[id] {
+			continue
+		}
+		// Skip subscribers who left or were erased since the send started.
+		sub, ok := n.store.Get(id)
+		if !ok || sub.Status != model.SubscriberActive {
+			continue
+		}
+		if !first {
+			select {
+			case <-ctx.Done():
+				slog.Warn("[newsletter] Send interrupted; it resumes on restart", "subject", subject, "sent", len(send.Sent))
+				return false
+			case <-tick.C:
+			}
+		}
+		first = false
+
+		err := n.deliverTo(ctx, sub, subject, htmlTmpl, textTmpl)
+		if err != nil && ctx.Err() != nil {
+			slog.Warn("[newsletter] Send interrupted; it resumes on restart", "subject", subject, "sent", len(send.Sent))
+			return false
+		}
+		if err != nil {
+			slog.Error("[newsletter] Failed to send issue", "error", err, "id", sub.ID)
+			send.Failed = append(send.Failed, sub.ID)
+		} else {
+			send.Sent = append(send.Sent, sub.ID)
+		}
+		if err := n.issues.Update(send); err != nil {
+			slog.Error("[newsletter] Failed to record send progress", "error", err, "send", send.ID)
+		}
+	}
+	n.finishIssue(send)
+	return true
+}
+
+func (n *NewsletterService) deliverTo(ctx context.Context, sub model.Subscriber, subject string, htmlTmpl *htmltemplate.Template, textTmpl *template.Template) error {
+	unsubURL := n.UnsubscribeURL(sub.ID)
+	data := issueData{Email: sub.Email, UnsubscribeURL: unsubURL}
+
+	var htmlBody, textBody bytes.Buffer
+	if err := htmlTmpl.Execute(&htmlBody, data); err != nil {
+		return err
+	}
+	if textTmpl != nil {
+		if err := textTmpl.Execute(&textBody, data); err != nil {
+			return err
+		}
+	}
+	return n.email.Deliver(ctx, model.Email{
+		To:      []string{sub.Email},
+		Subject: subject,
+		HTML:    htmlBody.String(),
+		Text:    textBody.String(),
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + unsubURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	})
+}
+
+func (n *NewsletterService) finishIssue(send model.IssueSend) {
+	now := time.Now()
+	send.FinishedAt = &now
+	if err := n.issues.Update(send); err != nil {
+		slog.Error("[newsletter] Failed to record send progress", "error", err, "send", send.ID)
+	}
+	slog.Info("[newsletter] Issue sent", "subject", send.Issue.Subject, "sent", len(send.Sent), "failed", len(send.Failed))
+}
+
+// UnsubscribeURL returns the link that unsubscribes subscriber id. It does
+// not expire.
+func (n *NewsletterService) UnsubscribeURL(id string) string {
+	return n.publicURL + "/api/subscribe/unsubscribe?" + url.Values{
+		"token": {n.sign(tokenUnsubscribe, id, time.Time{})},
+	}.Encode()
+}
+
+func (n *NewsletterService) lookup(purpose, token string) (model.Subscriber, error) {
+	id, err := n.verify(purpose, token)
+	if err != nil {
+		return model.Subscriber{}, err
+	}
+	sub, ok := n.store.Get(id)
+	if !ok {
+		return model.Subscriber{}, ErrSubscriberNotFound
+	}
+	return sub, nil
+}
+
+// sign returns "<payload>.<mac>" where payload is base64url of
+// "purpose|id|expiry" (expiry 0 means the token never expires).
+func (n *NewsletterService) sign(purpose, id string, expires time.Time) string {
+	var exp int64
+	if !expires.IsZero() {
+		exp = expires.Unix()
+	}
+	payload := base64.RawURLEncoding.EncodeToString([]byte(purpose + "|" + id + "|" + strconv.FormatInt(exp, 10)))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(n.mac(payload))
+}
+
+func (n *NewsletterService) verify(purpose, token string) (string, error) {
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", ErrInvalidSignature
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, n.mac(payload)) {
+		return "", ErrInvalidSignature
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 || parts[0] != purpose {
+		return "", ErrInvalidSignature
+	}
+	exp, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || (exp != 0 && time.Now().Unix() > exp) {
+		return "", ErrInvalidSignature
+	}
+	return parts[1], nil
+}
+
+func (n *NewsletterService) mac(payload string) []byte {
+	m := hmac.New(sha256.New, n.key)
+	m.Write([]byte(payload))
+	return m.Sum(nil)
+}
+
+// LoadOrCreateKey returns the hex-encoded 32-byte key stored at path,
+// generating and saving one on first use.
+func LoadOrCreateKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) < 16 {
+			return nil, fmt.Errorf("invalid key in %s", path)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generate key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("create dir for %s: %w", path, err)
+	}
+	if err := writeFileAtomic(path, []byte(hex.EncodeToString(key)+"\n")); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
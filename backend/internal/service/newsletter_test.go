@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"portfolio-backend/internal/model"
+)
+
+// recordedEmails is an EmailService that keeps the recipients it is given.
+type recordedEmails struct {
+	mu sync.Mutex
+	to []string
+}
+
+func (r *recordedEmails) Send(ctx context.Context, req model.ContactRequest) error { return nil }
+
+func (r *recordedEmails) Deliver(ctx context.Context, msg model.Email) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.to = append(r.to, msg.To...)
+	return nil
+}
+
+func (r *recordedEmails) recipients() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := append([]string(nil), r.to...)
+	sort.Strings(out)
+	return out
+}
+
+func TestNewsletterSendResumesAfterShutdown(t *testing.T) {
+	dir := t.TempDir()
+	subscribers, err := NewFileSubscriberStore(filepath.Join(dir, "subscribers.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := NewFileIssueStore(filepath.Join(dir, "sends.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		if _, err := subscribers.Create(model.Subscriber{Email: email, Status: model.SubscriberActive}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	issue := model.NewsletterIssue{Subject: "News", HTML: `<p>Hi {{.Email}}</p><a href="{{.UnsubscribeURL}}">Unsubscribe</a>`}
+
+	// One email a minute: the first goes out at once, then shutdown stops the send.
+	firstEmails, firstTasks := &recordedEmails{}, NewTasks()
+	first := NewNewsletterService(subscribers, issues, firstEmails, firstTasks, []byte("0123456789abcdef"), "https://example.com", time.Hour, 1.0/60)
+	if n, err := first.SendIssue(issue); err != nil || n != 3 {
+		t.Fatalf("SendIssue = %d, %v, want 3 recipients", n, err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(firstEmails.recipients()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the first email")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Shutdown gives up at once, which cancels the send; the second Wait
+	// lets the task wind down.
+	expired, cancel := context.WithCancel(context.Background())
+	cancel()
+	firstTasks.Wait(expired)
+	firstTasks.Wait(context.Background())
+
+	sends := issues.List()
+	if len(sends) != 1 || sends[0].FinishedAt != nil || len(sends[0].Sent) != 1 {
+		t.Fatalf("after shutdown sends = %+v, want one unfinished send with one delivery", sends)
+	}
+
+	// After a restart the rest go out, and nobody gets the issue twice.
+	restartEmails, restartTasks := &recordedEmails{}, NewTasks()
+	restarted := NewNewsletterService(subscribers, issues, restartEmails, restartTasks, []byte("0123456789abcdef"), "https://example.com", time.Hour, 1000)
+	restarted.Resume()
+	if err := restartTasks.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	got := append(firstEmails.recipients(), restartEmails.recipients()...)
+	sort.Strings(got)
+	if want := []string{"a@example.com", "b@example.com", "c@example.com"}; !slices.Equal(got, want) {
+		t.Errorf("issue went to %v, want each subscriber once: %v", got, want)
+	}
+	sends = issues.List()
+	if len(sends) != 1 || sends[0].FinishedAt == nil || len(sends[0].Sent) != 3 {
+		t.Errorf("after resume sends = %+v, want one finished send with three deliveries", sends)
+	}
+}
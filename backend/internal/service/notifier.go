@@ -0,0 +1,280 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/model"
+)
+
+// Notifier fans out lead events to external channels. Notify returns how
+// many channels received ev, which is zero when none are enabled.
+type Notifier interface {
+	Notify(ctx context.Context, ev model.LeadEvent) (int, error)
+}
+
+// Webhook payload formats.
+const (
+	WebhookSlack   = "slack"
+	WebhookDiscord = "discord"
+	WebhookJSON    = "json"
+)
+
+// WebhookTarget is a single outgoing webhook destination.
+type WebhookTarget struct {
+	Kind    string
+	URL     string
+	Secret  string
+	Enabled bool
+}
+
+// WebhookNotifier posts lead events to Slack, Discord and generic JSON
+// webhooks. Generic targets are signed with HMAC-SHA256 over
+// "<timestamp>.<body>", sent as X-Webhook-Timestamp and X-Webhook-Signature.
+type WebhookNotifier struct {
+	targets []WebhookTarget
+	client  *http.Client
+	retries int
+	backoff time.Duration
+	events  EventPublisher
+}
+
+// NewWebhookNotifier creates a notifier. Each delivery is attempted up to
+// retries+1 times with exponential backoff; timeout bounds every attempt.
+// Deliveries that still fail are reported to events.
+func NewWebhookNotifier(targets []WebhookTarget, timeout time.Duration, retries int, events EventPublisher) *WebhookNotifier {
+	enabled := 0
+	for _, t := range targets {
+		if t.Enabled {
+			enabled++
+		}
+	}
+	slog.Info("[notify] Webhook notifier initialized", "targets", len(targets), "enabled", enabled)
+	return &WebhookNotifier{
+		targets: targets,
+		client:  &http.Client{Timeout: timeout},
+		retries: retries,
+		backoff: 500 * time.Millisecond,
+		events:  events,
+	}
+}
+
+// Notify delivers ev to every enabled target. It returns the number of
+// targets that accepted it and the joined errors of those that still failed
+// after retries. Retries stop once ctx is done.
+func (n *WebhookNotifier) Notify(ctx context.Context, ev model.LeadEvent) (int, error) {
+	var errs []error
+	delivered := 0
+	for _, t := range n.targets {
+		if !t.Enabled {
+			continue
+		}
+		if err := n.deliver(ctx, t, ev); err != nil {
+			slog.Error("[notify] Delivery failed", "kind", t.Kind, "type", ev.Type, "error", err)
+			n.events.Publish(EventProviderError, model.ProviderErrorEvent{Provider: "webhook:" + t.Kind, Error: err.Error()})
+			errs = append(errs, fmt.Errorf("%s: %w", t.Kind, err))
+			continue
+		}
+		slog.Debug("[notify] Delivered", "kind", t.Kind, "type", ev.Type)
+		delivered++
+	}
+	return delivered, errors.Join(errs...)
+}
+
+func (n *WebhookNotifier) deliver(ctx context.Context, t WebhookTarget, ev model.LeadEvent) error {
+	body, err := webhookPayload(t.Kind, ev)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= n.retries; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(n.backoff << (attempt - 1))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return errors.Join(lastErr, ctx.Err())
+			case <-timer.C:
+			}
+		}
+		retry, err := n.post(ctx, t, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+		slog.Warn("[notify] Attempt failed", "kind", t.Kind, "attempt", attempt+1, "error", err)
+	}
+	return lastErr
+}
+
+// post sends one request and reports whether a failure is worth retrying.
+func (n *WebhookNotifier) post(ctx context.Context, t WebhookTarget, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "portfolio-backend-notifier")
+
+	if t.Kind == WebhookJSON && t.Secret != "" {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set("X-Webhook-Timestamp", ts)
+		req.Header.Set("X-Webhook-Signature", "sha256="+SignWebhook(t.Secret, ts, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("status %d", resp.StatusCode)
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>" keyed by secret.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func webhookPayload(kind string, ev model.LeadEvent) ([]byte, error) {
+	switch kind {
+	case WebhookSlack:
+		return json.Marshal(slackPayload(ev))
+	case WebhookDiscord:
+		return json.Marshal(discordPayload(ev))
+	case WebhookJSON:
+		return json.Marshal(ev)
+	default:
+		return nil, fmt.Errorf("unknown webhook kind %q", kind)
+	}
+}
+
+func leadTitle(ev model.LeadEvent) string {
+	if ev.Type == model.LeadChatHire {
+		return "Hiring intent in chat"
+	}
+	return "New contact from " + ev.Name
+}
+
+// slackEscaper escapes the characters Slack treats as markup in mrkdwn and
+// fallback text, so visitor input cannot inject mentions such as <!channel>
+// or disguised links.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackText escapes s and then cuts it to at most n bytes, backing off
+// rather than splitting an entity. Escaping first keeps the result within
+// Slack's length limits however much markup s contains.
+func slackText(s string, n int) string {
+	s = slackEscaper.Replace(s)
+	if len(s) <= n {
+		return s
+	}
+	cut := s[:n]
+	if amp := strings.LastIndexByte(cut, '&'); amp >= 0 && !strings.Contains(cut[amp:], ";") {
+		cut = cut[:amp]
+	}
+	return strings.ToValidUTF8(cut, "") + "…"
+}
+
+func slackPayload(ev model.LeadEvent) map[string]any {
+	var fields []map[string]string
+	for _, f := range leadFields(ev) {
+		fields = append(fields, map[string]string{
+			"type": "mrkdwn",
+			"text": "*" + f[0] + "*\n" + slackEscaper.Replace(f[1]),
+		})
+	}
+	blocks := []map[string]any{
+		{"type": "header", "text": map[string]string{"type": "plain_text", "text": leadTitle(ev)}},
+	}
+	if len(fields) > 0 {
+		blocks = append(blocks, map[string]any{"type": "section", "fields": fields})
+	}
+	blocks = append(blocks, map[string]any{
+		"type": "section",
+		"text": map[string]string{"type": "mrkdwn", "text": slackText(ev.Message, 2900)},
+	})
+	return map[string]any{
+		"text":   slackEscaper.Replace(leadTitle(ev)) + ": " + slackText(ev.Message, 200),
+		"blocks": blocks,
+	}
+}
+
+func discordPayload(ev model.LeadEvent) map[string]any {
+	var fields []map[string]any
+	for _, f := range leadFields(ev) {
+		fields = append(fields, map[string]any{"name": f[0], "value": f[1], "inline": true})
+	}
+	return map[string]any{
+		"content": leadTitle(ev),
+		// Visitor text must not ping @everyone, @here, users or roles.
+		"allowed_mentions": map[string]any{"parse": []string{}},
+		"embeds": []map[string]any{{
+			"title":       leadTitle(ev),
+			"description": truncate(ev.Message, 4000),
+			"fields":      fields,
+			"timestamp":   ev.CreatedAt.Format(time.RFC3339),
+		}},
+	}
+}
+
+func leadFields(ev model.LeadEvent) [][2]string {
+	var fields [][2]string
+	for _, f := range [][2]string{
+		{"Name", ev.Name},
+		{"Email", ev.Email},
+		{"Subject", ev.Subject},
+		{"Category", ev.Category},
+		{"Priority", ev.Priority},
+	} {
+		if f[1] != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "") + "…"
+}
+
+// IsHireIntent reports whether a chat message looks like a hiring or
+// freelance enquiry worth forwarding to the owner.
+func IsHireIntent(message string) bool {
+	msg := strings.ToLower(message)
+	for _, kw := range []string{"hire", "hiring", "freelance", "job offer", "opportunity", "contract", "recruit"} {
+		if strings.Contains(msg, kw) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"portfolio-backend/internal/model"
+)
+
+// recordedEvents is an EventPublisher that keeps what it is given.
+type recordedEvents struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recordedEvents) Publish(eventType string, data any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, eventType)
+}
+
+// webhookReceiver is a local webhook endpoint that answers with the queued
+// statuses, then 200, and records every request.
+type webhookReceiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	bodies   [][]byte
+	headers  []http.Header
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) *webhookReceiver {
+	rcv := &webhookReceiver{statuses: statuses}
+	rcv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rcv.mu.Lock()
+		defer rcv.mu.Unlock()
+		rcv.bodies = append(rcv.bodies, body)
+		rcv.headers = append(rcv.headers, r.Header.Clone())
+		status := http.StatusOK
+		if len(rcv.statuses) > 0 {
+			status, rcv.statuses = rcv.statuses[0], rcv.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(rcv.Close)
+	return rcv
+}
+
+func (r *webhookReceiver) requests() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.bodies)
+}
+
+func (r *webhookReceiver) body(t *testing.T, i int) map[string]any {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out map[string]any
+	if err := json.Unmarshal(r.bodies[i], &out); err != nil {
+		t.Fatalf("request %d is not JSON: %v", i, err)
+	}
+	return out
+}
+
+func newTestNotifier(retries int, events EventPublisher, targets ...WebhookTarget) *WebhookNotifier {
+	n := NewWebhookNotifier(targets, time.Second, retries, events)
+	n.backoff = time.Millisecond
+	return n
+}
+
+var testLead = model.LeadEvent{
+	Type:      model.LeadContact,
+	ContactID: "c1",
+	Name:      "Mallory <!channel> @everyone",
+	Email:     "mallory@example.com",
+	Subject:   "Hi & welcome",
+	Message:   "<https://evil.example|click here> @here",
+	CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	Priority:  "high",
+}
+
+func TestWebhookNotifierSlackEscapesMarkup(t *testing.T) {
+	rcv := newWebhookReceiver(t)
+	n := newTestNotifier(0, &recordedEvents{}, WebhookTarget{Kind: WebhookSlack, URL: rcv.URL, Enabled: true})
+
+	if _, err := n.Notify(context.Background(), testLead); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	body := rcv.body(t, 0)
+	blocks, _ := body["blocks"].([]any)
+	if len(blocks) != 3 {
+		t.Fatalf("got %d blocks, want header, fields and message", len(blocks))
+	}
+	// Everything rendered as mrkdwn: the fallback text, fields and message.
+	texts := []string{body["text"].(string)}
+	for _, f := range blocks[1].(map[string]any)["fields"].([]any) {
+		texts = append(texts, f.(map[string]any)["text"].(string))
+	}
+	texts = append(texts, blocks[2].(map[string]any)["text"].(map[string]any)["text"].(string))
+	all := strings.Join(texts, "\n")
+	for _, injected := range []string{"<!channel>", "<https://evil.example", "Hi & welcome"} {
+		if strings.Contains(all, injected) {
+			t.Errorf("mrkdwn contains unescaped %q: %s", injected, all)
+		}
+	}
+	for _, escaped := range []string{"&lt;!channel&gt;", "&lt;https://evil.example|click here&gt;", "Hi &amp; welcome"} {
+		if !strings.Contains(all, escaped) {
+			t.Errorf("mrkdwn lacks %q: %s", escaped, all)
+		}
+	}
+}
+
+func TestWebhookNotifierDiscordDisablesMentions(t *testing.T) {
+	rcv := newWebhookReceiver(t)
+	n := newTestNotifier(0, &recordedEvents{}, WebhookTarget{Kind: WebhookDiscord, URL: rcv.URL, Enabled: true})
+
+	if _, err := n.Notify(context.Background(), testLead); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	body := rcv.body(t, 0)
+	mentions, ok := body["allowed_mentions"].(map[string]any)
+	if !ok {
+		t.Fatalf("no allowed_mentions in %v", body)
+	}
+	if parse, _ := mentions["parse"].([]any); parse == nil || len(parse) != 0 {
+		t.Errorf("allowed_mentions.parse = %v, want []", mentions["parse"])
+	}
+	embeds, _ := body["embeds"].([]any)
+	if len(embeds) != 1 {
+		t.Fatalf("got %d embeds, want 1", len(embeds))
+	}
+	if got := embeds[0].(map[string]any)["description"]; got != testLead.Message {
+		t.Errorf("description = %v, want the message", got)
+	}
+}
+
+func TestWebhookNotifierJSONSignature(t *testing.T) {
+	rcv := newWebhookReceiver(t)
+	n := newTestNotifier(0, &recordedEvents{}, WebhookTarget{Kind: WebhookJSON, URL: rcv.URL, Secret: "s3cret", Enabled: true})
+
+	if _, err := n.Notify(context.Background(), testLead); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	h := rcv.headers[0]
+	ts := h.Get("X-Webhook-Timestamp")
+	if ts == "" {
+		t.Fatal("missing X-Webhook-Timestamp")
+	}
+	if got, want := h.Get("X-Webhook-Signature"), "sha256="+SignWebhook("s3cret", ts, rcv.bodies[0]); got != want {
+		t.Errorf("signature = %q, want %q", got, want)
+	}
+	var ev model.LeadEvent
+	if err := json.Unmarshal(rcv.bodies[0], &ev); err != nil {
+		t.Fatalf("body: %v", err)
+	}
+	if ev.ContactID != testLead.ContactID || ev.Message != testLead.Message {
+		t.Errorf("body = %+v, want the lead event", ev)
+	}
+}
+
+func TestWebhookNotifierRetries(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		statuses []int
+		wantReqs int
+		wantErr  bool
+	}{
+		{"retries 5xx", []int{http.StatusInternalServerError, http.StatusBadGateway}, 3, false},
+		{"retries 429", []int{http.StatusTooManyRequests}, 2, false},
+		{"gives up after retries", []int{500, 500, 500, 500}, 3, true},
+		{"no retry on 4xx", []int{http.StatusBadRequest}, 1, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rcv := newWebhookReceiver(t, tc.statuses...)
+			events := &recordedEvents{}
+			n := newTestNotifier(2, events, WebhookTarget{Kind: WebhookJSON, URL: rcv.URL, Enabled: true})
+
+			_, err := n.Notify(context.Background(), testLead)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Notify error = %v, want error %v", err, tc.wantErr)
+			}
+			if got := rcv.requests(); got != tc.wantReqs {
+				t.Errorf("got %d requests, want %d", got, tc.wantReqs)
+			}
+			if tc.wantErr && len(events.events) != 1 {
+				t.Errorf("published %v, want one provider error", events.events)
+			}
+		})
+	}
+}
+
+func TestWebhookNotifierSkipsDisabledTargets(t *testing.T) {
+	enabled := newWebhookReceiver(t)
+	disabled := newWebhookReceiver(t)
+	n := newTestNotifier(0, &recordedEvents{},
+		WebhookTarget{Kind: WebhookSlack, URL: disabled.URL, Enabled: false},
+		WebhookTarget{Kind: WebhookDiscord, URL: enabled.URL, Enabled: true},
+	)
+
+	delivered, err := n.Notify(context.Background(), testLead)
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if delivered != 1 {
+		t.Errorf("delivered to %d targets, want 1", delivered)
+	}
+	if got := disabled.requests(); got != 0 {
+		t.Errorf("disabled target got %d requests", got)
+	}
+	if got := enabled.requests(); got != 1 {
+		t.Errorf("enabled target got %d requests, want 1", got)
+	}
+}
+
+func TestWebhookNotifierStopsRetryingWhenCancelled(t *testing.T) {
+	rcv := newWebhookReceiver(t, 500, 500, 500)
+	n := newTestNotifier(2, &recordedEvents{}, WebhookTarget{Kind: WebhookJSON, URL: rcv.URL, Enabled: true})
+	n.backoff = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := n.Notify(ctx, testLead)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Notify error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Notify took %v after cancellation", elapsed)
+	}
+	if got := rcv.requests(); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+}
+
+func TestLeadRouterNotifiedOnlyWhenDelivered(t *testing.T) {
+	lead := model.ContactRequest{
+		Name:    "Recruiter",
+		Email:   "talent@acme.example",
+		Subject: "Job offer",
+		Message: "We would like to hire you for a senior engineering position on our platform team.",
+	}
+	for _, tc := range []struct {
+		name    string
+		enabled bool
+		want    bool
+	}{
+		{"no enabled target", false, false},
+		{"delivered", true, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rcv := newWebhookReceiver(t)
+			store, err := NewFileContactStore(filepath.Join(t.TempDir(), "contacts.json"), "example.com", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec, err := store.Create(lead, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			n := newTestNotifier(0, &recordedEvents{}, WebhookTarget{Kind: WebhookJSON, URL: rcv.URL, Enabled: tc.enabled})
+			router := NewLeadRouter(NewLeadScorer(nil, 0), store, n)
+
+			if score := router.Route(context.Background(), rec.ID, lead); score.Priority != model.PriorityHigh {
+				t.Fatalf("priority = %s, want high", score.Priority)
+			}
+			got, _ := store.Get(rec.ID)
+			if got.Lead == nil || got.Lead.Notified != tc.want {
+				t.Errorf("stored lead = %+v, want notified %v", got.Lead, tc.want)
+			}
+		})
+	}
+}
+
+func TestWebhookNotifierSlackLimitsEscapedMessage(t *testing.T) {
+	rcv := newWebhookReceiver(t)
+	n := newTestNotifier(0, &recordedEvents{}, WebhookTarget{Kind: WebhookSlack, URL: rcv.URL, Enabled: true})
+	lead := testLead
+	// The leading x puts the cut inside an entity.
+	lead.Message = "x" + strings.Repeat("<", 2950)
+
+	if _, err := n.Notify(context.Background(), lead); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	body := rcv.body(t, 0)
+	blocks, _ := body["blocks"].([]any)
+	text := blocks[len(blocks)-1].(map[string]any)["text"].(map[string]any)["text"].(string)
+	// Slack rejects section text over 3000 characters.
+	if got := utf8.RuneCountInString(text); got > 3000 {
+		t.Errorf("section text has %d characters, want at most 3000", got)
+	}
+	for name, s := range map[string]string{"section": text, "fallback": body["text"].(string)} {
+		s = strings.TrimSuffix(s, "…")
+		if !strings.HasSuffix(s, "&lt;") {
+			t.Errorf("%s text ends with a split entity: …%s", name, s[len(s)-8:])
+		}
+		if strings.Contains(s, "<") {
+			t.Errorf("%s text contains an unescaped <", name)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/model"
+)
+
+// Outbox records outbound email deliveries.
+type Outbox interface {
+	Record(entry model.OutboxEntry) error
+	List() []model.OutboxEntry
+	DeleteWhere(match func(model.OutboxEntry) bool) (int, error)
+}
+
+// FileOutbox keeps delivery records in memory and mirrors them to a JSON file.
+type FileOutbox struct {
+	filePath string
+
+	mu      sync.RWMutex
+	entries []model.OutboxEntry
+}
+
+// NewFileOutbox loads existing entries from path.
+func NewFileOutbox(path string) (*FileOutbox, error) {
+	o := &FileOutbox{filePath: path}
+	if err := loadJSONFile(path, &o.entries); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (o *FileOutbox) Record(entry model.OutboxEntry) error {
+	if entry.ID == "" {
+		entry.ID = newID()
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.entries = append(o.entries, entry)
+	if err := saveJSONFile(o.filePath, o.entries); err != nil {
+		o.entries = o.entries[:len(o.entries)-1]
+		return err
+	}
+	return nil
+}
+
+// List returns entries oldest first.
+func (o *FileOutbox) List() []model.OutboxEntry {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return append([]model.OutboxEntry(nil), o.entries...)
+}
+
+func (o *FileOutbox) DeleteWhere(match func(model.OutboxEntry) bool) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	kept := make([]model.OutboxEntry, 0, len(o.entries))
+	for _, e := range o.entries {
+		if !match(e) {
+			kept = append(kept, e)
+		}
+	}
+	removed := len(o.entries) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+	if err := saveJSONFile(o.filePath, kept); err != nil {
+		return 0, err
+	}
+	o.entries = kept
+	return removed, nil
+}
+
+// OutboxEmailService wraps an EmailService and records every delivery in an Outbox.
+type OutboxEmailService struct {
+	next    EmailService
+	outbox  Outbox
+	toEmail string
+	events  EventPublisher
+}
+
+// NewOutboxEmailService decorates next so its deliveries are recorded and
+// announced to events. toEmail is the owner address that contact
+// notifications are sent to.
+func NewOutboxEmailService(next EmailService, outbox Outbox, toEmail string, events EventPublisher) *OutboxEmailService {
+	return &OutboxEmailService{next: next, outbox: outbox, toEmail: toEmail, events: events}
+}
+
+func (s *OutboxEmailService) Send(ctx context.Context, req model.ContactRequest) error {
+	err := s.next.Send(ctx, req)
+	s.record([]string{s.toEmail}, req.Email, "Portfolio Contact: "+req.Subject, err)
+	return err
+}
+
+func (s *OutboxEmailService) Deliver(ctx context.Context, msg model.Email) error {
+	err := s.next.Deliver(ctx, msg)
+	s.record(msg.To, msg.ReplyTo, msg.Subject, err)
+	return err
+}
+
+func (s *OutboxEmailService) record(to []string, replyTo, subject string, sendErr error) {
+	entry := model.OutboxEntry{
+		CreatedAt: time.Now(),
+		To:        to,
+		ReplyTo:   replyTo,
+		Subject:   subject,
+		Status:    model.OutboxSent,
+	}
+	if sendErr != nil {
+		entry.Status = model.OutboxFailed
+		entry.Error = sendErr.Error()
+	}
+	if err := s.outbox.Record(entry); err != nil {
+		slog.Error("[outbox] Failed to record delivery", "error", err)
+	}
+	if sendErr != nil {
+		s.events.Publish(EventEmailFailed, entry)
+	} else {
+		s.events.Publish(EventEmailSent, entry)
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"sync"
+
+	"portfolio-backend/internal/model"
+)
+
+// PresenceBackplane shares visitor counts between server instances so each
+// one can report cluster-wide totals. The visitor hub calls Publish from its
+// event loop, so implementations must not block it on I/O.
+type PresenceBackplane interface {
+	// Publish announces this instance's local counts. Callers re-publish at
+	// least every heartbeat interval; an instance that stops is dropped.
+	Publish(snap model.PresenceSnapshot)
+	// Remote delivers the combined counts of all other live instances
+	// whenever they change. Only the latest value is buffered.
+	Remote() <-chan model.PresenceCounts
+	// Close withdraws this instance from the cluster.
+	Close() error
+}
+
+// sumPresence adds up every snapshot except self's.
+func sumPresence(snaps map[string]model.PresenceSnapshot, self string) model.PresenceCounts {
+	total := model.PresenceCounts{Rooms: map[string]int{}}
+	for id, s := range snaps {
+		if id == self {
+			continue
+		}
+		total.Instances++
+		total.Count += s.Count
+		for room, n := range s.Rooms {
+			total.Rooms[room] += n
+		}
+	}
+	return total
+}
+
+// samePresence reports whether a and b hold the same counts.
+func samePresence(a, b model.PresenceCounts) bool {
+	if a.Instances != b.Instances || a.Count != b.Count || len(a.Rooms) != len(b.Rooms) {
+		return false
+	}
+	for room, n := range a.Rooms {
+		if m, ok := b.Rooms[room]; !ok || m != n {
+			return false
+		}
+	}
+	return true
+}
+
+// offerCounts replaces whatever is buffered in ch with c. Each channel has
+// a single sender, so the send after draining cannot block.
+func offerCounts(ch chan model.PresenceCounts, c model.PresenceCounts) {
+	select {
+	case <-ch:
+	default:
+	}
+	ch <- c
+}
+
+// MemoryBus is an in-process backplane shared by hubs in the same process.
+// A single-instance deployment uses it with one member, where it reports no
+// remote visitors.
+type MemoryBus struct {
+	mu      sync.Mutex
+	snaps   map[string]model.PresenceSnapshot
+	members map[string]*memoryBackplane
+}
+
+// NewMemoryBus creates an empty in-process backplane.
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		snaps:   make(map[string]model.PresenceSnapshot),
+		members: make(map[string]*memoryBackplane),
+	}
+}
+
+// Backplane joins the bus as instance.
+func (b *MemoryBus) Backplane(instance string) PresenceBackplane {
+	m := &memoryBackplane{bus: b, instance: instance, remote: make(chan model.PresenceCounts, 1)}
+	b.mu.Lock()
+	b.members[instance] = m
+	b.mu.Unlock()
+	return m
+}
+
+func (b *MemoryBus) publish(snap model.PresenceSnapshot) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if snap.Gone {
+		delete(b.snaps, snap.Instance)
+		delete(b.members, snap.Instance)
+	} else {
+		b.snaps[snap.Instance] = snap
+	}
+	for id, m := range b.members {
+		if id == snap.Instance {
+			continue
+		}
+		if counts := sumPresence(b.snaps, id); !samePresence(counts, m.last) {
+			m.last = counts
+			offerCounts(m.remote, counts)
+		}
+	}
+}
+
+type memoryBackplane struct {
+	bus      *MemoryBus
+	instance string
+	remote   chan model.PresenceCounts
+	last     model.PresenceCounts // guarded by bus.mu
+}
+
+func (m *memoryBackplane) Publish(snap model.PresenceSnapshot) {
+	snap.Instance = m.instance
+	m.bus.publish(snap)
+}
+
+func (m *memoryBackplane) Remote() <-chan model.PresenceCounts { return m.remote }
+
+func (m *memoryBackplane) Close() error {
+	m.bus.publish(model.PresenceSnapshot{Instance: m.instance, Gone: true})
+	return nil
+}
@@ -0,0 +1,318 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gookit/slog"
+	"github.com/redis/go-redis/v9"
+
+	"portfolio-backend/internal/model"
+)
+
+// Redis keys and channel used by the presence backplane.
+const (
+	presenceChannel   = "presence:updates"
+	presenceKeyPrefix = "presence:instance:"
+)
+
+// redisTimeout bounds each Redis round trip made by the backplane.
+const redisTimeout = 3 * time.Second
+
+// RedisClient is the subset of Redis the presence backplane uses, so tests
+// can run it against an in-process fake.
+type RedisClient interface {
+	Publish(ctx context.Context, channel string, msg []byte) error
+	// Subscribe delivers messages on channel until ctx is cancelled, then
+	// closes the returned channel.
+	Subscribe(ctx context.Context, channel string) (<-chan []byte, error)
+	SetEX(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Del(ctx context.Context, key string) error
+	// Values returns the values of all keys starting with prefix.
+	Values(ctx context.Context, prefix string) ([][]byte, error)
+}
+
+// RedisBackplane shares presence through Redis. Every instance keeps a
+// heartbeat key holding its latest snapshot, refreshed on each publish and
+// expiring after ttl, and announces the snapshot on a pub/sub channel. New
+// instances seed from the keys; running ones follow the channel and drop
+// peers they have not heard from within ttl.
+type RedisBackplane struct {
+	client   RedisClient
+	instance string
+	ttl      time.Duration
+
+	pending chan model.PresenceSnapshot
+	remote  chan model.PresenceCounts
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewRedisBackplane joins the cluster as instance. ttl should be a few
+// times the interval at which the hub re-publishes.
+func NewRedisBackplane(client RedisClient, instance string, ttl time.Duration) (*RedisBackplane, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	messages, err := client.Subscribe(ctx, presenceChannel)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("subscribe %s: %w", presenceChannel, err)
+	}
+
+	seedCtx, seedCancel := context.WithTimeout(ctx, redisTimeout)
+	values, err := client.Values(seedCtx, presenceKeyPrefix)
+	seedCancel()
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("load presence keys: %w", err)
+	}
+	peers := map[string]time.Time{}
+	snaps := map[string]model.PresenceSnapshot{}
+	now := time.Now()
+	for _, v := range values {
+		var s model.PresenceSnapshot
+		if json.Unmarshal(v, &s) == nil && s.Instance != "" && s.Instance != instance {
+			snaps[s.Instance] = s
+			peers[s.Instance] = now
+		}
+	}
+
+	b := &RedisBackplane{
+		client:   client,
+		instance: instance,
+		ttl:      ttl,
+		pending:  make(chan model.PresenceSnapshot, 1),
+		remote:   make(chan model.PresenceCounts, 1),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	last := sumPresence(snaps, instance)
+	if len(snaps) > 0 {
+		offerCounts(b.remote, last)
+	}
+	slog.Info("[presence] Joined Redis backplane", "instance", instance, "peers", len(snaps))
+
+	go b.publishLoop(ctx)
+	go b.receiveLoop(ctx, messages, snaps, peers, last)
+	return b, nil
+}
+
+// Publish queues snap for sending, replacing any snapshot not yet sent.
+func (b *RedisBackplane) Publish(snap model.PresenceSnapshot) {
+	snap.Instance = b.instance
+	select {
+	case <-b.pending:
+	default:
+	}
+	b.pending <- snap
+}
+
+// Remote delivers the combined counts of the other instances.
+func (b *RedisBackplane) Remote() <-chan model.PresenceCounts { return b.remote }
+
+// Close stops the backplane and tells the other instances this one is gone.
+func (b *RedisBackplane) Close() error {
+	b.cancel()
+	<-b.done
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	return b.write(ctx, model.PresenceSnapshot{Instance: b.instance, Gone: true})
+}
+
+func (b *RedisBackplane) publishLoop(ctx context.Context) {
+	defer close(b.done)
+	failing := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case snap := <-b.pending:
+			wctx, cancel := context.WithTimeout(ctx, redisTimeout)
+			err := b.write(wctx, snap)
+			cancel()
+			// Log transitions only; a Redis outage would otherwise log every heartbeat.
+			switch {
+			case err != nil && !failing && ctx.Err() == nil:
+				slog.Warn("[presence] Publish failed", "error", err)
+				failing = true
+			case err == nil && failing:
+				slog.Info("[presence] Publish recovered")
+				failing = false
+			}
+		}
+	}
+}
+
+func (b *RedisBackplane) write(ctx context.Context, snap model.PresenceSnapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	key := presenceKeyPrefix + snap.Instance
+	if snap.Gone {
+		err = b.client.Del(ctx, key)
+	} else {
+		err = b.client.SetEX(ctx, key, data, b.ttl)
+	}
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, presenceChannel, data)
+}
+
+// receiveLoop tracks peer snapshots from the channel and expires peers
+// whose heartbeats stop. last is the sum most recently delivered.
+func (b *RedisBackplane) receiveLoop(ctx context.Context, messages <-chan []byte, snaps map[string]model.PresenceSnapshot, peers map[string]time.Time, last model.PresenceCounts) {
+	sweep := time.NewTicker(b.ttl / 2)
+	defer sweep.Stop()
+
+	for {
+		changed := false
+		select {
+		case <-ctx.Done():
+			return
+
+		case data, ok := <-messages:
+			if !ok {
+				return
+			}
+			var s model.PresenceSnapshot
+			if err := json.Unmarshal(data, &s); err != nil || s.Instance == "" {
+				slog.Debug("[presence] Ignored malformed snapshot", "error", err)
+				continue
+			}
+			if s.Instance == b.instance {
+				continue
+			}
+			if s.Gone {
+				_, changed = snaps[s.Instance]
+				delete(snaps, s.Instance)
+				delete(peers, s.Instance)
+			} else {
+				snaps[s.Instance] = s
+				peers[s.Instance] = time.Now()
+				changed = true
+			}
+
+		case now := <-sweep.C:
+			for id, seen := range peers {
+				if now.Sub(seen) > b.ttl {
+					slog.Info("[presence] Peer expired", "instance", id)
+					delete(snaps, id)
+					delete(peers, id)
+					changed = true
+				}
+			}
+		}
+		if !changed {
+			continue
+		}
+		// Heartbeats usually repeat the same counts; only deliver changes.
+		if counts := sumPresence(snaps, b.instance); !samePresence(counts, last) {
+			last = counts
+			offerCounts(b.remote, counts)
+		}
+	}
+}
+
+// ---------- go-redis adapter ----------
+
+// goRedisClient implements RedisClient with go-redis.
+type goRedisClient struct {
+	rdb *redis.Client
+}
+
+// NewRedisClient connects to the Redis server at url
+// (redis://[user:password@]host:port/db).
+func NewRedisClient(url string) (RedisClient, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("parse redis url: %w", err)
+	}
+	rdb := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		rdb.Close()
+		return nil, fmt.Errorf("connect to redis: %w", err)
+	}
+	return &goRedisClient{rdb: rdb}, nil
+}
+
+func (c *goRedisClient) Publish(ctx context.Context, channel string, msg []byte) error {
+	return c.rdb.Publish(ctx, channel, msg).Err()
+}
+
+func (c *goRedisClient) Subscribe(ctx context.Context, channel string) (<-chan []byte, error) {
+	ps := c.rdb.Subscribe(ctx, channel)
+	// Wait for the confirmation so the caller sees connection errors.
+	if _, err := ps.Receive(ctx); err != nil {
+		ps.Close()
+		return nil, err
+	}
+	out := make(chan []byte)
+	go func() {
+		defer close(out)
+		defer ps.Close()
+		// go-redis resubscribes after reconnecting; missed messages are
+		// covered by the next heartbeat.
+		in := ps.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-in:
+				if !ok {
+					return
+				}
+				select {
+				case out <- []byte(msg.Payload):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (c *goRedisClient) SetEX(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.rdb.Set(ctx, key, value, ttl).Err()
+}
+
+func (c *goRedisClient) Del(ctx context.Context, key string) error {
+	return c.rdb.Del(ctx, key).Err()
+}
+
+func (c *goRedisClient) Values(ctx context.Context, prefix string) ([][]byte, error) {
+	var keys []string
+	iter := c.rdb.Scan(ctx, 0, escapeGlob(prefix)+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	vals, err := c.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make([][]byte, 0, len(vals))
+	for _, v := range vals {
+		// Keys that expired between SCAN and MGET come back nil.
+		if s, ok := v.(string); ok {
+			out = append(out, []byte(s))
+		}
+	}
+	return out, nil
+}
+
+// escapeGlob escapes the characters special in Redis MATCH patterns.
+func escapeGlob(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`).Replace(s)
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"portfolio-backend/internal/model"
+)
+
+// fakeRedis is an in-process RedisClient: keys with expiry and pub/sub
+// channels that drop messages for subscribers that fall behind.
+type fakeRedis struct {
+	mu   sync.Mutex
+	keys map[string]fakeRedisValue
+	subs map[string]map[chan []byte]struct{}
+}
+
+type fakeRedisValue struct {
+	data    []byte
+	expires time.Time
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{
+		keys: map[string]fakeRedisValue{},
+		subs: map[string]map[chan []byte]struct{}{},
+	}
+}
+
+func (r *fakeRedis) Publish(ctx context.Context, channel string, msg []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for sub := range r.subs[channel] {
+		select {
+		case sub <- append([]byte(nil), msg...):
+		default:
+		}
+	}
+	return nil
+}
+
+func (r *fakeRedis) Subscribe(ctx context.Context, channel string) (<-chan []byte, error) {
+	sub := make(chan []byte, 64)
+	r.mu.Lock()
+	if r.subs[channel] == nil {
+		r.subs[channel] = map[chan []byte]struct{}{}
+	}
+	r.subs[channel][sub] = struct{}{}
+	r.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.subs[channel], sub)
+		close(sub)
+	}()
+	return sub, nil
+}
+
+func (r *fakeRedis) SetEX(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys[key] = fakeRedisValue{data: append([]byte(nil), value...), expires: time.Now().Add(ttl)}
+	return nil
+}
+
+func (r *fakeRedis) Del(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.keys, key)
+	return nil
+}
+
+func (r *fakeRedis) Values(ctx context.Context, prefix string) ([][]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out [][]byte
+	now := time.Now()
+	for key, v := range r.keys {
+		if strings.HasPrefix(key, prefix) && now.Before(v.expires) {
+			out = append(out, v.data)
+		}
+	}
+	return out, nil
+}
+
+// live returns the number of unexpired keys starting with prefix.
+func (r *fakeRedis) live(prefix string) int {
+	values, _ := r.Values(context.Background(), prefix)
+	return len(values)
+}
+
+func newTestBackplane(t *testing.T, client RedisClient, instance string, ttl time.Duration) *RedisBackplane {
+	t.Helper()
+	b, err := NewRedisBackplane(client, instance, ttl)
+	if err != nil {
+		t.Fatalf("NewRedisBackplane(%s): %v", instance, err)
+	}
+	return b
+}
+
+// closeBackplane withdraws b at the end of the test.
+func closeBackplane(t *testing.T, b *RedisBackplane) {
+	t.Cleanup(func() {
+		if err := b.Close(); err != nil {
+			t.Errorf("Close: %v", err)
+		}
+	})
+}
+
+// waitRemote reads b's remote counts until want holds for one of them.
+func waitRemote(t *testing.T, b *RedisBackplane, what string, want func(model.PresenceCounts) bool) model.PresenceCounts {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case c := <-b.Remote():
+			if want(c) {
+				return c
+			}
+		case <-timeout:
+			t.Fatalf("%s: timed out waiting for %s", b.instance, what)
+		}
+	}
+}
+
+func countIs(instances, count int) func(model.PresenceCounts) bool {
+	return func(c model.PresenceCounts) bool { return c.Instances == instances && c.Count == count }
+}
+
+func TestRedisBackplaneCountsAcrossInstances(t *testing.T) {
+	redis := newFakeRedis()
+	a := newTestBackplane(t, redis, "a", time.Minute)
+	closeBackplane(t, a)
+	b := newTestBackplane(t, redis, "b", time.Minute)
+
+	a.Publish(model.PresenceSnapshot{Count: 2, Rooms: map[string]int{"/": 2}})
+	b.Publish(model.PresenceSnapshot{Count: 3, Rooms: map[string]int{"/": 1, "/blog": 2}})
+
+	// Each instance sees only the other one.
+	waitRemote(t, a, "b's counts", countIs(1, 3))
+	waitRemote(t, b, "a's counts", countIs(1, 2))
+
+	// A late joiner seeds from the heartbeat keys before any new message.
+	c := newTestBackplane(t, redis, "c", time.Minute)
+	closeBackplane(t, c)
+	got := waitRemote(t, c, "seeded counts", countIs(2, 5))
+	if got.Rooms["/"] != 3 || got.Rooms["/blog"] != 2 {
+		t.Errorf("seeded rooms = %v, want / 3 and /blog 2", got.Rooms)
+	}
+
+	// Closing withdraws the instance at once rather than after the TTL.
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	waitRemote(t, a, "b to leave", countIs(0, 0))
+	waitRemote(t, c, "b to leave", countIs(1, 2))
+	if n := redis.live(presenceKeyPrefix); n != 1 {
+		t.Errorf("%d heartbeat keys after close, want 1", n)
+	}
+}
+
+func TestRedisBackplaneExpiresDeadInstance(t *testing.T) {
+	const ttl = 200 * time.Millisecond
+	redis := newFakeRedis()
+	watcher := newTestBackplane(t, redis, "watcher", ttl)
+	closeBackplane(t, watcher)
+	live := newTestBackplane(t, redis, "live", ttl)
+	closeBackplane(t, live)
+	dead := newTestBackplane(t, redis, "dead", ttl)
+
+	// The live instance heartbeats well within the TTL for the whole test.
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		tick := time.NewTicker(ttl / 4)
+		defer tick.Stop()
+		for {
+			live.Publish(model.PresenceSnapshot{Count: 1})
+			select {
+			case <-stop:
+				return
+			case <-tick.C:
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		close(stop)
+		wg.Wait()
+	})
+
+	dead.Publish(model.PresenceSnapshot{Count: 4})
+	waitRemote(t, watcher, "both peers", countIs(2, 5))
+
+	// Crash the instance: its loops stop without announcing that it is gone.
+	dead.cancel()
+	<-dead.done
+
+	start := time.Now()
+	waitRemote(t, watcher, "the dead peer to expire", countIs(1, 1))
+	if elapsed := time.Since(start); elapsed < ttl/2 {
+		t.Errorf("dead peer dropped after %v, before its TTL of %v", elapsed, ttl)
+	}
+	// Its heartbeat key expires too, so new instances do not count it.
+	if n := redis.live(presenceKeyPrefix + "dead"); n != 0 {
+		t.Errorf("dead instance still has %d live keys", n)
+	}
+	late := newTestBackplane(t, redis, "late", ttl)
+	closeBackplane(t, late)
+	waitRemote(t, late, "only the live peer", countIs(1, 1))
+}
+
+func TestRedisBackplaneFanOut(t *testing.T) {
+	redis := newFakeRedis()
+	publisher := newTestBackplane(t, redis, "publisher", time.Minute)
+	closeBackplane(t, publisher)
+	var subscribers []*RedisBackplane
+	for _, id := range []string{"s1", "s2", "s3"} {
+		s := newTestBackplane(t, redis, id, time.Minute)
+		closeBackplane(t, s)
+		subscribers = append(subscribers, s)
+	}
+
+	// Garbage on the channel is skipped without disturbing the others.
+	redis.Publish(context.Background(), presenceChannel, []byte("not json"))
+	redis.Publish(context.Background(), presenceChannel, []byte(`{"count":9}`))
+
+	for i := 1; i <= 3; i++ {
+		publisher.Publish(model.PresenceSnapshot{Count: i})
+		for _, s := range subscribers {
+			waitRemote(t, s, "the publisher's update", countIs(1, i))
+		}
+	}
+	// The publisher hears the subscribers but never counts itself.
+	subscribers[0].Publish(model.PresenceSnapshot{Count: 7})
+	waitRemote(t, publisher, "the subscriber's counts", countIs(1, 7))
+}
@@ -0,0 +1,544 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/model"
+)
+
+// Retention modes for expired contacts.
+const (
+	RetentionAnonymize = "anonymize"
+	RetentionPurge     = "purge"
+)
+
+// Audit actions.
+const (
+	AuditExport    = "export"
+	AuditErase     = "erase"
+	AuditRetention = "retention"
+)
+
+// RetentionPolicy controls how long personal data is kept.
+// A zero MaxAge disables retention.
+type RetentionPolicy struct {
+	MaxAge time.Duration
+	Mode   string
+}
+
+// PrivacyService exports, erases and expires personal data across the
+// contact store, chat transcripts, outbox, bookings, newsletter subscribers,
+// guestbook entries and plain-text log files.
+type PrivacyService struct {
+	contacts ContactStore
+	chat     ChatStore
+	outbox   Outbox
+	blobs    BlobStore
+	bookings BookingStore
+	subs     SubscriberStore
+	guest    GuestbookStore
+	audit    AuditLog
+	sealer   *RecordSealer
+	policy   RetentionPolicy
+	logs     []LogFiles
+}
+
+// PrivacyStores are the stores a PrivacyService searches.
+type PrivacyStores struct {
+	Contacts    ContactStore
+	Chat        ChatStore
+	Outbox      Outbox
+	Blobs       BlobStore
+	Bookings    BookingStore
+	Subscribers SubscriberStore
+	Guestbook   GuestbookStore
+}
+
+// LogFiles names plain-text logs subject to export and erasure. Lock pauses
+// their writer while a file is rewritten.
+type LogFiles struct {
+	Glob string
+	Lock sync.Locker
+}
+
+// logFile is one file matched by a LogFiles glob.
+type logFile struct {
+	path string
+	lock sync.Locker
+}
+
+// NewPrivacyService creates a PrivacyService. logs name plain-text logs
+// (e.g. contacts.log, logs/app.log*) that are searched and scrubbed line by line.
+func NewPrivacyService(
+	stores PrivacyStores,
+	audit AuditLog,
+	sealer *RecordSealer,
+	policy RetentionPolicy,
+	logs []LogFiles,
+) *PrivacyService {
+	return &PrivacyService{
+		contacts: stores.Contacts,
+		chat:     stores.Chat,
+		outbox:   stores.Outbox,
+		blobs:    stores.Blobs,
+		bookings: stores.Bookings,
+		subs:     stores.Subscribers,
+		guest:    stores.Guestbook,
+		audit:    audit,
+		sealer:   sealer,
+		policy:   policy,
+		logs:     logs,
+	}
+}
+
+// Export collects everything held about email.
+func (p *PrivacyService) Export(email, actor string) (model.DataExport, error) {
+	email = strings.TrimSpace(email)
+	out := model.DataExport{
+		Email:       email,
+		GeneratedAt: time.Now(),
+		Contacts:    []model.ContactRecord{},
+		Chat:        []model.ChatExchange{},
+		Outbox:      []model.OutboxEntry{},
+		Bookings:    []model.Booking{},
+		Subscribers: []model.Subscriber{},
+		Guestbook:   []model.GuestbookEntry{},
+		LogLines:    []string{},
+	}
+
+	for _, rec := range p.openContacts() {
+		if p.contactMatches(rec, email) {
+			out.Contacts = append(out.Contacts, rec)
+		}
+	}
+	for _, ex := range p.chat.List() {
+		if p.chatMatches(&ex, email) {
+			out.Chat = append(out.Chat, ex)
+		}
+	}
+	for _, e := range p.outbox.List() {
+		if outboxMatches(e, email) {
+			out.Outbox = append(out.Outbox, e)
+		}
+	}
+	for _, b := range p.bookings.List() {
+		if strings.EqualFold(b.Email, email) {
+			out.Bookings = append(out.Bookings, b)
+		}
+	}
+	for _, sub := range p.subs.List() {
+		if strings.EqualFold(sub.Email, email) {
+			out.Subscribers = append(out.Subscribers, sub)
+		}
+	}
+	for _, e := range p.guest.List("") {
+		if guestbookMatches(e, email) {
+			out.Guestbook = append(out.Guestbook, e)
+		}
+	}
+	for _, f := range p.logFiles() {
+		lines, err := p.matchingLines(f.path, email)
+		if err != nil {
+			return model.DataExport{}, err
+		}
+		out.LogLines = append(out.LogLines, lines...)
+	}
+
+	p.record(AuditExport, email, actor, map[string]int{
+		"contacts":    len(out.Contacts),
+		"chat":        len(out.Chat),
+		"outbox":      len(out.Outbox),
+		"bookings":    len(out.Bookings),
+		"subscribers": len(out.Subscribers),
+		"guestbook":   len(out.Guestbook),
+		"logLines":    len(out.LogLines),
+	})
+	return out, nil
+}
+
+// Erase deletes everything held about email and returns per-store counts.
+// Bookings are anonymized rather than deleted so their slots stay taken.
+func (p *PrivacyService) Erase(email, actor string) (map[string]int, error) {
+	email = strings.TrimSpace(email)
+	counts := map[string]int{}
+	var errs []error
+
+	var doomed []model.ContactRecord
+	for _, rec := range p.openContacts() {
+		if p.contactMatches(rec, email) {
+			doomed = append(doomed, rec)
+		}
+	}
+	for _, rec := range doomed {
+		if err := p.contacts.Delete(rec.ID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		counts["contacts"]++
+	}
+	counts["attachments"] = p.releaseBlobs(doomed)
+
+	n, err := p.chat.DeleteWhere(func(ex model.ChatExchange) bool { return p.chatMatches(&ex, email) })
+	counts["chat"] = n
+	errs = append(errs, err)
+
+	n, err = p.outbox.DeleteWhere(func(e model.OutboxEntry) bool { return outboxMatches(e, email) })
+	counts["outbox"] = n
+	errs = append(errs, err)
+
+	for _, b := range p.bookings.List() {
+		if !strings.EqualFold(b.Email, email) {
+			continue
+		}
+		if err := p.bookings.Update(anonymizeBooking(b)); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		counts["bookings"]++
+	}
+
+	for _, sub := range p.subs.List() {
+		if !strings.EqualFold(sub.Email, email) {
+			continue
+		}
+		if err := p.subs.Delete(sub.ID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		counts["subscribers"]++
+	}
+
+	for _, e := range p.guest.List("") {
+		if !guestbookMatches(e, email) {
+			continue
+		}
+		if err := p.guest.Delete(e.ID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		counts["guestbook"]++
+	}
+
+	for _, f := range p.logFiles() {
+		n, err := f.rewrite(func(line string) bool { return !p.sealer.LineMatches(line, email) })
+		counts["logLines"] += n
+		errs = append(errs, err)
+	}
+
+	p.record(AuditErase, email, actor, counts)
+	return counts, errors.Join(errs...)
+}
+
+// ApplyRetention expires data older than the policy's MaxAge. Contacts are
+// anonymized or purged per the policy mode; chat transcripts, outbox entries
+// and contact log lines are always purged.
+func (p *PrivacyService) ApplyRetention(now time.Time) (map[string]int, error) {
+	counts := map[string]int{}
+	if p.policy.MaxAge <= 0 {
+		return counts, nil
+	}
+	cutoff := now.Add(-p.policy.MaxAge)
+	var errs []error
+
+	var expired []model.ContactRecord
+	for _, rec := range p.openContacts() {
+		if rec.CreatedAt.Before(cutoff) && !(rec.Anonymized && p.policy.Mode == RetentionAnonymize) {
+			expired = append(expired, rec)
+		}
+	}
+	for _, rec := range expired {
+		var err error
+		if p.policy.Mode == RetentionPurge {
+			err = p.contacts.Delete(rec.ID)
+		} else {
+			err = p.contacts.Update(anonymize(rec))
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		counts["contacts"]++
+	}
+	counts["attachments"] = p.releaseBlobs(expired)
+
+	n, err := p.chat.DeleteWhere(func(ex model.ChatExchange) bool { return ex.CreatedAt.Before(cutoff) })
+	counts["chat"] = n
+	errs = append(errs, err)
+
+	n, err = p.outbox.DeleteWhere(func(e model.OutboxEntry) bool { return e.CreatedAt.Before(cutoff) })
+	counts["outbox"] = n
+	errs = append(errs, err)
+
+	for _, f := range p.logFiles() {
+		n, err := f.rewrite(func(line string) bool {
+			ts, ok := lineTimestamp(line)
+			return !ok || !ts.Before(cutoff)
+		})
+		counts["logLines"] += n
+		errs = append(errs, err)
+	}
+
+	if counts["contacts"]+counts["chat"]+counts["outbox"]+counts["logLines"] > 0 {
+		p.record(AuditRetention, "", "retention", counts)
+	}
+	return counts, errors.Join(errs...)
+}
+
+// RunRetention applies the retention policy every interval until ctx is done.
+func (p *PrivacyService) RunRetention(ctx context.Context, interval time.Duration) {
+	if p.policy.MaxAge <= 0 {
+		slog.Info("[privacy] Retention disabled")
+		return
+	}
+	slog.Info("[privacy] Retention enabled", "maxAge", p.policy.MaxAge.String(), "mode", p.policy.Mode)
+
+	for {
+		counts, err := p.ApplyRetention(time.Now())
+		if err != nil {
+			slog.Error("[privacy] Retention run failed", "error", err)
+		} else {
+			slog.Debug("[privacy] Retention run complete", "counts", counts)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// releaseBlobs deletes attachment blobs of the given records that are no
+// longer referenced by any remaining, non-anonymized record.
+func (p *PrivacyService) releaseBlobs(records []model.ContactRecord) int {
+	inUse := map[string]bool{}
+	for _, rec := range p.openContacts() {
+		for _, a := range rec.Contact.Attachments {
+			inUse[a.SHA256] = true
+		}
+	}
+	deleted := 0
+	for _, rec := range records {
+		for _, a := range rec.Contact.Attachments {
+			if inUse[a.SHA256] {
+				continue
+			}
+			if err := p.blobs.Delete(a.SHA256); err != nil {
+				slog.Error("[privacy] Failed to delete attachment", "error", err, "sha256", a.SHA256)
+				continue
+			}
+			inUse[a.SHA256] = true // count shared blobs once
+			deleted++
+		}
+	}
+	return deleted
+}
+
+func (p *PrivacyService) record(action, email, actor string, details map[string]int) {
+	entry := model.AuditEntry{
+		Time:    time.Now(),
+		Action:  action,
+		Actor:   actor,
+		Details: details,
+	}
+	if email != "" {
+		entry.SubjectHash = HashSubject(email)
+	}
+	if err := p.audit.Record(entry); err != nil {
+		slog.Error("[privacy] Failed to write audit entry", "error", err, "action", action)
+	}
+}
+
+// logFiles flushes buffered log output so the files on disk are complete,
+// then expands the configured globs.
+func (p *PrivacyService) logFiles() []logFile {
+	if err := slog.Flush(); err != nil {
+		slog.Warn("[privacy] Failed to flush logs", "error", err)
+	}
+	var files []logFile
+	for _, l := range p.logs {
+		matches, _ := filepath.Glob(l.Glob)
+		for _, path := range matches {
+			files = append(files, logFile{path: path, lock: l.Lock})
+		}
+	}
+	return files
+}
+
+// openContacts returns all contacts decrypted. Records that fail to open are
+// returned sealed so they are still subject to retention by age.
+func (p *PrivacyService) openContacts() []model.ContactRecord {
+	records := p.contacts.List()
+	for i := range records {
+		if err := p.sealer.OpenContact(&records[i]); err != nil {
+			slog.Error("[privacy] Failed to decrypt contact", "error", err, "contact", records[i].ID)
+		}
+	}
+	return records
+}
+
+// chatMatches opens ex in place and reports whether it mentions email.
+func (p *PrivacyService) chatMatches(ex *model.ChatExchange, email string) bool {
+	if err := p.sealer.OpenChat(ex); err != nil {
+		slog.Error("[privacy] Failed to decrypt chat", "error", err, "chat", ex.ID)
+		return false
+	}
+	return containsFold(ex.Message, email) || containsFold(ex.Response, email)
+}
+
+// matchingLines returns the lines of path that mention email, decrypting sealed entries.
+func (p *PrivacyService) matchingLines(path, email string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	var out []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if !p.sealer.LineMatches(line, email) {
+			continue
+		}
+		if prefix, body, ok := strings.Cut(line, "] "); ok {
+			if plain, err := p.sealer.OpenLine(body); err == nil {
+				line = prefix + "] " + plain
+			}
+		}
+		out = append(out, line)
+	}
+	return out, sc.Err()
+}
+
+func anonymize(rec model.ContactRecord) model.ContactRecord {
+	rec.Contact = model.ContactRequest{Name: "anonymized"}
+	rec.Sealed = nil
+	rec.EmailIndex = ""
+	for i := range rec.Thread {
+		rec.Thread[i].From = ""
+		rec.Thread[i].To = ""
+		rec.Thread[i].Subject = ""
+		rec.Thread[i].Body = ""
+		rec.Thread[i].Sealed = nil
+	}
+	rec.Geo = nil
+	rec.Anonymized = true
+	return rec
+}
+
+func anonymizeBooking(b model.Booking) model.Booking {
+	b.Name = "anonymized"
+	b.Email = ""
+	b.Note = ""
+	return b
+}
+
+func (p *PrivacyService) contactMatches(rec model.ContactRecord, email string) bool {
+	if p.sealer.MatchesEmail(rec, email) {
+		return true
+	}
+	for _, m := range rec.Thread {
+		if containsFold(m.From, email) || containsFold(m.To, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// guestbookMatches reports whether e mentions email. Entries carry no
+// address of their own, so ones that do not mention it cannot be found.
+func guestbookMatches(e model.GuestbookEntry, email string) bool {
+	return containsFold(e.Name, email) || containsFold(e.Message, email) || containsFold(e.Website, email)
+}
+
+func outboxMatches(e model.OutboxEntry, email string) bool {
+	if strings.EqualFold(e.ReplyTo, email) {
+		return true
+	}
+	for _, to := range e.To {
+		if containsFold(to, email) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(s, substr string) bool {
+	return substr != "" && strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// rewrite keeps only the lines for which keep returns true and reports how
+// many were dropped. The writer is paused throughout, so no line appended
+// meanwhile is lost.
+func (f logFile) rewrite(keep func(string) bool) (int, error) {
+	if f.lock != nil {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+	}
+	return rewriteLines(f.path, keep)
+}
+
+// rewriteLines keeps only the lines for which keep returns true and reports
+// how many were dropped. The file is rewritten in place so that loggers
+// holding it open keep appending to the same file; callers must pause them.
+func rewriteLines(path string, keep func(string) bool) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	var buf bytes.Buffer
+	dropped := 0
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		if keep(sc.Text()) {
+			buf.WriteString(sc.Text())
+			buf.WriteByte('\n')
+		} else {
+			dropped++
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return 0, fmt.Errorf("scan %s: %w", path, err)
+	}
+	if dropped == 0 {
+		return 0, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), info.Mode().Perm()); err != nil {
+		return 0, fmt.Errorf("rewrite %s: %w", path, err)
+	}
+	return dropped, nil
+}
+
+// lineTimestamp parses the bracketed timestamp that starts a contacts.log
+// line ("[2006-01-02T15:04:05Z07:00] …") or an app.log line
+// ("[2006/01/02T15:04:05.000] …").
+func lineTimestamp(line string) (time.Time, bool) {
+	end := strings.IndexByte(line, ']')
+	if !strings.HasPrefix(line, "[") || end < 0 {
+		return time.Time{}, false
+	}
+	if ts, err := time.Parse(time.RFC3339, line[1:end]); err == nil {
+		return ts, true
+	}
+	if ts, err := time.ParseInLocation("2006/01/02T15:04:05.000", line[1:end], time.Local); err == nil {
+		return ts, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// hourlyLimit allows each key, such as a client IP, a number of events per
+// sliding hour.
+type hourlyLimit struct {
+	perHour int
+
+	mu     sync.Mutex
+	recent map[string][]time.Time // key -> events in the last hour
+}
+
+// newHourlyLimit allows perHour events per key; zero disables the limit.
+func newHourlyLimit(perHour int) *hourlyLimit {
+	return &hourlyLimit{perHour: perHour, recent: map[string][]time.Time{}}
+}
+
+// allow records an event for key and reports whether it is within the
+// hourly limit.
+func (l *hourlyLimit) allow(key string, now time.Time) bool {
+	if l.perHour <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	cutoff := now.Add(-time.Hour)
+	for k, times := range l.recent {
+		i := 0
+		for i < len(times) && times[i].Before(cutoff) {
+			i++
+		}
+		if i == len(times) {
+			delete(l.recent, k)
+		} else {
+			l.recent[k] = times[i:]
+		}
+	}
+	if len(l.recent[key]) >= l.perHour {
+		return false
+	}
+	l.recent[key] = append(l.recent[key], now)
+	return true
+}
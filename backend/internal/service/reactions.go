@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gookit/slog"
+)
+
+// ErrTooManyReactionItems is returned when reacting to a new item would
+// exceed the store's item limit.
+var ErrTooManyReactionItems = errors.New("too many reaction items")
+
+// ReactionPolicy limits what visitors may react with, to what, and how fast.
+type ReactionPolicy struct {
+	Emojis []string
+	// Items are the items visitors may react to; empty allows any
+	// well-formed item, up to the store's item limit.
+	Items []string
+	// PerSecond is the sustained rate at which one connection may react;
+	// Burst is how many reactions it may send at once.
+	PerSecond float64
+	Burst     int
+}
+
+// Allows reports whether emoji is an accepted reaction.
+func (p ReactionPolicy) Allows(emoji string) bool {
+	for _, e := range p.Emojis {
+		if e == emoji {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsItem reports whether item may receive reactions.
+func (p ReactionPolicy) AllowsItem(item string) bool {
+	if len(p.Items) == 0 {
+		return true
+	}
+	for _, it := range p.Items {
+		if it == item {
+			return true
+		}
+	}
+	return false
+}
+
+// ReactionStore persists all-time reaction totals, keyed by item and then
+// by emoji.
+type ReactionStore interface {
+	// Add adds counts to item's totals and returns the new totals. It
+	// returns ErrTooManyReactionItems for a new item once the store is full.
+	Add(item string, counts map[string]int) (map[string]int, error)
+	// Accepts reports whether Add would take reactions to item.
+	Accepts(item string) bool
+	// Totals returns item's totals; items nobody reacted to have none.
+	Totals(item string) map[string]int
+	// All returns the totals of every item.
+	All() map[string]map[string]int
+}
+
+// FileReactionStore keeps reaction totals in memory and mirrors them to a
+// JSON file. Reactions arrive in bursts, so changes are written by Run
+// rather than on every Add.
+type FileReactionStore struct {
+	filePath string
+	maxItems int
+
+	mu     sync.Mutex
+	totals map[string]map[string]int
+	dirty  bool
+}
+
+// NewFileReactionStore loads existing totals from path. Once maxItems items
+// have reactions no new ones are added; zero allows any number.
+func NewFileReactionStore(path string, maxItems int) (*FileReactionStore, error) {
+	s := &FileReactionStore{filePath: path, maxItems: maxItems, totals: map[string]map[string]int{}}
+	if err := loadJSONFile(path, &s.totals); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileReactionStore) Add(item string, counts map[string]int) (map[string]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.totals[item]
+	if t == nil {
+		if s.full() {
+			return nil, ErrTooManyReactionItems
+		}
+		t = map[string]int{}
+		s.totals[item] = t
+	}
+	for emoji, n := range counts {
+		t[emoji] += n
+	}
+	s.dirty = true
+	return copyCounts(t), nil
+}
+
+func (s *FileReactionStore) Accepts(item string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.totals[item] != nil || !s.full()
+}
+
+func (s *FileReactionStore) full() bool {
+	return s.maxItems > 0 && len(s.totals) >= s.maxItems
+}
+
+func (s *FileReactionStore) Totals(item string) map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyCounts(s.totals[item])
+}
+
+func (s *FileReactionStore) All() map[string]map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all := make(map[string]map[string]int, len(s.totals))
+	for item, t := range s.totals {
+		all[item] = copyCounts(t)
+	}
+	return all
+}
+
+// Flush writes pending changes to disk.
+func (s *FileReactionStore) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return nil
+	}
+	if err := saveJSONFile(s.filePath, s.totals); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+// Run flushes totals every interval until ctx is done. Callers flush once
+// more after that.
+func (s *FileReactionStore) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := s.Flush(); err != nil {
+			slog.Error("[reactions] Failed to save reactions", "error", err)
+		}
+	}
+}
+
+func copyCounts(m map[string]int) map[string]int {
+	out := make(map[string]int, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"portfolio-backend/internal/envelope"
+	"portfolio-backend/internal/model"
+)
+
+// RecordSealer encrypts personal data in stored records. A sealer without a
+// keyring is a no-op, so stores behave identically with encryption disabled.
+//
+// Stores only ever seal; opening is left to the admin-facing callers that
+// actually need plaintext.
+type RecordSealer struct {
+	keys     *envelope.Keyring
+	indexKey []byte
+}
+
+// NewRecordSealer creates a sealer. keys may be nil to disable encryption.
+// indexKey keys the blind email index; it should outlive key rotations.
+func NewRecordSealer(keys *envelope.Keyring, indexKey []byte) *RecordSealer {
+	return &RecordSealer{keys: keys, indexKey: indexKey}
+}
+
+// Enabled reports whether records are encrypted.
+func (s *RecordSealer) Enabled() bool { return s != nil && s.keys != nil }
+
+// EmailIndex returns the keyed hash used to find records by address.
+func (s *RecordSealer) EmailIndex(email string) string {
+	if !s.Enabled() {
+		return ""
+	}
+	mac := hmac.New(sha256.New, s.indexKey)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// BlobAddress returns the name a blob is stored under: a keyed hash of its
+// content, so the files do not reveal whether a known file was uploaded.
+// Without encryption it is the plain SHA-256. The key is derived from the
+// index key, which outlives rotations, so identical uploads still share a
+// blob.
+func (s *RecordSealer) BlobAddress(data []byte) string {
+	if !s.Enabled() {
+		h := sha256.Sum256(data)
+		return hex.EncodeToString(h[:])
+	}
+	key := hmac.New(sha256.New, s.indexKey)
+	key.Write([]byte("blob-address"))
+	mac := hmac.New(sha256.New, key.Sum(nil))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// MatchesEmail reports whether rec belongs to email without decrypting it.
+func (s *RecordSealer) MatchesEmail(rec model.ContactRecord, email string) bool {
+	if rec.Sealed != nil {
+		return s.Enabled() && hmac.Equal([]byte(rec.EmailIndex), []byte(s.EmailIndex(email)))
+	}
+	return strings.EqualFold(rec.Contact.Email, email)
+}
+
+// SealContact moves the contact payload and thread contents into envelopes.
+func (s *RecordSealer) SealContact(rec *model.ContactRecord) error {
+	if !s.Enabled() {
+		return nil
+	}
+	if rec.Sealed == nil && !rec.Anonymized {
+		env, err := s.seal(rec.Contact, "contact:"+rec.ID)
+		if err != nil {
+			return err
+		}
+		rec.EmailIndex = s.EmailIndex(rec.Contact.Email)
+		rec.Sealed = env
+		rec.Contact = model.ContactRequest{}
+	}
+	for i := range rec.Thread {
+		if err := s.SealMessage(&rec.Thread[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OpenContact restores a sealed record's payload and thread in place.
+func (s *RecordSealer) OpenContact(rec *model.ContactRecord) error {
+	if rec.Sealed != nil {
+		if err := s.open(rec.Sealed, "contact:"+rec.ID, &rec.Contact); err != nil {
+			return fmt.Errorf("open contact %s: %w", rec.ID, err)
+		}
+		rec.Sealed = nil
+		rec.EmailIndex = ""
+	}
+	for i := range rec.Thread {
+		if err := s.OpenMessage(&rec.Thread[i]); err != nil {
+			return fmt.Errorf("open contact %s: %w", rec.ID, err)
+		}
+	}
+	return nil
+}
+
+type sealedMessage struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// SealMessage encrypts the personal fields of a thread message.
+func (s *RecordSealer) SealMessage(m *model.ThreadMessage) error {
+	if !s.Enabled() || m.Sealed != nil {
+		return nil
+	}
+	env, err := s.seal(sealedMessage{m.From, m.To, m.Subject, m.Body}, "message:"+m.MessageID)
+	if err != nil {
+		return err
+	}
+	m.Sealed = env
+	m.From, m.To, m.Subject, m.Body = "", "", "", ""
+	return nil
+}
+
+// OpenMessage decrypts a thread message in place.
+func (s *RecordSealer) OpenMessage(m *model.ThreadMessage) error {
+	if m.Sealed == nil {
+		return nil
+	}
+	var p sealedMessage
+	if err := s.open(m.Sealed, "message:"+m.MessageID, &p); err != nil {
+		return fmt.Errorf("open message %s: %w", m.MessageID, err)
+	}
+	m.From, m.To, m.Subject, m.Body = p.From, p.To, p.Subject, p.Body
+	m.Sealed = nil
+	return nil
+}
+
+type sealedChat struct {
+	Message  string `json:"message"`
+	Response string `json:"response"`
+}
+
+// SealChat encrypts a chat exchange in place.
+func (s *RecordSealer) SealChat(ex *model.ChatExchange) error {
+	if !s.Enabled() || ex.Sealed != nil {
+		return nil
+	}
+	env, err := s.seal(sealedChat{ex.Message, ex.Response}, "chat:"+ex.ID)
+	if err != nil {
+		return err
+	}
+	ex.Sealed = env
+	ex.Message, ex.Response = "", ""
+	return nil
+}
+
+// OpenChat decrypts a chat exchange in place.
+func (s *RecordSealer) OpenChat(ex *model.ChatExchange) error {
+	if ex.Sealed == nil {
+		return nil
+	}
+	var p sealedChat
+	if err := s.open(ex.Sealed, "chat:"+ex.ID, &p); err != nil {
+		return fmt.Errorf("open chat %s: %w", ex.ID, err)
+	}
+	ex.Message, ex.Response = p.Message, p.Response
+	ex.Sealed = nil
+	return nil
+}
+
+// sealedLinePrefix marks contacts.log lines written with encryption enabled:
+// "[ts] sealed:<email index>:<base64 envelope JSON>".
+const sealedLinePrefix = "sealed:"
+
+// SealLine encrypts a log line body, prefixing it with the email index so
+// erasure can still find it.
+func (s *RecordSealer) SealLine(email, line string) (string, error) {
+	if !s.Enabled() {
+		return line, nil
+	}
+	env, err := s.keys.Seal([]byte(line), nil)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(env)
+	if err != nil {
+		return "", err
+	}
+	return sealedLinePrefix + s.EmailIndex(email) + ":" + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// OpenLine decrypts a line body produced by SealLine. Other lines are returned as-is.
+func (s *RecordSealer) OpenLine(line string) (string, error) {
+	if !strings.HasPrefix(line, sealedLinePrefix) {
+		return line, nil
+	}
+	env, ok := parseSealedLine(line)
+	if !ok {
+		return "", fmt.Errorf("malformed sealed line")
+	}
+	if !s.Enabled() {
+		return "", fmt.Errorf("line is encrypted but no keys are configured")
+	}
+	pt, err := s.keys.Open(env, nil)
+	return string(pt), err
+}
+
+func parseSealedLine(line string) (*envelope.Envelope, bool) {
+	rest, ok := strings.CutPrefix(line, sealedLinePrefix)
+	if !ok {
+		return nil, false
+	}
+	_, enc, _ := strings.Cut(rest, ":")
+	data, err := base64.StdEncoding.DecodeString(enc)
+	if err != nil {
+		return nil, false
+	}
+	var env envelope.Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, false
+	}
+	return &env, true
+}
+
+// LineMatches reports whether a log line mentions email, either in clear or
+// via the email index of a sealed line.
+func (s *RecordSealer) LineMatches(line, email string) bool {
+	if containsFold(line, email) {
+		return true
+	}
+	return s.Enabled() && strings.Contains(line, sealedLinePrefix+s.EmailIndex(email)+":")
+}
+
+// NeedsRotation reports whether env should be re-encrypted under the active key.
+func (s *RecordSealer) NeedsRotation(env *envelope.Envelope) bool {
+	return s.Enabled() && env != nil && s.keys.NeedsRotation(env)
+}
+
+// Keys exposes the keyring for components that seal raw bytes (e.g. blobs).
+func (s *RecordSealer) Keys() *envelope.Keyring {
+	if !s.Enabled() {
+		return nil
+	}
+	return s.keys
+}
+
+func (s *RecordSealer) seal(v any, aad string) (*envelope.Envelope, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("encode for sealing: %w", err)
+	}
+	return s.keys.Seal(data, []byte(aad))
+}
+
+func (s *RecordSealer) open(env *envelope.Envelope, aad string, v any) error {
+	if !s.Enabled() {
+		return fmt.Errorf("record is encrypted but no keys are configured")
+	}
+	data, err := s.keys.Open(env, []byte(aad))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
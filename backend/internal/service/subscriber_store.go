@@ -0,0 +1,114 @@
+package service
+
+import (
+	"strings"
+	"sync"
+
+	"portfolio-backend/internal/model"
+)
+
+// SubscriberStore persists newsletter subscriptions, one per address.
+type SubscriberStore interface {
+	Create(sub model.Subscriber) (model.Subscriber, error)
+	Get(id string) (model.Subscriber, bool)
+	FindByEmail(email string) (model.Subscriber, bool)
+	List() []model.Subscriber
+	Update(sub model.Subscriber) error
+	Delete(id string) error
+}
+
+// FileSubscriberStore keeps subscribers in memory and mirrors them to a JSON file.
+type FileSubscriberStore struct {
+	filePath string
+
+	mu   sync.RWMutex
+	subs []model.Subscriber
+}
+
+// NewFileSubscriberStore loads existing subscribers from path.
+func NewFileSubscriberStore(path string) (*FileSubscriberStore, error) {
+	s := &FileSubscriberStore{filePath: path}
+	if err := loadJSONFile(path, &s.subs); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSubscriberStore) Create(sub model.Subscriber) (model.Subscriber, error) {
+	if sub.ID == "" {
+		sub.ID = newID()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subs = append(s.subs, sub)
+	if err := saveJSONFile(s.filePath, s.subs); err != nil {
+		s.subs = s.subs[:len(s.subs)-1]
+		return model.Subscriber{}, err
+	}
+	return sub, nil
+}
+
+func (s *FileSubscriberStore) Get(id string) (model.Subscriber, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, sub := range s.subs {
+		if sub.ID == id {
+			return sub, true
+		}
+	}
+	return model.Subscriber{}, false
+}
+
+func (s *FileSubscriberStore) FindByEmail(email string) (model.Subscriber, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, sub := range s.subs {
+		if strings.EqualFold(sub.Email, email) {
+			return sub, true
+		}
+	}
+	return model.Subscriber{}, false
+}
+
+// List returns subscribers oldest first.
+func (s *FileSubscriberStore) List() []model.Subscriber {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]model.Subscriber(nil), s.subs...)
+}
+
+func (s *FileSubscriberStore) Update(sub model.Subscriber) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.subs {
+		if s.subs[i].ID != sub.ID {
+			continue
+		}
+		prev := s.subs[i]
+		s.subs[i] = sub
+		if err := saveJSONFile(s.filePath, s.subs); err != nil {
+			s.subs[i] = prev
+			return err
+		}
+		return nil
+	}
+	return ErrSubscriberNotFound
+}
+
+func (s *FileSubscriberStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.subs {
+		if s.subs[i].ID != id {
+			continue
+		}
+		prev := s.subs
+		s.subs = append(s.subs[:i:i], s.subs[i+1:]...)
+		if err := saveJSONFile(s.filePath, s.subs); err != nil {
+			s.subs = prev
+			return err
+		}
+		return nil
+	}
+	return ErrSubscriberNotFound
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gookit/slog"
+)
+
+// Tasks tracks background work started by requests, such as emails sent
+// after the response, so shutdown can wait for it.
+type Tasks struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewTasks creates an empty task group.
+func NewTasks() *Tasks {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Tasks{ctx: ctx, cancel: cancel}
+}
+
+// Go runs f in a new goroutine. f's context is cancelled when Wait gives
+// up, so outbound calls can be abandoned. Once Wait has been called no new
+// work is accepted, and Go reports false without running f.
+func (t *Tasks) Go(f func(ctx context.Context)) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		slog.Warn("[tasks] Refused task after shutdown began")
+		return false
+	}
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		f(t.ctx)
+	}()
+	return true
+}
+
+// Wait stops accepting tasks and waits for every running task to finish,
+// or for ctx to be done, in which case the tasks' context is cancelled.
+func (t *Tasks) Wait(ctx context.Context) error {
+	t.mu.Lock()
+	t.closed = true
+	t.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+	defer t.cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/model"
+)
+
+// ErrContactNotFound is returned when a thread operation targets an unknown contact.
+var ErrContactNotFound = errors.New("contact not found")
+
+// ErrReplyNotStored is returned with the message when a reply was emailed
+// but could not be added to its thread; sending it again would duplicate it.
+var ErrReplyNotStored = errors.New("reply sent but not stored")
+
+// ContactThreads sends replies to contacts and attaches inbound answers to the
+// matching conversation using RFC 5322 In-Reply-To/References headers.
+type ContactThreads struct {
+	store     ContactStore
+	email     EmailService
+	fromEmail string
+	msgDomain string
+	sealer    *RecordSealer
+}
+
+// NewContactThreads creates a thread manager. fromEmail is recorded as the
+// sender of outbound replies; its domain is used for generated Message-IDs.
+// sealer opens encrypted records when composing a reply.
+func NewContactThreads(store ContactStore, email EmailService, fromEmail string, sealer *RecordSealer) *ContactThreads {
+	return &ContactThreads{
+		store:     store,
+		email:     email,
+		fromEmail: fromEmail,
+		msgDomain: MessageDomain(fromEmail),
+		sealer:    sealer,
+	}
+}
+
+// Reply emails the contact and stores the outbound message on its thread.
+// If the email went out but storing failed, the message is returned with
+// ErrReplyNotStored.
+func (t *ContactThreads) Reply(ctx context.Context, id string, req model.ReplyRequest) (model.ThreadMessage, error) {
+	rec, ok := t.store.Get(id)
+	if !ok {
+		return model.ThreadMessage{}, ErrContactNotFound
+	}
+	if err := t.sealer.OpenContact(&rec); err != nil {
+		return model.ThreadMessage{}, err
+	}
+
+	subject := req.Subject
+	if subject == "" {
+		subject = replySubject(rec)
+	}
+
+	refs := threadReferences(rec)
+	msg := model.ThreadMessage{
+		MessageID:  NewMessageID("reply."+newID(), t.msgDomain),
+		Direction:  model.DirectionOutbound,
+		From:       t.fromEmail,
+		To:         rec.Contact.Email,
+		Subject:    subject,
+		Body:       req.Message,
+		InReplyTo:  refs[len(refs)-1],
+		References: refs,
+		CreatedAt:  time.Now(),
+	}
+
+	err := t.email.Deliver(ctx, model.Email{
+		To:      []string{rec.Contact.Email},
+		Subject: subject,
+		Text:    req.Message,
+		HTML:    "<p>" + strings.ReplaceAll(html.EscapeString(req.Message), "\n", "<br>") + "</p>",
+		Headers: map[string]string{
+			"Message-ID":  msg.MessageID,
+			"In-Reply-To": msg.InReplyTo,
+			"References":  strings.Join(msg.References, " "),
+		},
+	})
+	if err != nil {
+		return model.ThreadMessage{}, fmt.Errorf("send reply: %w", err)
+	}
+
+	if _, err := t.store.AppendMessage(id, msg); err != nil {
+		return msg, fmt.Errorf("%w: %v", ErrReplyNotStored, err)
+	}
+
+	slog.Info("[thread] Reply sent", "contact", id, "to", msg.To, "messageId", msg.MessageID)
+	return msg, nil
+}
+
+// AttachInbound stores a received email on the thread it answers. The thread
+// is located via In-Reply-To, then References, then the sender's address.
+func (t *ContactThreads) AttachInbound(in model.InboundEmail) (model.ContactRecord, error) {
+	rec, ok := t.store.FindByMessageID(in.InReplyTo)
+	for i := len(in.References) - 1; !ok && i >= 0; i-- {
+		rec, ok = t.store.FindByMessageID(in.References[i])
+	}
+	if !ok {
+		rec, ok = t.latestBySender(in.From)
+	}
+	if !ok {
+		return model.ContactRecord{}, ErrContactNotFound
+	}
+
+	msgID := NormalizeMessageID(in.MessageID)
+	if msgID == "" {
+		msgID = NewMessageID("inbound."+newID(), t.msgDomain)
+	}
+
+	msg := model.ThreadMessage{
+		MessageID:  msgID,
+		Direction:  model.DirectionInbound,
+		From:       in.From,
+		To:         in.To,
+		Subject:    in.Subject,
+		Body:       in.Text,
+		InReplyTo:  NormalizeMessageID(in.InReplyTo),
+		References: in.References,
+		CreatedAt:  time.Now(),
+	}
+
+	updated, err := t.store.AppendMessage(rec.ID, msg)
+	if err != nil {
+		return model.ContactRecord{}, fmt.Errorf("store inbound: %w", err)
+	}
+
+	slog.Info("[thread] Inbound reply attached", "contact", rec.ID, "from", in.From, "messageId", msgID)
+	return updated, nil
+}
+
+func (t *ContactThreads) latestBySender(from string) (model.ContactRecord, bool) {
+	addr := from
+	if parsed, err := mail.ParseAddress(from); err == nil {
+		addr = parsed.Address
+	}
+	if addr == "" {
+		return model.ContactRecord{}, false
+	}
+	// List is newest first, so the first match is the latest conversation.
+	for _, rec := range t.store.List() {
+		if t.sealer.MatchesEmail(rec, addr) {
+			return rec, true
+		}
+	}
+	return model.ContactRecord{}, false
+}
+
+// threadReferences returns the Message-IDs of the conversation in order,
+// starting with the original submission.
+func threadReferences(rec model.ContactRecord) []string {
+	refs := []string{rec.MessageID}
+	for _, m := range rec.Thread {
+		refs = append(refs, m.MessageID)
+	}
+	return refs
+}
+
+func replySubject(rec model.ContactRecord) string {
+	subject := rec.Contact.Subject
+	if subject == "" {
+		subject = "Your message"
+	}
+	if strings.HasPrefix(strings.ToLower(subject), "re:") {
+		return subject
+	}
+	return "Re: " + subject
+}
+
+// NewMessageID builds an RFC 5322 Message-ID of the form <local@domain>.
+func NewMessageID(local, domain string) string {
+	return "<" + local + "@" + domain + ">"
+}
+
+// NormalizeMessageID trims whitespace and ensures the ID is wrapped in angle brackets.
+func NormalizeMessageID(id string) string {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return ""
+	}
+	if !strings.HasPrefix(id, "<") {
+		id = "<" + id
+	}
+	if !strings.HasSuffix(id, ">") {
+		id += ">"
+	}
+	return id
+}
+
+// MessageDomain extracts the domain of an address such as "Name <user@host>",
+// falling back to "localhost" when it cannot be parsed.
+func MessageDomain(from string) string {
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if at := strings.LastIndex(addr.Address, "@"); at >= 0 {
+			return addr.Address[at+1:]
+		}
+	}
+	return "localhost"
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gookit/slog"
+
+	"portfolio-backend/internal/model"
+)
+
+// ErrUnknownRange is returned for history ranges other than those in
+// HistoryRanges.
+var ErrUnknownRange = errors.New("unknown history range")
+
+// historyRange is a selectable history window and the width of the points
+// it is downsampled to.
+type historyRange struct {
+	span, step time.Duration
+}
+
+// HistoryRanges are the windows VisitorHistory.Report serves, each
+// downsampled to a few hundred points.
+var HistoryRanges = map[string]historyRange{
+	"24h": {24 * time.Hour, 5 * time.Minute},
+	"7d":  {7 * 24 * time.Hour, 30 * time.Minute},
+	"30d": {30 * 24 * time.Hour, 2 * time.Hour},
+}
+
+// historyMinutes is how many per-minute samples the ring holds: the longest
+// range.
+const historyMinutes = 30 * 24 * 60
+
+// noSample marks minutes without a sample, e.g. while the server was down.
+const noSample = -1
+
+// VisitorHistory keeps a per-minute series of concurrent visitors in a ring
+// buffer covering the longest range, plus the all-time and daily peaks,
+// which outlive the ring. It is mirrored to a JSON file by Run.
+type VisitorHistory struct {
+	filePath string
+
+	mu     sync.Mutex
+	counts []int // indexed by unix minute modulo historyMinutes
+	last   int64 // unix minute of the newest sample
+	peak   model.VisitorPeak
+	daily  map[string]model.VisitorPeak // by UTC date
+	dirty  bool
+}
+
+// visitorHistoryFile is the persisted form: counts[i] is the sample of
+// minute start+i, oldest first.
+type visitorHistoryFile struct {
+	Start  int64                        `json:"start"`
+	Counts []int                        `json:"counts"`
+	Peak   model.VisitorPeak            `json:"peak"`
+	Daily  map[string]model.VisitorPeak `json:"daily"`
+}
+
+// NewVisitorHistory loads an existing series from path.
+func NewVisitorHistory(path string) (*VisitorHistory, error) {
+	h := &VisitorHistory{filePath: path, counts: make([]int, historyMinutes), daily: map[string]model.VisitorPeak{}}
+	for i := range h.counts {
+		h.counts[i] = noSample
+	}
+	var f visitorHistoryFile
+	if err := loadJSONFile(path, &f); err != nil {
+		return nil, err
+	}
+	for i, n := range f.Counts {
+		h.setLocked(f.Start+int64(i), n)
+	}
+	h.peak = f.Peak
+	for day, p := range f.Daily {
+		h.daily[day] = p
+	}
+	return h, nil
+}
+
+// Sample records count as the number of concurrent visitors at now. Several
+// samples in one minute keep the highest.
+func (h *VisitorHistory) Sample(now time.Time, count int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.setLocked(now.Unix()/60, count)
+	if count > h.peak.Count {
+		h.peak = model.VisitorPeak{Count: count, At: now.UTC()}
+	}
+	day := now.UTC().Format(time.DateOnly)
+	if p, ok := h.daily[day]; !ok || count > p.Count {
+		h.daily[day] = model.VisitorPeak{Count: count, At: now.UTC()}
+	}
+	h.dirty = true
+}
+
+// setLocked stores n for minute, clearing the minutes skipped since the
+// newest sample. Minutes older than the ring are ignored.
+func (h *VisitorHistory) setLocked(minute int64, n int) {
+	if n < 0 || minute <= h.last-historyMinutes {
+		return
+	}
+	if minute > h.last {
+		for m := max(h.last+1, minute-historyMinutes+1); m < minute; m++ {
+			h.counts[m%historyMinutes] = noSample
+		}
+		h.counts[minute%historyMinutes] = n
+		h.last = minute
+		return
+	}
+	h.counts[minute%historyMinutes] = max(h.counts[minute%historyMinutes], n)
+}
+
+// Report returns the series for rng ("24h", "7d" or "30d") ending at now,
+// with the all-time peak, today's peak and the daily peaks in the range.
+func (h *VisitorHistory) Report(rng string, now time.Time) (model.VisitorHistory, error) {
+	r, ok := HistoryRanges[rng]
+	if !ok {
+		return model.VisitorHistory{}, ErrUnknownRange
+	}
+	now = now.UTC()
+	stepMinutes := int64(r.step / time.Minute)
+	// Points are aligned to their step so they do not shift between calls.
+	end := now.Unix()/60/stepMinutes*stepMinutes + stepMinutes
+	start := end - int64(r.span/time.Minute)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	rep := model.VisitorHistory{
+		Range:      rng,
+		Step:       int(r.step / time.Second),
+		Points:     []model.VisitorHistoryPoint{},
+		Peak:       h.peak,
+		Today:      h.daily[now.Format(time.DateOnly)],
+		DailyPeaks: []model.DailyVisitorPeak{},
+	}
+	for from := start; from < end; from += stepMinutes {
+		p := model.VisitorHistoryPoint{At: time.Unix(from*60, 0).UTC()}
+		sum, samples := 0, 0
+		for m := from; m < from+stepMinutes; m++ {
+			n := h.sampleLocked(m)
+			if n == noSample {
+				continue
+			}
+			sum += n
+			samples++
+			p.Max = max(p.Max, n)
+		}
+		if samples == 0 {
+			continue
+		}
+		p.Avg = float64(sum) / float64(samples)
+		rep.Points = append(rep.Points, p)
+	}
+
+	firstDay := time.Unix(start*60, 0).UTC().Format(time.DateOnly)
+	for day, p := range h.daily {
+		if day >= firstDay {
+			rep.DailyPeaks = append(rep.DailyPeaks, model.DailyVisitorPeak{Date: day, Count: p.Count, At: p.At})
+		}
+	}
+	sort.Slice(rep.DailyPeaks, func(i, j int) bool { return rep.DailyPeaks[i].Date < rep.DailyPeaks[j].Date })
+	return rep, nil
+}
+
+// sampleLocked returns the sample of minute, or noSample.
+func (h *VisitorHistory) sampleLocked(minute int64) int {
+	if minute > h.last || minute <= h.last-historyMinutes {
+		return noSample
+	}
+	return h.counts[minute%historyMinutes]
+}
+
+// Flush writes pending samples to disk.
+func (h *VisitorHistory) Flush() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.dirty {
+		return nil
+	}
+	f := visitorHistoryFile{Peak: h.peak, Daily: h.daily}
+	for m := h.last - historyMinutes + 1; m <= h.last; m++ {
+		n := h.sampleLocked(m)
+		if n == noSample && len(f.Counts) == 0 {
+			continue
+		}
+		if len(f.Counts) == 0 {
+			f.Start = m
+		}
+		f.Counts = append(f.Counts, n)
+	}
+	if err := saveJSONFile(h.filePath, f); err != nil {
+		return err
+	}
+	h.dirty = false
+	return nil
+}
+
+// Run flushes the series every interval until ctx is done. Callers flush
+// once more after that.
+func (h *VisitorHistory) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := h.Flush(); err != nil {
+			slog.Error("[visitor] Failed to save visitor history", "error", err)
+		}
+	}
+}
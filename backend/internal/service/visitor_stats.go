@@ -0,0 +1,96 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"portfolio-backend/internal/model"
+)
+
+// VisitorStats tracks visitor sessions and peak concurrency in hourly
+// buckets so digests can report on any recent window.
+type VisitorStats struct {
+	keep time.Duration
+
+	mu      sync.Mutex
+	current int
+	buckets []visitorBucket
+}
+
+type visitorBucket struct {
+	hour     time.Time
+	sessions int
+	peak     int
+	peakAt   time.Time
+}
+
+// NewVisitorStats creates a tracker that retains buckets for keep.
+func NewVisitorStats(keep time.Duration) *VisitorStats {
+	return &VisitorStats{keep: keep}
+}
+
+// Connected records a new session; current is the count including it.
+func (s *VisitorStats) Connected(current int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.bucketLocked(time.Now())
+	b.sessions++
+	s.setLocked(b, current)
+}
+
+// Disconnected records the count after a session ended.
+func (s *VisitorStats) Disconnected(current int) { s.Observe(current) }
+
+// Observe records a count change that is not a new session, such as
+// visitors coming or going on another instance.
+func (s *VisitorStats) Observe(current int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setLocked(s.bucketLocked(time.Now()), current)
+}
+
+// Summary reports sessions and the peak for buckets overlapping [since, now].
+func (s *VisitorStats) Summary(since time.Time) model.VisitorSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sum := model.VisitorSummary{Current: s.current}
+	from := since.Truncate(time.Hour)
+	for _, b := range s.buckets {
+		if b.hour.Before(from) {
+			continue
+		}
+		sum.Sessions += b.sessions
+		if b.peak > sum.Peak {
+			sum.Peak, sum.PeakAt = b.peak, b.peakAt
+		}
+	}
+	// Visitors connected since before the last bucket still count.
+	if s.current > sum.Peak {
+		sum.Peak, sum.PeakAt = s.current, time.Now()
+	}
+	return sum
+}
+
+func (s *VisitorStats) setLocked(b *visitorBucket, current int) {
+	s.current = current
+	if current > b.peak {
+		b.peak, b.peakAt = current, time.Now()
+	}
+}
+
+// bucketLocked returns the bucket for now's hour, starting a new one (seeded
+// with the current count) and dropping expired ones as needed.
+func (s *VisitorStats) bucketLocked(now time.Time) *visitorBucket {
+	hour := now.Truncate(time.Hour)
+	if n := len(s.buckets); n > 0 && s.buckets[n-1].hour.Equal(hour) {
+		return &s.buckets[n-1]
+	}
+
+	cutoff := now.Add(-s.keep)
+	i := 0
+	for i < len(s.buckets) && s.buckets[i].hour.Before(cutoff) {
+		i++
+	}
+	s.buckets = append(s.buckets[i:], visitorBucket{hour: hour, peak: s.current, peakAt: now})
+	return &s.buckets[len(s.buckets)-1]
+}
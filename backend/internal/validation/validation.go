@@ -0,0 +1,322 @@
+// Package validation sanitizes and validates user-submitted input.
+package validation
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+
+	"portfolio-backend/internal/model"
+)
+
+//go:embed disposable_domains.txt
+var defaultDisposable []byte
+
+// Errors maps a field name to a human-readable problem with it.
+type Errors map[string]string
+
+func (e Errors) Error() string {
+	fields := make([]string, 0, len(e))
+	for f := range e {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = f + ": " + e[f]
+	}
+	return strings.Join(parts, "; ")
+}
+
+// MXResolver looks up mail exchangers for a domain. *net.Resolver satisfies it.
+type MXResolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+}
+
+// Limits are the maximum lengths, in runes, of each contact field.
+type Limits struct {
+	Name    int
+	Email   int
+	Subject int
+	Message int
+}
+
+// DefaultLimits are generous enough for real messages while bounding abuse.
+var DefaultLimits = Limits{Name: 100, Email: 254, Subject: 200, Message: 5000}
+
+// Options configures a ContactValidator.
+type Options struct {
+	Limits Limits
+	// DisposableFile optionally points to an extra blocklist in the same
+	// format as the embedded disposable_domains.txt.
+	DisposableFile string
+	// Resolver enables MX checks when non-nil.
+	Resolver  MXResolver
+	MXTimeout time.Duration
+}
+
+// ContactValidator normalizes and validates contact form submissions.
+type ContactValidator struct {
+	limits     Limits
+	disposable map[string]struct{}
+	resolver   MXResolver
+	mxTimeout  time.Duration
+}
+
+// NewContactValidator builds a validator from opts, loading the embedded
+// disposable-domain list plus any extra file.
+func NewContactValidator(opts Options) (*ContactValidator, error) {
+	v := &ContactValidator{
+		limits:     opts.Limits,
+		disposable: make(map[string]struct{}),
+		resolver:   opts.Resolver,
+		mxTimeout:  opts.MXTimeout,
+	}
+	if v.limits == (Limits{}) {
+		v.limits = DefaultLimits
+	}
+	if v.mxTimeout == 0 {
+		v.mxTimeout = 3 * time.Second
+	}
+
+	v.addDomains(defaultDisposable)
+	if opts.DisposableFile != "" {
+		data, err := os.ReadFile(opts.DisposableFile)
+		if err != nil {
+			return nil, fmt.Errorf("read disposable domains: %w", err)
+		}
+		v.addDomains(data)
+	}
+	return v, nil
+}
+
+func (v *ContactValidator) addDomains(data []byte) {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.ToLower(strings.TrimSpace(sc.Text()))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		v.disposable[line] = struct{}{}
+	}
+}
+
+// Validate sanitizes req in place and returns per-field errors, or nil when
+// the submission is acceptable.
+func (v *ContactValidator) Validate(ctx context.Context, req *model.ContactRequest) Errors {
+	req.Name = SanitizeLine(req.Name)
+	req.Email = SanitizeLine(req.Email)
+	req.Subject = SanitizeLine(req.Subject)
+	req.Message = SanitizeText(req.Message)
+
+	errs := Errors{}
+	checkText(errs, "name", req.Name, v.limits.Name)
+	checkText(errs, "subject", req.Subject, v.limits.Subject)
+	checkText(errs, "message", req.Message, v.limits.Message)
+
+	if addr, msg := v.checkEmail(ctx, req.Email); msg != "" {
+		errs["email"] = msg
+	} else {
+		req.Email = addr
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func checkText(errs Errors, field, value string, max int) {
+	n := len([]rune(value))
+	switch {
+	case n == 0:
+		errs[field] = "This field is required"
+	case n > max:
+		errs[field] = fmt.Sprintf("Must be at most %d characters", max)
+	}
+}
+
+// checkEmail returns the bare address on success, or an error message.
+func (v *ContactValidator) checkEmail(ctx context.Context, raw string) (string, string) {
+	if raw == "" {
+		return "", "This field is required"
+	}
+	if len([]rune(raw)) > v.limits.Email {
+		return "", fmt.Sprintf("Must be at most %d characters", v.limits.Email)
+	}
+
+	addr, err := mail.ParseAddress(raw)
+	if err != nil || addr.Name != "" {
+		return "", "Enter a valid email address"
+	}
+
+	at := strings.LastIndex(addr.Address, "@")
+	domain := strings.ToLower(addr.Address[at+1:])
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", "Enter a valid email address"
+	}
+
+	if v.isDisposable(domain) {
+		return "", "Disposable email addresses are not accepted"
+	}
+
+	if v.resolver != nil && !v.hasMX(ctx, domain) {
+		return "", "This email domain cannot receive mail"
+	}
+
+	return addr.Address[:at+1] + domain, ""
+}
+
+// isDisposable matches the domain and each of its parent domains.
+func (v *ContactValidator) isDisposable(domain string) bool {
+	for d := domain; d != ""; {
+		if _, ok := v.disposable[d]; ok {
+			return true
+		}
+		dot := strings.IndexByte(d, '.')
+		if dot < 0 {
+			break
+		}
+		d = d[dot+1:]
+	}
+	return false
+}
+
+// hasMX reports whether the domain has MX records. Lookup failures other
+// than "no such host" are treated as success so DNS hiccups never block a lead.
+func (v *ContactValidator) hasMX(ctx context.Context, domain string) bool {
+	ctx, cancel := context.WithTimeout(ctx, v.mxTimeout)
+	defer cancel()
+
+	mxs, err := v.resolver.LookupMX(ctx, domain)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return false
+		}
+		return true
+	}
+	for _, mx := range mxs {
+		// A single "." host is a null MX (RFC 7505): the domain accepts no mail.
+		if mx.Host != "." {
+			return true
+		}
+	}
+	return false
+}
+
+// SanitizeLine NFC-normalizes s, drops all control characters and trims it.
+func SanitizeLine(s string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || isInvisible(r) {
+			return -1
+		}
+		return r
+	}, norm.NFC.String(s)))
+}
+
+// SanitizeText is SanitizeLine for multi-line input: newlines and tabs are
+// kept and CRLF is folded to LF.
+func SanitizeText(s string) string {
+	s = strings.ReplaceAll(norm.NFC.String(s), "\r\n", "\n")
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if unicode.IsControl(r) || isInvisible(r) {
+			return -1
+		}
+		return r
+	}, s))
+}
+
+// isInvisible matches bidi overrides and zero-width characters often used to
+// disguise content.
+func isInvisible(r rune) bool {
+	switch {
+	case r >= 0x200B && r <= 0x200F, r >= 0x202A && r <= 0x202E, r >= 0x2066 && r <= 0x2069, r == 0xFEFF:
+		return true
+	}
+	return false
+}
+
+// ValidateBooking sanitizes req in place and returns per-field errors, or nil
+// when the booking details are acceptable. The note is optional.
+func (v *ContactValidator) ValidateBooking(ctx context.Context, req *model.BookingRequest) Errors {
+	req.Name = SanitizeLine(req.Name)
+	req.Email = SanitizeLine(req.Email)
+	req.Note = SanitizeText(req.Note)
+
+	errs := Errors{}
+	checkText(errs, "name", req.Name, v.limits.Name)
+	if n := len([]rune(req.Note)); n > v.limits.Message {
+		errs["note"] = fmt.Sprintf("Must be at most %d characters", v.limits.Message)
+	}
+	if req.Start.IsZero() {
+		errs["start"] = "Choose a time slot"
+	}
+
+	if addr, msg := v.checkEmail(ctx, req.Email); msg != "" {
+		errs["email"] = msg
+	} else {
+		req.Email = addr
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// GuestbookMessageMax is the longest guestbook message, in runes.
+const GuestbookMessageMax = 1000
+
+// ValidateGuestbook sanitizes req in place and returns per-field errors, or
+// nil when the entry is acceptable. The website is optional but must be an
+// http or https URL.
+func (v *ContactValidator) ValidateGuestbook(req *model.GuestbookRequest) Errors {
+	req.Name = SanitizeLine(req.Name)
+	req.Message = SanitizeText(req.Message)
+	req.Website = SanitizeLine(req.Website)
+
+	errs := Errors{}
+	checkText(errs, "name", req.Name, v.limits.Name)
+	checkText(errs, "message", req.Message, GuestbookMessageMax)
+	if req.Website != "" {
+		u, err := url.Parse(req.Website)
+		switch {
+		case len(req.Website) > 200:
+			errs["website"] = "Must be at most 200 characters"
+		case err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "":
+			errs["website"] = "Enter a full http:// or https:// address"
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// ValidateEmail sanitizes and checks a lone email address, returning the
+// normalized address or per-field errors.
+func (v *ContactValidator) ValidateEmail(ctx context.Context, raw string) (string, Errors) {
+	addr, msg := v.checkEmail(ctx, SanitizeLine(raw))
+	if msg != "" {
+		return "", Errors{"email": msg}
+	}
+	return addr, nil
+}
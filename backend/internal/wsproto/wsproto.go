@@ -0,0 +1,225 @@
+// Package wsproto defines the envelope protocol spoken on /ws/visitors.
+//
+// Every message is a JSON object
+//
+//	{"type": "presence", "v": 1, "id": "optional-correlation-id", "payload": {...}}
+//
+// Clients open with a "hello" carrying the protocol version and the
+// capabilities they understand; the server answers "welcome" with the
+// capabilities both sides share and only sends message types covered by
+// them. Clients that never say hello are treated as legacy and get the
+// original flat {"count": N} messages. Schema documents the format.
+package wsproto
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Version is the current protocol version. Clients may speak any version
+// from 1 up to it.
+const Version = 1
+
+// Schema is the JSON Schema (draft 2020-12) for all messages.
+//
+//go:embed schema.json
+var Schema []byte
+
+// Message types.
+const (
+	// Client to server.
+	TypeHello = "hello"
+	TypeView  = "view"
+	TypeReact = "react"
+
+	// Server to client.
+	TypeWelcome   = "welcome"
+	TypePresence  = "presence"
+	TypeReactions = "reactions"
+	TypeGuestbook = "guestbook"
+	TypeError     = "error"
+)
+
+// Capabilities a client may announce in hello.
+const (
+	// CapPresence enables room and section counts in presence messages.
+	CapPresence = "presence"
+	// CapGeo enables viewers-by-country counts in presence messages.
+	CapGeo = "geo"
+	// CapReactions enables reactions messages for the item being viewed.
+	CapReactions = "reactions"
+	// CapGuestbook enables guestbook messages for newly approved entries.
+	CapGuestbook = "guestbook"
+)
+
+// ServerCapabilities lists every capability this server supports.
+var ServerCapabilities = []string{CapPresence, CapGeo, CapReactions, CapGuestbook}
+
+// Error codes sent in error payloads.
+const (
+	ErrBadJSON     = "bad_json"
+	ErrBadVersion  = "bad_version"
+	ErrUnknownType = "unknown_type"
+	ErrBadPayload  = "bad_payload"
+	ErrNoHello     = "hello_required"
+	ErrRateLimited = "rate_limited"
+)
+
+// maxIDLength bounds client-chosen correlation IDs.
+const maxIDLength = 64
+
+// MaxReactionCount bounds the count of a single react message.
+const MaxReactionCount = 50
+
+// Envelope wraps every message.
+type Envelope struct {
+	Type    string          `json:"type"`
+	V       int             `json:"v"`
+	ID      string          `json:"id,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Hello is the client's opening message.
+type Hello struct {
+	Client       string   `json:"client,omitempty"`
+	Capabilities []string `json:"capabilities"`
+	// Automated is set by clients that detect they run under automation,
+	// such as navigator.webdriver in browsers; they are not counted.
+	Automated bool `json:"automated,omitempty"`
+}
+
+// Welcome answers hello with the negotiated protocol.
+type Welcome struct {
+	Session      string   `json:"session"`
+	Version      int      `json:"version"`
+	Capabilities []string `json:"capabilities"`
+	// PingInterval is how often the server pings, in seconds.
+	PingInterval int `json:"pingInterval"`
+}
+
+// View says what the client is looking at. An empty field leaves that kind
+// of room; an omitted one keeps the current room. Item names the project or
+// post open in front of the visitor, such as "project:graphql-parser".
+type View struct {
+	Page    *string `json:"page,omitempty"`
+	Section *string `json:"section,omitempty"`
+	Item    *string `json:"item,omitempty"`
+}
+
+// React sends Count reactions (1 when omitted) with Emoji to Item. The
+// server may accept fewer than Count when the client reacts too fast.
+type React struct {
+	Item  string `json:"item"`
+	Emoji string `json:"emoji"`
+	Count int    `json:"count,omitempty"`
+}
+
+// Reactions announces the reactions an item received since the last
+// message (Burst, by emoji) and its all-time Totals.
+type Reactions struct {
+	Item   string         `json:"item"`
+	Burst  map[string]int `json:"burst"`
+	Totals map[string]int `json:"totals"`
+}
+
+// Presence carries visitor counts. Rooms and Sections are only filled for
+// clients with CapPresence, and Countries (keyed by ISO 3166-1 alpha-2 code)
+// for clients with CapGeo.
+type Presence struct {
+	Count     int            `json:"count"`
+	Rooms     map[string]int `json:"rooms,omitempty"`
+	Sections  map[string]int `json:"sections,omitempty"`
+	Countries map[string]int `json:"countries,omitempty"`
+}
+
+// GuestbookEntry announces a guestbook entry that was just approved.
+type GuestbookEntry struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Name      string    `json:"name"`
+	Message   string    `json:"message"`
+	Website   string    `json:"website,omitempty"`
+	Country   string    `json:"country,omitempty"`
+}
+
+// Error reports a rejected client message; Ref echoes its id.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Ref     string `json:"ref,omitempty"`
+}
+
+func (e *Error) Error() string { return e.Code + ": " + e.Message }
+
+// clientPayloads maps each client message type to a constructor for its payload.
+var clientPayloads = map[string]func() any{
+	TypeHello: func() any { return &Hello{} },
+	TypeView:  func() any { return &View{} },
+	TypeReact: func() any { return &React{} },
+}
+
+// Decode parses and validates a client message, returning the envelope and
+// its typed payload (*Hello, *View or *React). Unknown payload fields are allowed so
+// newer clients can talk to older servers.
+func Decode(data []byte) (Envelope, any, *Error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return env, nil, &Error{Code: ErrBadJSON, Message: "message is not a JSON envelope"}
+	}
+	if len(env.ID) > maxIDLength {
+		return env, nil, &Error{Code: ErrBadPayload, Message: fmt.Sprintf("id longer than %d characters", maxIDLength)}
+	}
+	if env.V < 1 || env.V > Version {
+		return env, nil, &Error{
+			Code: ErrBadVersion, Ref: env.ID,
+			Message: fmt.Sprintf("unsupported protocol version %d (supported: 1-%d)", env.V, Version),
+		}
+	}
+	newPayload, ok := clientPayloads[env.Type]
+	if !ok {
+		return env, nil, &Error{Code: ErrUnknownType, Ref: env.ID, Message: fmt.Sprintf("unknown message type %q", env.Type)}
+	}
+
+	payload := newPayload()
+	if len(bytes.TrimSpace(env.Payload)) > 0 {
+		if err := json.Unmarshal(env.Payload, payload); err != nil {
+			return env, nil, &Error{Code: ErrBadPayload, Ref: env.ID, Message: "invalid " + env.Type + " payload: " + err.Error()}
+		}
+	}
+	if h, ok := payload.(*Hello); ok && len(h.Capabilities) > 32 {
+		return env, nil, &Error{Code: ErrBadPayload, Ref: env.ID, Message: "too many capabilities"}
+	}
+	if r, ok := payload.(*React); ok {
+		if r.Count == 0 {
+			r.Count = 1
+		}
+		if r.Count < 0 || r.Count > MaxReactionCount {
+			return env, nil, &Error{Code: ErrBadPayload, Ref: env.ID, Message: fmt.Sprintf("count must be between 1 and %d", MaxReactionCount)}
+		}
+	}
+	return env, payload, nil
+}
+
+// Encode builds a server message of type t.
+func Encode(t, id string, payload any) []byte {
+	raw, _ := json.Marshal(payload)
+	data, _ := json.Marshal(Envelope{Type: t, V: Version, ID: id, Payload: raw})
+	return data
+}
+
+// Negotiate returns the capabilities both the client and server support.
+func Negotiate(client []string) []string {
+	shared := []string{}
+	for _, s := range ServerCapabilities {
+		for _, c := range client {
+			if c == s {
+				shared = append(shared, s)
+				break
+			}
+		}
+	}
+	return shared
+}
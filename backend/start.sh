@@ -22,7 +22,7 @@ echo ""
 echo "Configuration:"
 echo "  Port: ${PORT:-8080}"
 echo "  Email: ${SMTP_USER:-not configured}"
-echo "  To: ${TO_EMAIL:-yadavbhavy25@gmail.com}"
+echo "  To: ${TO_EMAIL:-not configured (required)}"
 echo "  Groq AI: $([ -n "$GROQ_API_KEY" ] && echo 'enabled' || echo 'disabled')"
 echo ""
 
@@ -1142,6 +1142,78 @@ class Animations {
         const modal = document.getElementById('project-modal');
         if (!modal) return;
 
+        // Reactions for the open project; sent and received over the visitor socket
+        const reactionsEl = document.getElementById('project-modal-reactions');
+        let openItem = '';
+        let reactionTotals = {};
+
+        const renderReactions = (emojis) => {
+            if (!reactionsEl) return;
+            reactionsEl.innerHTML = '';
+            emojis.forEach(emoji => {
+                const btn = document.createElement('button');
+                btn.type = 'button';
+                btn.className = 'reaction-btn';
+                btn.dataset.emoji = emoji;
+                btn.innerHTML = `<span>${emoji}</span><span class="reaction-count">${reactionTotals[emoji] || 0}</span>`;
+                btn.addEventListener('click', () => {
+                    if (window.sendReaction) window.sendReaction(openItem, emoji);
+                });
+                reactionsEl.appendChild(btn);
+            });
+        };
+
+        const loadReactions = async (item) => {
+            if (!reactionsEl || typeof getApiUrl !== 'function') return;
+            try {
+                const res = await fetch(`${getApiUrl('reactions')}?item=${encodeURIComponent(item)}`);
+                const json = await res.json();
+                if (item !== openItem || !json.success) return;
+                reactionTotals = json.data.items[0]?.totals || {};
+                renderReactions(json.data.emojis);
+            } catch (e) {}
+        };
+
+        window.addEventListener('visitorreactions', (e) => {
+            const { item, burst, totals } = e.detail;
+            if (item !== openItem || !reactionsEl) return;
+            reactionTotals = totals;
+            Object.entries(burst).forEach(([emoji, n]) => {
+                const btn = reactionsEl.querySelector(`[data-emoji="${emoji}"]`);
+                if (!btn) return;
+                btn.querySelector('.reaction-count').textContent = totals[emoji] || 0;
+                for (let i = 0; i < Math.min(n, 10); i++) {
+                    const float = document.createElement('span');
+                    float.className = 'reaction-float';
+                    float.textContent = emoji;
+                    btn.appendChild(float);
+                    gsap.fromTo(float, { y: 0, x: 0, opacity: 1 }, {
+                        y: -60 - Math.random() * 40,
+                        x: (Math.random() - 0.5) * 40,
+                        opacity: 0,
+                        duration: 1.2,
+                        delay: i * 0.08,
+                        ease: 'power1.out',
+                        onComplete: () => float.remove()
+                    });
+                }
+            });
+        });
+
+        const setOpenItem = (item) => {
+            if (item === openItem) return;
+            openItem = item;
+            reactionTotals = {};
+            if (reactionsEl) reactionsEl.innerHTML = '';
+            window.dispatchEvent(new CustomEvent('itemview', { detail: { item } }));
+            if (item) loadReactions(item);
+        };
+
+        const closeModal = () => {
+            modal.classList.remove('active');
+            setOpenItem('');
+        };
+
         // Make project cards clickable
         document.querySelectorAll('.project-card').forEach(card => {
             card.addEventListener('click', () => {
@@ -1160,6 +1232,7 @@ class Animations {
                         <ul>${project.details.map(d => `<li>${d}</li>`).join('')}</ul>
                     `;
                     modal.classList.add('active');
+                    setOpenItem('project:' + title.toLowerCase().replace(/[^a-z0-9]+/g, '-').replace(/^-|-$/g, ''));
                 }
             });
         });
@@ -1167,16 +1240,16 @@ class Animations {
         // Close modal
         modal.addEventListener('click', (e) => {
             if (e.target === modal) {
-                modal.classList.remove('active');
+                closeModal();
             }
         });
 
-        window.closeProjectModal = () => modal.classList.remove('active');
+        window.closeProjectModal = closeModal;
 
         // Close on escape key
         document.addEventListener('keydown', (e) => {
-            if (e.key === 'Escape') {
-                modal.classList.remove('active');
+            if (e.key === 'Escape' && modal.classList.contains('active')) {
+                closeModal();
             }
         });
     }
@@ -1237,7 +1310,82 @@ class Animations {
         const maxReconnectAttempts = 5;
         let reconnectTimeout = null;
         let activeWs = null;
-        let fallbackInterval = null;
+        let activeStream = null;
+        let pollInterval = null;
+        let currentSection = null;
+        let currentItem = '';
+
+        // Envelope protocol: {type, v, id, payload}; see /api/ws/schema
+        const PROTOCOL_VERSION = 1;
+        const sendMessage = (type, payload) => {
+            if (activeWs && activeWs.readyState === WebSocket.OPEN) {
+                activeWs.send(JSON.stringify({ type, v: PROTOCOL_VERSION, payload }));
+            }
+        };
+        const sendView = (view) => sendMessage('view', view);
+
+        // The project open in the modal; reactions are broadcast to its viewers
+        window.addEventListener('itemview', (e) => {
+            currentItem = e.detail.item;
+            sendView({ item: currentItem });
+        });
+        window.sendReaction = (item, emoji) => sendMessage('react', { item, emoji });
+
+        // Report the section most in view so the server can keep per-section presence
+        if ('IntersectionObserver' in window) {
+            const observer = new IntersectionObserver((entries) => {
+                const visible = entries
+                    .filter(entry => entry.isIntersecting)
+                    .sort((a, b) => b.intersectionRatio - a.intersectionRatio)[0];
+                if (visible && visible.target.id !== currentSection) {
+                    currentSection = visible.target.id;
+                    sendView({ section: currentSection });
+                }
+            }, { threshold: [0.25, 0.5, 0.75] });
+            document.querySelectorAll('section[id]').forEach(section => observer.observe(section));
+        }
+
+        // Today's peak (UTC day) from the history, raised as live counts exceed it
+        const peakEl = document.getElementById('visitor-peak');
+        const peakCountEl = document.getElementById('visitor-peak-count');
+        let peakToday = 0;
+        const showPeak = (count) => {
+            if (!peakEl || count <= peakToday) return;
+            peakToday = count;
+            peakCountEl.textContent = count;
+            peakEl.hidden = false;
+        };
+        const historyPath = typeof CONFIG !== 'undefined' ? CONFIG.API.visitorsHistory : '/api/visitors/history';
+        fetch(`${backendUrl}${historyPath}?range=24h`)
+            .then(res => res.json())
+            .then(body => { if (body.success) showPeak(body.data.today.count); })
+            .catch(() => {});
+
+        // Server messages arrive the same way over WebSocket and the event stream
+        const handleMessage = (data) => {
+            try {
+                const msg = JSON.parse(data);
+                if (msg.type === 'presence') {
+                    visitorCountEl.textContent = msg.payload.count;
+                    showPeak(msg.payload.count);
+                    const countries = Object.entries(msg.payload.countries || {})
+                        .sort((a, b) => b[1] - a[1])
+                        .slice(0, 5)
+                        .map(([code, n]) => `${code} ${n}`);
+                    visitorCountEl.title = countries.length ? `Viewing now: ${countries.join(', ')}` : '';
+                    window.dispatchEvent(new CustomEvent('visitorpresence', { detail: msg.payload }));
+                } else if (msg.type === 'reactions') {
+                    window.dispatchEvent(new CustomEvent('visitorreactions', { detail: msg.payload }));
+                } else if (msg.type === 'guestbook') {
+                    window.dispatchEvent(new CustomEvent('visitorguestbook', { detail: msg.payload }));
+                } else if (msg.type === 'error') {
+                    console.warn('Visitor socket error:', msg.payload.code, msg.payload.message);
+                } else if (msg.count !== undefined) {
+                    // Pre-handshake servers send a bare count
+                    visitorCountEl.textContent = msg.count;
+                }
+            } catch (e) {}
+        };
 
         const connectWebSocket = () => {
             try {
@@ -1246,19 +1394,24 @@ class Animations {
 
                 ws.onopen = () => {
                     reconnectAttempts = 0;
+                    sendMessage('hello', {
+                        client: 'portfolio-web',
+                        capabilities: ['presence', 'geo', 'reactions', 'guestbook'],
+                        // Automated browsers are not counted as visitors
+                        automated: navigator.webdriver === true
+                    });
+                    const view = { page: window.location.pathname };
+                    if (currentSection) view.section = currentSection;
+                    if (currentItem) view.item = currentItem;
+                    sendView(view);
                 };
 
-                ws.onmessage = (event) => {
-                    try {
-                        const data = JSON.parse(event.data);
-                        if (data.count !== undefined) {
-                            visitorCountEl.textContent = data.count;
-                        }
-                    } catch (e) {}
-                };
+                ws.onmessage = (event) => handleMessage(event.data);
 
-                ws.onclose = () => {
+                ws.onclose = (event) => {
                     activeWs = null;
+                    // 1001: the server is restarting; reconnecting is expected to work
+                    if (event.code === 1001) reconnectAttempts = 0;
                     if (reconnectAttempts < maxReconnectAttempts) {
                         const delay = Math.min(1000 * Math.pow(2, reconnectAttempts), 30000);
                         reconnectTimeout = setTimeout(() => {
@@ -1266,33 +1419,62 @@ class Animations {
                             connectWebSocket();
                         }, delay);
                     } else {
-                        simulateFallback();
+                        connectEventStream();
                     }
                 };
 
                 ws.onerror = () => {};
 
             } catch (error) {
-                simulateFallback();
+                connectEventStream();
             }
         };
 
-        // Fallback simulation if WebSocket fails
-        const simulateFallback = () => {
-            let count = Math.floor(Math.random() * 5) + 2;
-            visitorCountEl.textContent = count;
+        // Fallback for networks that block WebSockets: the same messages over
+        // Server-Sent Events. EventSource reconnects by itself and resumes the
+        // session from the last event ID; reactions need the socket.
+        const connectEventStream = () => {
+            if (typeof EventSource === 'undefined') {
+                pollCount();
+                return;
+            }
+            const params = new URLSearchParams({
+                capabilities: 'presence,geo,guestbook',
+                page: window.location.pathname
+            });
+            const streamPath = typeof CONFIG !== 'undefined' ? CONFIG.API.visitorsStream : '/api/visitors/stream';
+            activeStream = new EventSource(`${backendUrl}${streamPath}?${params}`);
+            activeStream.onmessage = (event) => handleMessage(event.data);
+            activeStream.onerror = () => {
+                // CLOSED means the server refused the stream; keep the count fresh by polling
+                if (activeStream.readyState === EventSource.CLOSED) {
+                    activeStream = null;
+                    pollCount();
+                }
+            };
+        };
 
-            fallbackInterval = setInterval(() => {
-                const change = Math.random() > 0.5 ? 1 : -1;
-                count = Math.max(1, Math.min(12, count + change));
-                visitorCountEl.textContent = count;
-            }, 30000 + Math.random() * 30000);
+        // Last resort: read the count periodically without being counted
+        const pollCount = () => {
+            const countPath = typeof CONFIG !== 'undefined' ? CONFIG.API.visitors : '/api/visitors';
+            const refresh = () => fetch(backendUrl + countPath)
+                .then(res => res.json())
+                .then(body => {
+                    if (body.success) {
+                        visitorCountEl.textContent = body.data.count;
+                        showPeak(body.data.count);
+                    }
+                })
+                .catch(() => {});
+            refresh();
+            pollInterval = setInterval(refresh, 60000);
         };
 
         // Cleanup on page unload
         window.addEventListener('beforeunload', () => {
             if (reconnectTimeout) clearTimeout(reconnectTimeout);
-            if (fallbackInterval) clearInterval(fallbackInterval);
+            if (pollInterval) clearInterval(pollInterval);
+            if (activeStream) activeStream.close();
             if (activeWs) activeWs.close();
         });
 
@@ -10,7 +10,13 @@ const CONFIG = {
     API: {
         contact: '/api/contact',
         chat: '/api/chat',
-        health: '/api/health'
+        health: '/api/health',
+        analytics: '/api/analytics/collect',
+        reactions: '/api/reactions',
+        guestbook: '/api/guestbook',
+        visitors: '/api/visitors',
+        visitorsStream: '/api/visitors/stream',
+        visitorsHistory: '/api/visitors/history'
     }
 };
 
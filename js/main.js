@@ -16,6 +16,117 @@ class App {
             this.initResumeAnalyzer();
             this.initScrollReveal();
             this.initFormValidation();
+            this.initAnalytics();
+            this.initGuestbook();
+        });
+    }
+
+    // Guestbook: approved entries, new ones arrive live over the visitor socket
+    initGuestbook() {
+        const form = document.getElementById('guestbook-form');
+        const list = document.getElementById('guestbook-entries');
+        const more = document.getElementById('guestbook-more');
+        const status = document.getElementById('guestbook-status');
+        if (!form || !list) return;
+
+        let page = 0;
+
+        const renderEntry = (entry) => {
+            const el = document.createElement('article');
+            el.className = 'guestbook-entry';
+            el.dataset.id = entry.id;
+
+            const header = document.createElement('div');
+            header.className = 'guestbook-entry-header';
+            let name;
+            if (entry.website) {
+                name = document.createElement('a');
+                name.href = entry.website;
+                name.target = '_blank';
+                name.rel = 'nofollow noopener ugc';
+            } else {
+                name = document.createElement('strong');
+            }
+            name.textContent = entry.name;
+            const time = document.createElement('time');
+            time.dateTime = entry.createdAt;
+            time.textContent = new Date(entry.createdAt).toLocaleDateString();
+            header.append(name, time);
+
+            const message = document.createElement('p');
+            message.textContent = entry.message;
+            el.append(header, message);
+            return el;
+        };
+
+        const loadPage = async () => {
+            try {
+                const res = await fetch(`${getApiUrl('guestbook')}?page=${page + 1}`);
+                const result = await res.json();
+                if (!result.success) return;
+                page = result.data.page;
+                result.data.entries.forEach(entry => {
+                    if (!list.querySelector(`[data-id="${entry.id}"]`)) list.appendChild(renderEntry(entry));
+                });
+                more.hidden = page * result.data.perPage >= result.data.total;
+            } catch (e) {}
+        };
+
+        more.addEventListener('click', loadPage);
+        window.addEventListener('visitorguestbook', (e) => {
+            if (!list.querySelector(`[data-id="${e.detail.id}"]`)) list.prepend(renderEntry(e.detail));
+        });
+
+        form.addEventListener('submit', async (e) => {
+            e.preventDefault();
+            const submitBtn = form.querySelector('.btn-submit');
+            submitBtn.disabled = true;
+            status.textContent = '';
+            this.clearFieldErrors(form);
+
+            try {
+                const res = await fetch(getApiUrl('guestbook'), {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({
+                        name: form.querySelector('#gb-name').value,
+                        website: form.querySelector('#gb-website').value,
+                        message: form.querySelector('#gb-message').value,
+                        nickname: form.querySelector('#gb-nickname').value
+                    })
+                });
+                const result = await res.json();
+                if (!result.success && result.data && result.data.errors) {
+                    const errors = Object.fromEntries(Object.entries(result.data.errors).map(([field, msg]) => [`gb-${field}`, msg]));
+                    this.showFieldErrors(form, errors);
+                } else {
+                    status.textContent = result.message;
+                    if (result.success) form.reset();
+                }
+            } catch (error) {
+                status.textContent = 'Could not reach the guestbook. Please try again later.';
+            }
+            submitBtn.disabled = false;
+        });
+
+        loadPage();
+    }
+
+    // Cookieless analytics: one page view per load, plus resume downloads
+    initAnalytics() {
+        const send = (event) => {
+            const body = JSON.stringify(event);
+            const url = getApiUrl('analytics');
+            // sendBeacon survives navigation; text/plain avoids a CORS preflight
+            if (!navigator.sendBeacon?.(url, new Blob([body], { type: 'text/plain' }))) {
+                fetch(url, { method: 'POST', body, keepalive: true }).catch(() => {});
+            }
+        };
+
+        send({ type: 'pageview', url: location.href, referrer: document.referrer });
+
+        document.getElementById('download-resume')?.addEventListener('click', (e) => {
+            send({ type: 'download', url: location.href, target: e.currentTarget.href });
         });
     }
 
@@ -68,15 +179,37 @@ class App {
 
             try {
                 // Send via Railway backend API
-                const response = await fetch(getApiUrl('contact'), {
-                    method: 'POST',
-                    headers: {
-                        'Content-Type': 'application/json'
-                    },
-                    body: JSON.stringify(formData)
-                });
+                // Files require multipart; plain submissions stay JSON
+                const fileInput = form.querySelector('#attachments');
+                const files = fileInput ? Array.from(fileInput.files) : [];
+                let request;
+                if (files.length > 0) {
+                    const body = new FormData();
+                    Object.entries(formData).forEach(([key, value]) => body.append(key, value));
+                    files.forEach(file => body.append('attachments', file));
+                    request = { method: 'POST', body };
+                } else {
+                    request = {
+                        method: 'POST',
+                        headers: {
+                            'Content-Type': 'application/json'
+                        },
+                        body: JSON.stringify(formData)
+                    };
+                }
+
+                const response = await fetch(getApiUrl('contact'), request);
 
                 const result = await response.json();
+                this.clearFieldErrors(form);
+
+                if (!result.success && result.data && result.data.errors) {
+                    // Field-level validation errors: highlight instead of falling back to mailto
+                    this.showFieldErrors(form, result.data.errors);
+                    submitBtn.innerHTML = originalText;
+                    submitBtn.disabled = false;
+                    return;
+                }
 
                 if (result.success) {
                     // Show success message
@@ -113,6 +246,24 @@ class App {
         });
     }
 
+    showFieldErrors(form, errors) {
+        Object.entries(errors).forEach(([field, message]) => {
+            const input = form.querySelector(`#${field}`);
+            if (!input) return;
+            const group = input.closest('.form-group');
+            group.classList.add('has-error');
+            const hint = document.createElement('small');
+            hint.className = 'field-error';
+            hint.textContent = message;
+            group.appendChild(hint);
+        });
+    }
+
+    clearFieldErrors(form) {
+        form.querySelectorAll('.form-group.has-error').forEach(group => group.classList.remove('has-error'));
+        form.querySelectorAll('.field-error').forEach(hint => hint.remove());
+    }
+
     // GitHub Repositories
     initGitHubRepos() {
         const reposContainer = document.getElementById('github-repos');